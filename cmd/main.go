@@ -1,15 +1,20 @@
 package main
 
 import (
+	"context"
 	"log"
 
+	"github.com/resumelens/authservice/internal/captcha"
 	"github.com/resumelens/authservice/internal/config"
 	"github.com/resumelens/authservice/internal/db"
-	"github.com/resumelens/authservice/internal/gcs"
 	"github.com/resumelens/authservice/internal/handler"
+	"github.com/resumelens/authservice/internal/queue"
 	"github.com/resumelens/authservice/internal/routes"
+	"github.com/resumelens/authservice/internal/scanner"
 	"github.com/resumelens/authservice/internal/services"
+	"github.com/resumelens/authservice/internal/storage"
 	"github.com/resumelens/authservice/internal/utils"
+	"github.com/resumelens/authservice/internal/vector"
 )
 
 func main() {
@@ -20,20 +25,66 @@ func main() {
 
 	db.ConnectDatabase(cfg)
 	utils.InitJWT(cfg)
-	gcs.InitClient(cfg.GoogleProjectID, cfg.GoogleCredentialsFile)
 
+	blobStore, err := storage.NewBlobStore(cfg)
+	if err != nil {
+		log.Fatalf("Storage error: %s", err)
+	}
+	log.Printf("Using %s blob storage backend", cfg.StorageBackend)
+
+	embedder := vector.NewEmbedder(cfg)
+	vectorIndex, err := vector.NewIndex(cfg, db.DB)
+	if err != nil {
+		log.Fatalf("Vector index error: %s", err)
+	}
+	log.Printf("Using %s vector index with %s embeddings", cfg.VectorBackend, embedder.Model())
+
+	fileScanner, err := scanner.New(cfg)
+	if err != nil {
+		log.Fatalf("Scanner error: %s", err)
+	}
+	if clamd, ok := fileScanner.(*scanner.ClamdScanner); ok {
+		if err := clamd.Ping(context.Background()); err != nil {
+			log.Printf("Warning: clamd is not reachable, uploads will stay pending until it is: %v", err)
+		}
+	}
+	log.Printf("Using %s malware scanner", fileScanner.Name())
+
+	jobQueue := queue.New(db.DB, queue.Options{
+		Workers:     cfg.QueueWorkers,
+		MaxAttempts: cfg.QueueMaxAttempts,
+	})
 	// Services
-	jobApplicationService := services.NewJobApplicationService(gcs.GCSClient, cfg.GCSBucketName)
-	authService := services.NewAuthService(cfg)
-	jobHostingService := services.NewJobHostingService(cfg)
+	permissionService := services.NewPermissionService()
+	jobApplicationService := services.NewJobApplicationService(cfg, blobStore, jobQueue)
+	authService := services.NewAuthService(cfg, permissionService, jobQueue)
+	jobHostingService := services.NewJobHostingService(cfg, jobQueue)
+	jobBoardService := services.NewJobBoardService(jobApplicationService)
+	pipelineService := services.NewPipelineService()
+	candidatePortalService := services.NewCandidatePortalService(jobApplicationService)
+	searchService := services.NewSearchService(blobStore, embedder, vectorIndex)
+	queueAdminService := services.NewQueueAdminService(jobQueue)
+	candidateService := services.NewCandidateService(jobQueue)
+
+	// Background work
+	resumeProcessor := services.NewResumeProcessor(blobStore, embedder, vectorIndex)
+	uploadScanner := services.NewUploadScanner(blobStore, fileScanner, jobQueue)
+	services.RegisterTasks(jobQueue, cfg, resumeProcessor, uploadScanner, jobApplicationService, candidateService)
+	jobQueue.Start(context.Background())
 
 	// Handlers
 	jobApplicationHandler := handler.NewJobApplicationHandler(jobApplicationService)
 	authHandler := handler.NewAuthHandler(authService)
 	jobHostingHandler := handler.NewJobHostingHandler(jobHostingService)
+	pipelineHandler := handler.NewPipelineHandler(pipelineService)
+	candidatePortalHandler := handler.NewCandidatePortalHandler(candidatePortalService)
+	searchHandler := handler.NewSearchHandler(searchService)
+	queueAdminHandler := handler.NewQueueAdminHandler(queueAdminService)
+	candidateHandler := handler.NewCandidateHandler(candidateService)
+	jobBoardHandler := handler.NewJobBoardHandler(jobBoardService, captcha.NewVerifier(cfg.CaptchaVerifyURL, cfg.CaptchaSecret))
 
 	// Routes
-	r := routes.SetupRouter(jobApplicationHandler, authHandler, jobHostingHandler)
+	r := routes.SetupRouter(cfg, jobApplicationHandler, authHandler, jobHostingHandler, jobBoardHandler, pipelineHandler, candidatePortalHandler, searchHandler, queueAdminHandler, candidateHandler, permissionService)
 
 	port := cfg.Port
 	if port == "" {
@@ -0,0 +1,90 @@
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var ErrVerificationFailed = errors.New("captcha verification failed")
+
+// Verifier checks a CAPTCHA response token submitted by a browser.
+type Verifier interface {
+	Verify(ctx context.Context, token, remoteIP string) error
+}
+
+// NoopVerifier accepts every request. It is used when no CAPTCHA secret is
+// configured.
+type NoopVerifier struct{}
+
+func (NoopVerifier) Verify(ctx context.Context, token, remoteIP string) error {
+	return nil
+}
+
+// SiteVerifyVerifier talks the "siteverify" protocol shared by reCAPTCHA,
+// hCaptcha and Cloudflare Turnstile: a form POST of secret, response and
+// remoteip answered with {"success": bool}.
+type SiteVerifyVerifier struct {
+	verifyURL  string
+	secret     string
+	httpClient *http.Client
+}
+
+func NewSiteVerifyVerifier(verifyURL, secret string) *SiteVerifyVerifier {
+	return &SiteVerifyVerifier{
+		verifyURL:  verifyURL,
+		secret:     secret,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (v *SiteVerifyVerifier) Verify(ctx context.Context, token, remoteIP string) error {
+	if token == "" {
+		return ErrVerificationFailed
+	}
+
+	form := url.Values{
+		"secret":   {v.secret},
+		"response": {token},
+	}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.verifyURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("captcha verify request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Success bool `json:"success"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("captcha verify response: %w", err)
+	}
+	if !result.Success {
+		return ErrVerificationFailed
+	}
+	return nil
+}
+
+// NewVerifier returns a SiteVerifyVerifier when a secret is configured and a
+// NoopVerifier otherwise.
+func NewVerifier(verifyURL, secret string) Verifier {
+	if secret == "" {
+		return NoopVerifier{}
+	}
+	return NewSiteVerifyVerifier(verifyURL, secret)
+}
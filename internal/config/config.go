@@ -2,12 +2,16 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"strings"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
 	Port                  string `mapstructure:"PORT"`
+	StorageBackend        string `mapstructure:"STORAGE_BACKEND"`
+	LocalStoragePath      string `mapstructure:"LOCAL_STORAGE_PATH"`
 	GCSBucketName         string `mapstructure:"GCS_BUCKET_NAME"`
 	GoogleProjectID       string `mapstructure:"GOOGLE_PROJECT_ID"`
 	GoogleCredentialsFile string `mapstructure:"GOOGLE_APPLICATION_CREDENTIALS"`
@@ -16,11 +20,40 @@ type Config struct {
 	JWTSecret   string `mapstructure:"JWT_SECRET"`
 	JWTExpiry   int    `mapstructure:"JWT_EXPIRY"`
 
+	RefreshTokenExpiry int `mapstructure:"REFRESH_TOKEN_EXPIRY"` // hours
+
 	SMTPHost       string `mapstructure:"SMTP_HOST"`
 	SMTPPort       string `mapstructure:"SMTP_PORT"`
 	SMTPUser       string `mapstructure:"SMTP_USER"`
 	SMTPPass       string `mapstructure:"SMTP_PASS"`
 	SMTPSenderName string `mapstructure:"SMTP_SENDER_NAME"`
+
+	PublicRateLimit  int    `mapstructure:"PUBLIC_RATE_LIMIT"` // requests per minute per IP
+	ApplyRateLimit   int    `mapstructure:"APPLY_RATE_LIMIT"`  // applications per minute per IP
+	CaptchaSecret    string `mapstructure:"CAPTCHA_SECRET"`
+	CaptchaVerifyURL string `mapstructure:"CAPTCHA_VERIFY_URL"`
+
+	// TrustedProxies lists the IPs and CIDRs of reverse proxies whose
+	// X-Forwarded-For is believed. Empty means clients connect directly.
+	TrustedProxies []string `mapstructure:"TRUSTED_PROXIES"`
+
+	MagicLinkExpiryDays int `mapstructure:"MAGIC_LINK_EXPIRY_DAYS"`
+
+	QueueWorkers     int `mapstructure:"QUEUE_WORKERS"`
+	QueueMaxAttempts int `mapstructure:"QUEUE_MAX_ATTEMPTS"`
+
+	UploadMaxFileMB    int64 `mapstructure:"UPLOAD_MAX_FILE_MB"`
+	UploadMaxRequestMB int64 `mapstructure:"UPLOAD_MAX_REQUEST_MB"`
+	ImportMaxArchiveMB int64 `mapstructure:"IMPORT_MAX_ARCHIVE_MB"`
+
+	DownloadURLExpiryMinutes int `mapstructure:"DOWNLOAD_URL_EXPIRY_MINUTES"`
+
+	ScannerBackend     string `mapstructure:"SCANNER_BACKEND"`
+	ClamdAddress       string `mapstructure:"CLAMD_ADDRESS"`
+	ScanTimeoutSeconds int    `mapstructure:"SCAN_TIMEOUT_SECONDS"`
+
+	VectorBackend       string `mapstructure:"VECTOR_BACKEND"`
+	EmbeddingDimensions int    `mapstructure:"EMBEDDING_DIMENSIONS"`
 }
 
 func LoadConfig() (*Config, error) {
@@ -39,6 +72,27 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("unable to decode into struct: %w", err)
 	}
 
+	// TRUSTED_PROXIES is comma-separated.
+	for i, proxy := range config.TrustedProxies {
+		config.TrustedProxies[i] = strings.TrimSpace(proxy)
+	}
+
+	if config.StorageBackend == "" {
+		config.StorageBackend = "gcs"
+	}
+	if config.LocalStoragePath == "" {
+		config.LocalStoragePath = "./data/blobs"
+	}
+	if config.VectorBackend == "" {
+		config.VectorBackend = "postgres"
+	}
+	if config.ScannerBackend == "" {
+		config.ScannerBackend = "none"
+	}
+	if config.EmbeddingDimensions == 0 {
+		config.EmbeddingDimensions = 512
+	}
+
 	// Validate required fields
 	if err := validateConfig(&config); err != nil {
 		return nil, fmt.Errorf("config validation failed: %w", err)
@@ -51,17 +105,87 @@ func LoadConfig() (*Config, error) {
 	if config.JWTExpiry == 0 {
 		config.JWTExpiry = 60
 	}
+	if config.RefreshTokenExpiry == 0 {
+		config.RefreshTokenExpiry = 24 * 30
+	}
+	if config.PublicRateLimit == 0 {
+		config.PublicRateLimit = 60
+	}
+	if config.ApplyRateLimit == 0 {
+		config.ApplyRateLimit = 5
+	}
+	if config.MagicLinkExpiryDays == 0 {
+		config.MagicLinkExpiryDays = 90
+	}
+	if config.QueueWorkers == 0 {
+		config.QueueWorkers = 4
+	}
+	if config.QueueMaxAttempts == 0 {
+		config.QueueMaxAttempts = 5
+	}
+	if config.UploadMaxFileMB == 0 {
+		config.UploadMaxFileMB = 10
+	}
+	if config.UploadMaxRequestMB == 0 {
+		config.UploadMaxRequestMB = 25
+	}
+	if config.ImportMaxArchiveMB == 0 {
+		config.ImportMaxArchiveMB = 200
+	}
+	if config.DownloadURLExpiryMinutes == 0 {
+		config.DownloadURLExpiryMinutes = 5
+	}
+	if config.ClamdAddress == "" {
+		config.ClamdAddress = "tcp://localhost:3310"
+	}
+	if config.ScanTimeoutSeconds == 0 {
+		config.ScanTimeoutSeconds = 60
+	}
+	if config.CaptchaVerifyURL == "" {
+		config.CaptchaVerifyURL = "https://www.google.com/recaptcha/api/siteverify"
+	}
 
 	return &config, nil
 }
 
 func validateConfig(cfg *Config) error {
 	required := map[string]string{
-		"GCS_BUCKET_NAME":                cfg.GCSBucketName,
-		"GOOGLE_PROJECT_ID":              cfg.GoogleProjectID,
-		"GOOGLE_APPLICATION_CREDENTIALS": cfg.GoogleCredentialsFile,
-		"DB_URL":                         cfg.DatabaseURL,
-		"JWT_SECRET":                     cfg.JWTSecret,
+		"DB_URL":     cfg.DatabaseURL,
+		"JWT_SECRET": cfg.JWTSecret,
+	}
+
+	switch cfg.StorageBackend {
+	case "gcs":
+		required["GCS_BUCKET_NAME"] = cfg.GCSBucketName
+		required["GOOGLE_PROJECT_ID"] = cfg.GoogleProjectID
+		required["GOOGLE_APPLICATION_CREDENTIALS"] = cfg.GoogleCredentialsFile
+	case "local", "memory":
+	default:
+		return fmt.Errorf("STORAGE_BACKEND must be one of gcs, local, memory")
+	}
+
+	switch cfg.VectorBackend {
+	case "postgres", "memory":
+	default:
+		return fmt.Errorf("VECTOR_BACKEND must be one of postgres, memory")
+	}
+
+	switch cfg.ScannerBackend {
+	case "none", "clamd":
+	default:
+		return fmt.Errorf("SCANNER_BACKEND must be one of none, clamd")
+	}
+
+	if cfg.EmbeddingDimensions <= 0 {
+		return fmt.Errorf("EMBEDDING_DIMENSIONS must be a positive number")
+	}
+
+	for _, proxy := range cfg.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				return fmt.Errorf("TRUSTED_PROXIES entry %q is not an IP address or CIDR", proxy)
+			}
+		}
 	}
 
 	for name, value := range required {
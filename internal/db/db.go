@@ -3,6 +3,7 @@ package db
 import (
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/resumelens/authservice/internal/config"
 	"github.com/resumelens/authservice/internal/models"
@@ -23,22 +24,101 @@ func ConnectDatabase(cfg *config.Config) {
 
 	fmt.Println("Database connected.")
 
-	migrateDatabase()
+	migrateDatabase(cfg)
 }
 
-func migrateDatabase() {
+func migrateDatabase(cfg *config.Config) {
 	err := DB.AutoMigrate(
 		&models.User{},
+		&models.RefreshToken{},
 		&models.Organization{},
 		&models.Invite{},
 		&models.Candidate{},
+		&models.CandidateIdentity{},
+		&models.CandidateDuplicate{},
+		&models.CandidateNote{},
 		&models.JobApplication{},
+		&models.DocumentVersion{},
+		&models.DocumentAccessLog{},
+		&models.QuarantinedFile{},
+		&models.Embedding{},
+		&models.QueueJob{},
+		&models.DeadLetterJob{},
+		&models.PipelineStage{},
+		&models.ApplicationStageHistory{},
+		&models.ApplicationRating{},
 		&models.Role{},
 		&models.Job{},
+		&models.JobAuditLog{},
 		&models.JobAnalytics{},
 	)
 	if err != nil {
 		log.Fatalf("Database migration failed: %v", err)
 	}
+
+	// Jobs used to carry a single is_active flag; fold it into status.
+	if DB.Migrator().HasColumn(&models.Job{}, "is_active") {
+		if err := DB.Exec(`UPDATE jobs SET status = 'closed' WHERE is_active = false`).Error; err != nil {
+			log.Fatalf("Database migration failed: %v", err)
+		}
+		if err := DB.Migrator().DropColumn(&models.Job{}, "is_active"); err != nil {
+			log.Fatalf("Database migration failed: %v", err)
+		}
+	}
+
+	// Applications used to start in a free-form 'pending' status.
+	if err := DB.Exec(`UPDATE job_applications SET status = 'applied' WHERE status = 'pending'`).Error; err != nil {
+		log.Fatalf("Database migration failed: %v", err)
+	}
+
+	// Magic links used to never expire; give those issued before expiry
+	// existed the usual lifetime from when they were sent.
+	if err := DB.Exec(`UPDATE job_applications SET magic_link_expires_at = COALESCE(created_at, now()) + make_interval(days => ?) WHERE magic_link_expires_at IS NULL`,
+		cfg.MagicLinkExpiryDays).Error; err != nil {
+		log.Fatalf("Database migration failed: %v", err)
+	}
+
+	// Jobs created before slugs existed; keep in sync with services.jobSlug.
+	if err := DB.Exec(`UPDATE jobs SET slug = coalesce(nullif(trim(both '-' from left(trim(both '-' from regexp_replace(lower(title), '[^a-z0-9]+', '-', 'g')), 60)), ''), 'job') || '-' || left(id::text, 8) WHERE slug IS NULL OR slug = ''`).Error; err != nil {
+		log.Fatalf("Database migration failed: %v", err)
+	}
+
+	// Expression index backing full-text job search; keep in sync with
+	// services.jobSearchVector.
+	if err := DB.Exec(`CREATE INDEX IF NOT EXISTS idx_jobs_search ON jobs USING GIN (to_tsvector('english', coalesce(title, '') || ' ' || coalesce(description, '')))`).Error; err != nil {
+		log.Fatalf("Database migration failed: %v", err)
+	}
+	// Candidate emails are unique per organization, ignoring case. Older
+	// databases may already hold duplicates; the service relies on the index,
+	// so it refuses to start until they are merged.
+	if err := checkDuplicateCandidateEmails(); err != nil {
+		log.Fatalf("Database migration failed: %v", err)
+	}
+	if err := DB.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_candidates_org_email ON candidates (organization_id, lower(email))`).Error; err != nil {
+		log.Fatalf("Database migration failed: %v", err)
+	}
 	fmt.Println("Database migrated successfully.")
 }
+
+// checkDuplicateCandidateEmails reports organizations that have more than
+// one candidate with the same email, naming a few of them.
+func checkDuplicateCandidateEmails() error {
+	var duplicates []struct {
+		OrganizationID string
+		Email          string
+		CandidateIDs   string
+	}
+	err := DB.Raw(`SELECT organization_id, lower(email) AS email, string_agg(id::text, ', ' ORDER BY created_at, id) AS candidate_ids
+		FROM candidates GROUP BY organization_id, lower(email) HAVING count(*) > 1
+		ORDER BY organization_id, lower(email) LIMIT 20`).Scan(&duplicates).Error
+	if err != nil || len(duplicates) == 0 {
+		return err
+	}
+	lines := make([]string, len(duplicates))
+	for i, d := range duplicates {
+		lines[i] = fmt.Sprintf("  organization %s, %s: candidates %s", d.OrganizationID, d.Email, d.CandidateIDs)
+	}
+	return fmt.Errorf("candidate emails must be unique within an organization, but these are not (first 20 shown):\n%s\n"+
+		"Merge each group with POST /api/v1/candidates/:id/merge on the previous release, then start this one again",
+		strings.Join(lines, "\n"))
+}
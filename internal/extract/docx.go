@@ -0,0 +1,91 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxDOCXPartSize caps how much of word/document.xml is read, guarding
+// against compressed parts that inflate to absurd sizes.
+const maxDOCXPartSize = 32 << 20
+
+func extractDOCX(data []byte) (*Result, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("extract: invalid docx: %w", err)
+	}
+
+	var document *zip.File
+	for _, f := range archive.File {
+		if f.Name == "word/document.xml" {
+			document = f
+			break
+		}
+	}
+	if document == nil {
+		return nil, fmt.Errorf("extract: invalid docx: word/document.xml missing")
+	}
+	if document.Flags&0x1 != 0 {
+		return nil, ErrEncrypted
+	}
+
+	rc, err := document.Open()
+	if err != nil {
+		return nil, fmt.Errorf("extract: invalid docx: %w", err)
+	}
+	defer rc.Close()
+
+	text, err := docxText(io.LimitReader(rc, maxDOCXPartSize))
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Format: FormatDOCX, Text: text, Pages: 1}, nil
+}
+
+// docxText walks WordprocessingML and keeps run text, turning paragraphs,
+// breaks and tabs into their plain-text equivalents.
+func docxText(r io.Reader) (string, error) {
+	var b strings.Builder
+	decoder := xml.NewDecoder(r)
+	inText := false
+
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("extract: invalid docx xml: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				b.WriteByte('\t')
+			case "br", "cr":
+				b.WriteByte('\n')
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p", "tr":
+				b.WriteByte('\n')
+			case "tc":
+				b.WriteByte('\t')
+			}
+		case xml.CharData:
+			if inText {
+				b.Write(t)
+			}
+		}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,91 @@
+// Package extract pulls plain text out of uploaded documents. It is pure Go
+// and only understands the formats candidates commonly upload.
+package extract
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Supported formats.
+const (
+	FormatPDF  = "pdf"
+	FormatDOCX = "docx"
+	FormatTXT  = "txt"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("extract: unsupported document format")
+	ErrEncrypted         = errors.New("extract: document is encrypted")
+	ErrNoText            = errors.New("extract: document contains no extractable text")
+)
+
+// Result is the outcome of a successful extraction.
+type Result struct {
+	Format string
+	Text   string
+	Pages  int
+}
+
+// DetectFormat identifies a document from its leading bytes, falling back to
+// the file extension for plain text.
+func DetectFormat(filename string, data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte("%PDF-")):
+		return FormatPDF
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")) && strings.EqualFold(filepath.Ext(filename), ".docx"):
+		return FormatDOCX
+	case strings.EqualFold(filepath.Ext(filename), ".txt") && utf8.Valid(data):
+		return FormatTXT
+	}
+	return ""
+}
+
+// Text extracts the text of a document.
+func Text(filename string, data []byte) (*Result, error) {
+	var result *Result
+	var err error
+
+	switch DetectFormat(filename, data) {
+	case FormatPDF:
+		result, err = extractPDF(data)
+	case FormatDOCX:
+		result, err = extractDOCX(data)
+	case FormatTXT:
+		result = &Result{Format: FormatTXT, Text: string(data), Pages: 1}
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result.Text = normalize(result.Text)
+	if result.Text == "" {
+		return nil, ErrNoText
+	}
+	return result, nil
+}
+
+var (
+	horizontalSpace = regexp.MustCompile(`[ \t\x{00a0}]+`)
+	blankLines      = regexp.MustCompile(`\n{3,}`)
+)
+
+// normalize collapses runs of spaces and blank lines and trims every line.
+func normalize(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	text = strings.ToValidUTF8(text, "")
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(horizontalSpace.ReplaceAllString(line, " "))
+	}
+	text = strings.Join(lines, "\n")
+	return strings.TrimSpace(blankLines.ReplaceAllString(text, "\n\n"))
+}
@@ -0,0 +1,144 @@
+package extract
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readTestdata(t testing.TB, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestTextSamples(t *testing.T) {
+	tests := []struct {
+		file   string
+		format string
+		pages  int
+		want   string
+	}{
+		{"resume.pdf", FormatPDF, 1, "Jane Doe\nSenior Go Engineer (remote)\nSkills: Go, Postgres, Kubernetes"},
+		{"resume_compressed.pdf", FormatPDF, 2, "Élodie martin\ndata engineer\n\nreferences"},
+		{"resume.docx", FormatDOCX, 1, "Jane Doe\nSenior Go Engineer\nPhone 555-0100\n2019\nAcme & Co\n\nLine one\nLine two"},
+		{"resume.txt", FormatTXT, 1, "Jane Doe\n\nSenior Go Engineer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			result, err := Text(tt.file, readTestdata(t, tt.file))
+			if err != nil {
+				t.Fatalf("Text: %v", err)
+			}
+			if result.Format != tt.format || result.Pages != tt.pages {
+				t.Errorf("format, pages = %q, %d; want %q, %d", result.Format, result.Pages, tt.format, tt.pages)
+			}
+			if result.Text != tt.want {
+				t.Errorf("text = %q; want %q", result.Text, tt.want)
+			}
+		})
+	}
+}
+
+// pdfWithContent wraps a content stream in a minimal one-page document.
+func pdfWithContent(content string) []byte {
+	return []byte(fmt.Sprintf("%%PDF-1.4\n"+
+		"1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj\n"+
+		"2 0 obj << /Type /Pages /Kids [3 0 R] >> endobj\n"+
+		"3 0 obj << /Type /Page /Contents 4 0 R >> endobj\n"+
+		"4 0 obj << /Length %d >>\nstream\n%s\nendstream\nendobj\n", len(content), content))
+}
+
+// pdfWithObjectStream packs the page tree of pdfWithContent into an object
+// stream with the given /First and header.
+func pdfWithObjectStream(first, header string) []byte {
+	body := header + " << /Type /Catalog /Pages 2 0 R >> << /Type /Pages /Kids [3 0 R] >>"
+	return []byte(fmt.Sprintf("%%PDF-1.5\n"+
+		"3 0 obj << /Type /Page /Contents 4 0 R >> endobj\n"+
+		"4 0 obj << /Length 14 >>\nstream\nBT (Hello) Tj ET\nendstream\nendobj\n"+
+		"5 0 obj << /Type /ObjStm /N 2 /First %s /Length %d >>\nstream\n%s\nendstream\nendobj\n", first, len(body), body))
+}
+
+func TestTextMalformed(t *testing.T) {
+	deepArrays := append([]byte("%PDF-1.4\n1 0 obj\n"), bytes.Repeat([]byte("["), 5<<20)...)
+	deepDicts := append([]byte("%PDF-1.4\n1 0 obj\n"), bytes.Repeat([]byte("<< /A "), 1<<20)...)
+	strays := append([]byte("%PDF-1.4\n1 0 obj\n"), bytes.Repeat([]byte(")>"), 1<<20)...)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr error  // checked when want is empty; nil accepts any error
+		want    string // expected text when extraction succeeds
+	}{
+		{name: "deeply nested arrays", data: deepArrays, wantErr: errPDFNesting},
+		{name: "deeply nested dictionaries", data: deepDicts, wantErr: errPDFNesting},
+		{name: "deeply nested content operands", data: pdfWithContent("BT " + strings.Repeat("[", 10000) + " ET"), wantErr: errPDFNesting},
+		{name: "stray delimiters", data: strays},
+		{name: "length beyond int range", data: bytes.Replace(pdfWithContent("BT (Hello) Tj ET"), []byte("/Length 16"), []byte("/Length 1e300"), 1), want: "Hello"},
+		{name: "huge length", data: bytes.Replace(pdfWithContent("BT (Hello) Tj ET"), []byte("/Length 16"), []byte("/Length 9223372036854775807"), 1), want: "Hello"},
+		{name: "infinite length", data: bytes.Replace(pdfWithContent("BT (Hello) Tj ET"), []byte("/Length 16"), []byte("/Length +Inf"), 1), want: "Hello"},
+		{name: "negative length", data: bytes.Replace(pdfWithContent("BT (Hello) Tj ET"), []byte("/Length 16"), []byte("/Length -5"), 1), want: "Hello"},
+		{name: "unterminated hex string", data: []byte("%PDF-1.4\n1 0 obj << /A <414")},
+		{name: "ascii85 zeros", data: bytes.Replace(pdfWithContent("zzzz6<#'U87cURD^cf.C*5rE~>"), []byte("/Length"), []byte("/Filter /A85 /Length"), 1), want: "Hello"},
+		{name: "object stream", data: pdfWithObjectStream("8", "1 0 2 34"), want: "Hello"},
+		{name: "negative first", data: pdfWithObjectStream("-8", "1 0 2 34"), want: "Hello"},
+		{name: "first beyond stream", data: pdfWithObjectStream("1e300", "1 0 2 34"), want: "Hello"},
+		{name: "negative offset", data: pdfWithObjectStream("8", "1 -9 2 -1000000"), want: "Hello"},
+		{name: "offset beyond stream", data: pdfWithObjectStream("8", "1 0 2 1e300"), want: "Hello"},
+		{name: "encrypted", data: readTestdata(t, "encrypted.pdf"), wantErr: ErrEncrypted},
+		{name: "no text", data: pdfWithContent("0 0 m 10 10 l S"), wantErr: ErrNoText},
+		{name: "truncated docx", data: readTestdata(t, "resume.docx")[:200]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := "resume.pdf"
+			if bytes.HasPrefix(tt.data, []byte("PK")) {
+				name = "resume.docx"
+			}
+			result, err := Text(name, tt.data)
+			switch {
+			case tt.want != "":
+				if err != nil {
+					t.Fatalf("Text: %v", err)
+				}
+				if result.Text != tt.want {
+					t.Errorf("text = %q; want %q", result.Text, tt.want)
+				}
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("err = %v; want %v", err, tt.wantErr)
+				}
+			case err == nil:
+				t.Errorf("Text succeeded with %q; want an error", result.Text)
+			}
+		})
+	}
+}
+
+func TestTextUnsupported(t *testing.T) {
+	for _, name := range []string{"resume.doc", "photo.png", "resume.txt"} {
+		if _, err := Text(name, []byte{0xff, 0xfe, 0x00}); !errors.Is(err, ErrUnsupportedFormat) {
+			t.Errorf("Text(%q) err = %v; want ErrUnsupportedFormat", name, err)
+		}
+	}
+}
+
+func FuzzText(f *testing.F) {
+	for _, file := range []string{"resume.pdf", "resume_compressed.pdf", "encrypted.pdf", "resume.docx"} {
+		f.Add(file, readTestdata(f, file))
+	}
+	f.Add("resume.pdf", pdfWithObjectStream("8", "1 0 2 34"))
+	f.Fuzz(func(t *testing.T, name string, data []byte) {
+		result, err := Text(name, data)
+		if err == nil && result.Text == "" {
+			t.Errorf("Text returned no text and no error")
+		}
+	})
+}
@@ -0,0 +1,704 @@
+package extract
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"encoding/ascii85"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	// maxPDFStreamSize bounds a single decoded stream.
+	maxPDFStreamSize = 64 << 20
+	// maxPDFPages bounds how many pages are read; resumes are short.
+	maxPDFPages = 100
+	// maxFormDepth bounds recursion through nested form XObjects.
+	maxFormDepth = 8
+)
+
+var pdfObjectHeader = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+
+// pdfDocument is a loosely parsed PDF. Objects are located by scanning for
+// "n g obj" headers instead of trusting the xref table, which makes the
+// reader tolerant of the damaged files candidates sometimes upload.
+type pdfDocument struct {
+	objects map[int]interface{}
+	cmaps   map[int]*toUnicodeMap
+}
+
+func extractPDF(data []byte) (*Result, error) {
+	doc := &pdfDocument{
+		objects: make(map[int]interface{}),
+		cmaps:   make(map[int]*toUnicodeMap),
+	}
+	if err := doc.load(data); err != nil {
+		return nil, err
+	}
+
+	if doc.encrypted(data) {
+		return nil, ErrEncrypted
+	}
+
+	pages := doc.pages()
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("extract: invalid pdf: no pages found")
+	}
+
+	var b strings.Builder
+	for i, page := range pages {
+		if i >= maxPDFPages {
+			break
+		}
+		w := &textWriter{}
+		resources, _ := doc.resolve(page[pdfName("Resources")]).(pdfDict)
+		for _, content := range doc.pageContents(page) {
+			if err := doc.runContent(w, content, resources, 0); err != nil {
+				return nil, err
+			}
+		}
+		b.WriteString(w.String())
+		b.WriteString("\n\n")
+	}
+
+	return &Result{Format: FormatPDF, Text: b.String(), Pages: len(pages)}, nil
+}
+
+func (d *pdfDocument) load(data []byte) error {
+	for _, m := range pdfObjectHeader.FindAllSubmatchIndex(data, -1) {
+		var num int
+		fmt.Sscanf(string(data[m[2]:m[3]]), "%d", &num)
+
+		l := &pdfLexer{data: data, pos: m[1]}
+		obj, ok := l.object(0)
+		if l.err != nil {
+			return l.err
+		}
+		if !ok {
+			continue
+		}
+		if dict, isDict := obj.(pdfDict); isDict {
+			if raw, ok := streamBody(data, l.pos, dict); ok {
+				obj = &pdfStream{dict: dict, raw: raw}
+			}
+		}
+		// Later definitions win, which is how incremental updates work.
+		d.objects[num] = obj
+	}
+
+	// Objects packed into object streams (PDF 1.5+).
+	for _, obj := range d.objects {
+		stream, ok := obj.(*pdfStream)
+		if !ok || stream.dict[pdfName("Type")] != pdfName("ObjStm") {
+			continue
+		}
+		if err := d.loadObjectStream(stream); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pdfOffset converts a length or offset read from the file to an int,
+// reporting false unless 0 <= v <= limit.
+func pdfOffset(v interface{}, limit int) (int, bool) {
+	f, ok := v.(float64)
+	if !ok || !(f >= 0 && f <= float64(limit)) {
+		return 0, false
+	}
+	return int(f), true
+}
+
+// streamBody returns the raw bytes of a stream whose dictionary ended at pos.
+func streamBody(data []byte, pos int, dict pdfDict) ([]byte, bool) {
+	l := &pdfLexer{data: data, pos: pos}
+	l.skipSpace()
+	if !bytes.HasPrefix(data[l.pos:], []byte("stream")) {
+		return nil, false
+	}
+	start := l.pos + len("stream")
+	if start < len(data) && data[start] == '\r' {
+		start++
+	}
+	if start < len(data) && data[start] == '\n' {
+		start++
+	}
+
+	if start > len(data) {
+		return nil, false
+	}
+	if length, ok := pdfOffset(dict[pdfName("Length")], len(data)-start); ok {
+		end := start + length
+		if bytes.Contains(data[end:min(end+32, len(data))], []byte("endstream")) {
+			return data[start:end], true
+		}
+	}
+	end := bytes.Index(data[start:], []byte("endstream"))
+	if end < 0 {
+		return data[start:], true
+	}
+	return bytes.TrimRight(data[start:start+end], "\r\n"), true
+}
+
+// loadObjectStream adds the objects packed into stream. Object numbers
+// already defined by the file body are left alone.
+func (d *pdfDocument) loadObjectStream(stream *pdfStream) error {
+	data, err := d.decode(stream)
+	if err != nil {
+		return nil
+	}
+	first, ok := pdfOffset(stream.dict[pdfName("First")], len(data))
+	if !ok {
+		return nil
+	}
+	n, _ := pdfOffset(stream.dict[pdfName("N")], first)
+
+	header := &pdfLexer{data: data[:first]}
+	for i := 0; i < n; i++ {
+		numTok, ok1 := header.token()
+		offTok, ok2 := header.token()
+		if !ok1 || !ok2 {
+			return nil
+		}
+		num, isNum := numTok.(float64)
+		off, ok := pdfOffset(offTok, len(data)-first)
+		if !isNum || !ok {
+			continue
+		}
+		if _, exists := d.objects[int(num)]; exists {
+			continue
+		}
+		l := &pdfLexer{data: data, pos: first + off}
+		obj, ok := l.object(0)
+		if l.err != nil {
+			return l.err
+		}
+		if ok {
+			d.objects[int(num)] = obj
+		}
+	}
+	return nil
+}
+
+func (d *pdfDocument) resolve(obj interface{}) interface{} {
+	for i := 0; i < 32; i++ {
+		ref, ok := obj.(pdfRef)
+		if !ok {
+			return obj
+		}
+		obj = d.objects[ref.num]
+	}
+	return nil
+}
+
+func (d *pdfDocument) dict(obj interface{}) pdfDict {
+	switch v := d.resolve(obj).(type) {
+	case pdfDict:
+		return v
+	case *pdfStream:
+		return v.dict
+	}
+	return nil
+}
+
+func (d *pdfDocument) encrypted(data []byte) bool {
+	for _, obj := range d.objects {
+		if s, ok := obj.(*pdfStream); ok && s.dict[pdfName("Type")] == pdfName("XRef") {
+			if _, has := s.dict[pdfName("Encrypt")]; has {
+				return true
+			}
+		}
+	}
+	idx := bytes.LastIndex(data, []byte("trailer"))
+	if idx < 0 {
+		return false
+	}
+	l := &pdfLexer{data: data, pos: idx + len("trailer")}
+	trailer, _ := l.object(0)
+	if dict, ok := trailer.(pdfDict); ok {
+		_, has := dict[pdfName("Encrypt")]
+		return has
+	}
+	return false
+}
+
+// pages walks the page tree from the catalog, falling back to every
+// /Type /Page object when the tree is unusable.
+func (d *pdfDocument) pages() []pdfDict {
+	var pages []pdfDict
+	seen := make(map[int]bool)
+
+	var walk func(node interface{}, inherited pdfDict, depth int)
+	walk = func(node interface{}, inherited pdfDict, depth int) {
+		if ref, ok := node.(pdfRef); ok {
+			if seen[ref.num] {
+				return
+			}
+			seen[ref.num] = true
+		}
+		dict := d.dict(node)
+		if dict == nil || depth > 64 || len(pages) >= maxPDFPages {
+			return
+		}
+		if _, ok := dict[pdfName("Resources")]; !ok && inherited != nil {
+			merged := make(pdfDict, len(dict)+1)
+			for k, v := range dict {
+				merged[k] = v
+			}
+			merged[pdfName("Resources")] = inherited
+			dict = merged
+		}
+		switch dict[pdfName("Type")] {
+		case pdfName("Pages"):
+			res, _ := d.resolve(dict[pdfName("Resources")]).(pdfDict)
+			kids, _ := d.resolve(dict[pdfName("Kids")]).(pdfArray)
+			for _, kid := range kids {
+				walk(kid, res, depth+1)
+			}
+		case pdfName("Page"):
+			pages = append(pages, dict)
+		}
+	}
+
+	for _, obj := range d.objects {
+		if dict, ok := obj.(pdfDict); ok && dict[pdfName("Type")] == pdfName("Catalog") {
+			walk(dict[pdfName("Pages")], nil, 0)
+			break
+		}
+	}
+	if len(pages) > 0 {
+		return pages
+	}
+
+	for _, obj := range d.objects {
+		if dict, ok := obj.(pdfDict); ok && dict[pdfName("Type")] == pdfName("Page") {
+			pages = append(pages, dict)
+		}
+	}
+	return pages
+}
+
+func (d *pdfDocument) pageContents(page pdfDict) [][]byte {
+	var streams []interface{}
+	switch v := d.resolve(page[pdfName("Contents")]).(type) {
+	case pdfArray:
+		streams = v
+	case *pdfStream:
+		streams = []interface{}{v}
+	}
+
+	var contents [][]byte
+	for _, s := range streams {
+		stream, ok := d.resolve(s).(*pdfStream)
+		if !ok {
+			continue
+		}
+		if data, err := d.decode(stream); err == nil {
+			contents = append(contents, data)
+		}
+	}
+	return contents
+}
+
+// decode applies a stream's filters. Only the filters used for text,
+// fonts and object streams are supported.
+func (d *pdfDocument) decode(stream *pdfStream) ([]byte, error) {
+	var filters []interface{}
+	switch f := d.resolve(stream.dict[pdfName("Filter")]).(type) {
+	case pdfName:
+		filters = []interface{}{f}
+	case pdfArray:
+		filters = f
+	}
+
+	data := stream.raw
+	for _, f := range filters {
+		var err error
+		switch d.resolve(f) {
+		case pdfName("FlateDecode"), pdfName("Fl"):
+			data, err = inflate(data)
+		case pdfName("ASCIIHexDecode"), pdfName("AHx"):
+			data, err = hex.DecodeString(strings.Map(func(r rune) rune {
+				if r == '>' || isPDFSpace(byte(r)) {
+					return -1
+				}
+				return r
+			}, string(data)))
+		case pdfName("ASCII85Decode"), pdfName("A85"):
+			data = bytes.TrimSuffix(bytes.TrimSpace(data), []byte("~>"))
+			// "z" expands one byte to four.
+			out := make([]byte, 4*len(data)+4)
+			var n int
+			n, _, err = ascii85.Decode(out, data, true)
+			data = out[:n]
+		default:
+			return nil, fmt.Errorf("extract: unsupported pdf filter %v", f)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+func inflate(data []byte) ([]byte, error) {
+	var r io.Reader
+	if zr, err := zlib.NewReader(bytes.NewReader(data)); err == nil {
+		r = zr
+	} else {
+		r = flate.NewReader(bytes.NewReader(data))
+	}
+	out, err := io.ReadAll(io.LimitReader(r, maxPDFStreamSize))
+	// Many producers write streams with a truncated checksum; keep what
+	// decompressed cleanly.
+	if err != nil && len(out) == 0 {
+		return nil, err
+	}
+	return out, nil
+}
+
+// pdfFont decodes the bytes of a shown string into text.
+type pdfFont struct {
+	cmap *toUnicodeMap
+}
+
+func (d *pdfDocument) font(resources pdfDict, name pdfName) *pdfFont {
+	fonts := d.dict(resources[pdfName("Font")])
+	if fonts == nil {
+		return &pdfFont{}
+	}
+	fontDict := d.dict(fonts[name])
+	if fontDict == nil {
+		return &pdfFont{}
+	}
+
+	toUnicode, isRef := fontDict[pdfName("ToUnicode")].(pdfRef)
+	if !isRef {
+		return &pdfFont{}
+	}
+	if cmap, ok := d.cmaps[toUnicode.num]; ok {
+		return &pdfFont{cmap: cmap}
+	}
+	var cmap *toUnicodeMap
+	if stream, ok := d.resolve(toUnicode).(*pdfStream); ok {
+		if data, err := d.decode(stream); err == nil {
+			cmap = parseToUnicode(data)
+		}
+	}
+	d.cmaps[toUnicode.num] = cmap
+	return &pdfFont{cmap: cmap}
+}
+
+func (f *pdfFont) decode(s []byte) string {
+	if f != nil && f.cmap != nil {
+		return f.cmap.decode(s)
+	}
+	// Without a ToUnicode map assume a simple font in WinAnsiEncoding,
+	// which covers what word processors emit for Latin text.
+	var b strings.Builder
+	for _, c := range s {
+		if r, ok := winAnsiHigh[c]; ok {
+			b.WriteRune(r)
+		} else if c >= 0x20 || c == '\t' {
+			b.WriteRune(rune(c))
+		}
+	}
+	return b.String()
+}
+
+var winAnsiHigh = map[byte]rune{
+	0x80: '€', 0x82: '‚', 0x83: 'ƒ', 0x84: '„', 0x85: '…', 0x86: '†', 0x87: '‡',
+	0x88: 'ˆ', 0x89: '‰', 0x8A: 'Š', 0x8B: '‹', 0x8C: 'Œ', 0x8E: 'Ž', 0x91: '\'',
+	0x92: '\'', 0x93: '"', 0x94: '"', 0x95: '•', 0x96: '–', 0x97: '—', 0x98: '˜',
+	0x99: '™', 0x9A: 'š', 0x9B: '›', 0x9C: 'œ', 0x9E: 'ž', 0x9F: 'Ÿ',
+}
+
+// toUnicodeMap is a parsed ToUnicode CMap.
+type toUnicodeMap struct {
+	width  int
+	single map[uint32]string
+	ranges []cmapRange
+}
+
+type cmapRange struct {
+	lo, hi uint32
+	base   []rune
+}
+
+func parseToUnicode(data []byte) *toUnicodeMap {
+	m := &toUnicodeMap{width: 1, single: make(map[uint32]string)}
+	l := &pdfLexer{data: data}
+
+	var operands []interface{}
+	for {
+		tok, ok := l.token()
+		if !ok {
+			break
+		}
+		op, isOp := tok.(pdfOp)
+		if !isOp {
+			if s, isStr := tok.(string); isStr && s == "[" {
+				arr, _ := l.finishObject(tok, 0)
+				operands = append(operands, arr)
+				continue
+			}
+			operands = append(operands, tok)
+			continue
+		}
+
+		switch op {
+		case "endcodespacerange":
+			for _, o := range operands {
+				if b, ok := o.([]byte); ok && len(b) > m.width {
+					m.width = len(b)
+				}
+			}
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				src, ok1 := operands[i].([]byte)
+				dst, ok2 := operands[i+1].([]byte)
+				if ok1 && ok2 {
+					m.single[bytesToCode(src)] = utf16BytesToString(dst)
+				}
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				lo, ok1 := operands[i].([]byte)
+				hi, ok2 := operands[i+1].([]byte)
+				if !ok1 || !ok2 {
+					continue
+				}
+				loCode, hiCode := bytesToCode(lo), bytesToCode(hi)
+				switch dst := operands[i+2].(type) {
+				case []byte:
+					m.ranges = append(m.ranges, cmapRange{lo: loCode, hi: hiCode, base: []rune(utf16BytesToString(dst))})
+				case pdfArray:
+					for j, item := range dst {
+						if b, ok := item.([]byte); ok && loCode+uint32(j) <= hiCode {
+							m.single[loCode+uint32(j)] = utf16BytesToString(b)
+						}
+					}
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+	return m
+}
+
+func (m *toUnicodeMap) decode(s []byte) string {
+	var b strings.Builder
+	for i := 0; i+m.width <= len(s); i += m.width {
+		code := bytesToCode(s[i : i+m.width])
+		if text, ok := m.single[code]; ok {
+			b.WriteString(text)
+			continue
+		}
+		for _, r := range m.ranges {
+			if code >= r.lo && code <= r.hi && len(r.base) > 0 {
+				out := append([]rune(nil), r.base...)
+				out[len(out)-1] += rune(code - r.lo)
+				b.WriteString(string(out))
+				break
+			}
+		}
+	}
+	return b.String()
+}
+
+func bytesToCode(b []byte) uint32 {
+	var code uint32
+	for _, c := range b {
+		code = code<<8 | uint32(c)
+	}
+	return code
+}
+
+func utf16BytesToString(b []byte) string {
+	units := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		units = append(units, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	if len(b)%2 == 1 {
+		units = append(units, uint16(b[len(b)-1]))
+	}
+	return string(utf16.Decode(units))
+}
+
+// textWriter lays out shown strings, starting new lines when the text
+// position moves vertically.
+type textWriter struct {
+	b     strings.Builder
+	lastY float64
+	hasY  bool
+}
+
+func (w *textWriter) show(s string) {
+	w.b.WriteString(s)
+}
+
+func (w *textWriter) space() {
+	w.b.WriteByte(' ')
+}
+
+func (w *textWriter) newline() {
+	w.b.WriteByte('\n')
+}
+
+// moveTo records a new baseline, breaking the line if it changed.
+func (w *textWriter) moveTo(y float64) {
+	if w.hasY && abs(y-w.lastY) > 1 {
+		w.newline()
+	} else if w.hasY {
+		w.space()
+	}
+	w.lastY = y
+	w.hasY = true
+}
+
+func (w *textWriter) String() string {
+	return w.b.String()
+}
+
+func abs(f float64) float64 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
+
+// runContent interprets the text operators of a content stream.
+func (d *pdfDocument) runContent(w *textWriter, content []byte, resources pdfDict, depth int) error {
+	l := &pdfLexer{data: content}
+	var operands []interface{}
+	var font *pdfFont
+	var lineY float64
+
+	num := func(i int) float64 {
+		if i < len(operands) {
+			if f, ok := operands[i].(float64); ok {
+				return f
+			}
+		}
+		return 0
+	}
+
+	for {
+		tok, ok := l.token()
+		if !ok {
+			return l.err
+		}
+		op, isOp := tok.(pdfOp)
+		if !isOp {
+			if s, isStr := tok.(string); isStr && (s == "[" || s == "<<") {
+				obj, _ := l.finishObject(tok, 0)
+				operands = append(operands, obj)
+			} else {
+				operands = append(operands, tok)
+			}
+			continue
+		}
+
+		switch op {
+		case "BT":
+			lineY = 0
+		case "Tf":
+			if name, ok := firstName(operands); ok {
+				font = d.font(resources, name)
+			}
+		case "Td", "TD":
+			lineY += num(1)
+			if num(1) != 0 {
+				w.moveTo(lineY)
+			} else if num(0) != 0 {
+				w.space()
+			}
+		case "Tm":
+			lineY = num(5)
+			w.moveTo(lineY)
+		case "T*":
+			w.newline()
+		case "Tj":
+			if s, ok := lastString(operands); ok {
+				w.show(font.decode(s))
+			}
+		case "'", "\"":
+			w.newline()
+			if s, ok := lastString(operands); ok {
+				w.show(font.decode(s))
+			}
+		case "TJ":
+			if len(operands) > 0 {
+				arr, _ := operands[len(operands)-1].(pdfArray)
+				for _, item := range arr {
+					switch v := item.(type) {
+					case []byte:
+						w.show(font.decode(v))
+					case float64:
+						// Large negative kerning is how many producers
+						// encode the gap between words.
+						if v < -200 {
+							w.space()
+						}
+					}
+				}
+			}
+		case "Do":
+			if name, ok := firstName(operands); ok && depth < maxFormDepth {
+				if err := d.runForm(w, resources, name, depth); err != nil {
+					return err
+				}
+			}
+		case "ID":
+			// Skip inline image data up to EI.
+			if end := bytes.Index(l.data[l.pos:], []byte("EI")); end >= 0 {
+				l.pos += end + 2
+			} else {
+				l.pos = len(l.data)
+			}
+		}
+		operands = operands[:0]
+	}
+}
+
+func (d *pdfDocument) runForm(w *textWriter, resources pdfDict, name pdfName, depth int) error {
+	xobjects := d.dict(resources[pdfName("XObject")])
+	if xobjects == nil {
+		return nil
+	}
+	stream, ok := d.resolve(xobjects[name]).(*pdfStream)
+	if !ok || stream.dict[pdfName("Subtype")] != pdfName("Form") {
+		return nil
+	}
+	data, err := d.decode(stream)
+	if err != nil {
+		return nil
+	}
+	formResources := d.dict(stream.dict[pdfName("Resources")])
+	if formResources == nil {
+		formResources = resources
+	}
+	return d.runContent(w, data, formResources, depth+1)
+}
+
+func firstName(operands []interface{}) (pdfName, bool) {
+	for _, o := range operands {
+		if n, ok := o.(pdfName); ok {
+			return n, true
+		}
+	}
+	return "", false
+}
+
+func lastString(operands []interface{}) ([]byte, bool) {
+	if len(operands) == 0 {
+		return nil, false
+	}
+	s, ok := operands[len(operands)-1].([]byte)
+	return s, ok
+}
@@ -0,0 +1,306 @@
+package extract
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+)
+
+// maxPDFNesting bounds how deeply arrays and dictionaries may nest. Real
+// files stay in single digits; the limit keeps hostile ones from exhausting
+// the stack.
+const maxPDFNesting = 128
+
+var errPDFNesting = errors.New("extract: invalid pdf: objects nested too deeply")
+
+// The PDF object model, reduced to what text extraction needs.
+type (
+	pdfName   string
+	pdfArray  []interface{}
+	pdfDict   map[pdfName]interface{}
+	pdfRef    struct{ num, gen int }
+	pdfOp     string // content stream operator or bare keyword
+	pdfStream struct {
+		dict pdfDict
+		raw  []byte
+	}
+)
+
+// pdfLexer tokenizes both the file body and content streams. Once err is
+// set the lexer reports EOF.
+type pdfLexer struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func isPDFSpace(c byte) bool {
+	switch c {
+	case 0, '\t', '\n', '\f', '\r', ' ':
+		return true
+	}
+	return false
+}
+
+func isPDFDelim(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+func (l *pdfLexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if isPDFSpace(c) {
+			l.pos++
+		} else if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+		} else {
+			return
+		}
+	}
+}
+
+// token returns the next token: a number, pdfName, []byte string, pdfOp, or
+// one of the structural markers "[", "]", "<<", ">>". ok is false at EOF.
+func (l *pdfLexer) token() (interface{}, bool) {
+	for {
+		l.skipSpace()
+		if l.err != nil || l.pos >= len(l.data) {
+			return nil, false
+		}
+		// Stray ">" and ")" are skipped.
+		if c := l.data[l.pos]; c == ')' || (c == '>' && (l.pos+1 >= len(l.data) || l.data[l.pos+1] != '>')) {
+			l.pos++
+			continue
+		}
+		break
+	}
+
+	c := l.data[l.pos]
+	switch {
+	case c == '/':
+		l.pos++
+		start := l.pos
+		for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelim(l.data[l.pos]) {
+			l.pos++
+		}
+		return pdfName(decodeNameEscapes(l.data[start:l.pos])), true
+	case c == '(':
+		return l.literalString(), true
+	case c == '<':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '<' {
+			l.pos += 2
+			return "<<", true
+		}
+		return l.hexString(), true
+	case c == '>':
+		l.pos += 2
+		return ">>", true
+	case c == '[' || c == ']' || c == '{' || c == '}':
+		l.pos++
+		return string(c), true
+	}
+
+	start := l.pos
+	for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelim(l.data[l.pos]) {
+		l.pos++
+	}
+	word := l.data[start:l.pos]
+	if n, err := strconv.ParseFloat(string(word), 64); err == nil && (word[0] == '-' || word[0] == '+' || word[0] == '.' || (word[0] >= '0' && word[0] <= '9')) {
+		return n, true
+	}
+	return pdfOp(word), true
+}
+
+func decodeNameEscapes(b []byte) string {
+	if bytes.IndexByte(b, '#') < 0 {
+		return string(b)
+	}
+	out := make([]byte, 0, len(b))
+	for i := 0; i < len(b); i++ {
+		if b[i] == '#' && i+2 < len(b) {
+			if v, err := strconv.ParseUint(string(b[i+1:i+3]), 16, 8); err == nil {
+				out = append(out, byte(v))
+				i += 2
+				continue
+			}
+		}
+		out = append(out, b[i])
+	}
+	return string(out)
+}
+
+func (l *pdfLexer) literalString() []byte {
+	l.pos++ // (
+	var out []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+			out = append(out, c)
+		case ')':
+			depth--
+			if depth == 0 {
+				return out
+			}
+			out = append(out, c)
+		case '\\':
+			if l.pos >= len(l.data) {
+				return out
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b':
+				out = append(out, '\b')
+			case 'f':
+				out = append(out, '\f')
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+			case '\n':
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					out = append(out, byte(v))
+				} else {
+					out = append(out, e)
+				}
+			}
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func (l *pdfLexer) hexString() []byte {
+	l.pos++ // <
+	var digits []byte
+	for l.pos < len(l.data) && l.data[l.pos] != '>' {
+		if c := l.data[l.pos]; !isPDFSpace(c) {
+			digits = append(digits, c)
+		}
+		l.pos++
+	}
+	if l.pos < len(l.data) {
+		l.pos++ // >
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, 0, len(digits)/2)
+	for i := 0; i+1 < len(digits); i += 2 {
+		v, err := strconv.ParseUint(string(digits[i:i+2]), 16, 8)
+		if err != nil {
+			break
+		}
+		out = append(out, byte(v))
+	}
+	return out
+}
+
+// object parses one complete object, folding "n g R" into a pdfRef. depth
+// is how many arrays and dictionaries enclose it.
+func (l *pdfLexer) object(depth int) (interface{}, bool) {
+	tok, ok := l.token()
+	if !ok {
+		return nil, false
+	}
+	return l.finishObject(tok, depth)
+}
+
+// finishObject completes the object that starts with tok. Nesting deeper
+// than maxPDFNesting sets l.err and stops the lexer.
+func (l *pdfLexer) finishObject(tok interface{}, depth int) (interface{}, bool) {
+	switch t := tok.(type) {
+	case string:
+		if (t == "[" || t == "<<") && depth >= maxPDFNesting {
+			l.err = errPDFNesting
+			return nil, false
+		}
+		switch t {
+		case "[":
+			var arr pdfArray
+			for {
+				next, ok := l.token()
+				if !ok {
+					return arr, true
+				}
+				if s, isStr := next.(string); isStr && s == "]" {
+					return arr, true
+				}
+				obj, ok := l.finishObject(next, depth+1)
+				if !ok {
+					return arr, false
+				}
+				arr = append(arr, obj)
+			}
+		case "<<":
+			dict := make(pdfDict)
+			for {
+				key, ok := l.token()
+				if !ok {
+					return dict, true
+				}
+				if s, isStr := key.(string); isStr && s == ">>" {
+					return dict, true
+				}
+				name, isName := key.(pdfName)
+				if !isName {
+					continue
+				}
+				value, ok := l.object(depth + 1)
+				if !ok {
+					return dict, l.err == nil
+				}
+				dict[name] = value
+			}
+		}
+		return nil, true
+	case float64:
+		// Look ahead for "gen R".
+		save := l.pos
+		if gen, ok := l.token(); ok {
+			if g, isNum := gen.(float64); isNum {
+				if r, ok := l.token(); ok {
+					if op, isOp := r.(pdfOp); isOp && op == "R" {
+						return pdfRef{num: int(t), gen: int(g)}, true
+					}
+				}
+			}
+		}
+		l.pos = save
+		return t, true
+	case pdfOp:
+		switch t {
+		case "true":
+			return true, true
+		case "false":
+			return false, true
+		case "null":
+			return nil, true
+		}
+		return t, true
+	}
+	return tok, true
+}
@@ -36,16 +36,13 @@ func (h *AuthHandler) Login(c *gin.Context) {
 }
 
 func (h *AuthHandler) Invite(c *gin.Context) {
-	inviterRole, _ := c.Get("role")
-	inviterOrgID, _ := c.Get("organizationID")
-
 	var req services.InviteRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
 
-	response, statusCode := h.authService.Invite(req, inviterRole.(string), inviterOrgID.(string))
+	response, statusCode := h.authService.Invite(callerFromContext(c), req)
 	c.JSON(statusCode, response)
 }
 
@@ -76,3 +73,14 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	response, statusCode := h.authService.RefreshToken(req)
 	c.JSON(statusCode, response)
 }
+
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var req services.LogoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, statusCode := h.authService.Logout(req)
+	c.JSON(statusCode, response)
+}
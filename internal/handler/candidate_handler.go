@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/resumelens/authservice/internal/services"
+)
+
+type CandidateHandler struct {
+	candidateService *services.CandidateService
+}
+
+func NewCandidateHandler(candidateService *services.CandidateService) *CandidateHandler {
+	return &CandidateHandler{candidateService: candidateService}
+}
+
+func (h *CandidateHandler) ListDuplicates(c *gin.Context) {
+	var req services.ListDuplicatesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, statusCode := h.candidateService.ListDuplicates(callerFromContext(c), req)
+	c.JSON(statusCode, response)
+}
+
+func (h *CandidateHandler) DismissDuplicate(c *gin.Context) {
+	response, statusCode := h.candidateService.DismissDuplicate(callerFromContext(c), c.Param("id"))
+	c.JSON(statusCode, response)
+}
+
+func (h *CandidateHandler) RescanDuplicates(c *gin.Context) {
+	response, statusCode := h.candidateService.RescanDuplicates(callerFromContext(c))
+	c.JSON(statusCode, response)
+}
+
+// MergeCandidates merges the candidate in the request body into the one in
+// the path.
+func (h *CandidateHandler) MergeCandidates(c *gin.Context) {
+	var req services.MergeCandidatesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, statusCode := h.candidateService.MergeCandidates(callerFromContext(c), c.Param("id"), req)
+	c.JSON(statusCode, response)
+}
+
+func (h *CandidateHandler) CreateCandidate(c *gin.Context) {
+	var req services.CreateCandidateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, statusCode := h.candidateService.CreateCandidate(callerFromContext(c), req)
+	c.JSON(statusCode, response)
+}
+
+func (h *CandidateHandler) ListCandidates(c *gin.Context) {
+	var req services.ListCandidatesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, statusCode := h.candidateService.ListCandidates(callerFromContext(c), req)
+	c.JSON(statusCode, response)
+}
+
+func (h *CandidateHandler) GetCandidate(c *gin.Context) {
+	response, statusCode := h.candidateService.GetCandidate(callerFromContext(c), c.Param("id"))
+	c.JSON(statusCode, response)
+}
+
+func (h *CandidateHandler) UpdateCandidate(c *gin.Context) {
+	var req services.UpdateCandidateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, statusCode := h.candidateService.UpdateCandidate(callerFromContext(c), c.Param("id"), req)
+	c.JSON(statusCode, response)
+}
+
+func (h *CandidateHandler) ListTags(c *gin.Context) {
+	response, statusCode := h.candidateService.ListTags(callerFromContext(c))
+	c.JSON(statusCode, response)
+}
+
+func (h *CandidateHandler) CreateNote(c *gin.Context) {
+	var req services.CreateNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, statusCode := h.candidateService.CreateNote(callerFromContext(c), c.Param("id"), req)
+	c.JSON(statusCode, response)
+}
+
+func (h *CandidateHandler) ListNotes(c *gin.Context) {
+	var req services.ListNotesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, statusCode := h.candidateService.ListNotes(callerFromContext(c), c.Param("id"), req)
+	c.JSON(statusCode, response)
+}
+
+func (h *CandidateHandler) UpdateNote(c *gin.Context) {
+	var req services.UpdateNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, statusCode := h.candidateService.UpdateNote(callerFromContext(c), c.Param("id"), c.Param("noteID"), req)
+	c.JSON(statusCode, response)
+}
+
+func (h *CandidateHandler) DeleteNote(c *gin.Context) {
+	response, statusCode := h.candidateService.DeleteNote(callerFromContext(c), c.Param("id"), c.Param("noteID"))
+	c.JSON(statusCode, response)
+}
+
+// RateApplication sets the caller's own rating of an application.
+func (h *CandidateHandler) RateApplication(c *gin.Context) {
+	var req services.RateApplicationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, statusCode := h.candidateService.RateApplication(callerFromContext(c), c.Param("id"), req)
+	c.JSON(statusCode, response)
+}
+
+func (h *CandidateHandler) ListRatings(c *gin.Context) {
+	response, statusCode := h.candidateService.ListRatings(callerFromContext(c), c.Param("id"))
+	c.JSON(statusCode, response)
+}
+
+func (h *CandidateHandler) DeleteRating(c *gin.Context) {
+	response, statusCode := h.candidateService.DeleteRating(callerFromContext(c), c.Param("id"))
+	c.JSON(statusCode, response)
+}
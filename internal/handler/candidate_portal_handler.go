@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/resumelens/authservice/internal/services"
+)
+
+type CandidatePortalHandler struct {
+	portalService *services.CandidatePortalService
+}
+
+func NewCandidatePortalHandler(portalService *services.CandidatePortalService) *CandidatePortalHandler {
+	return &CandidatePortalHandler{portalService: portalService}
+}
+
+func (h *CandidatePortalHandler) GetApplication(c *gin.Context) {
+	response, statusCode := h.portalService.GetApplication(c.Param("token"))
+	c.JSON(statusCode, response)
+}
+
+func (h *CandidatePortalHandler) UpdateContact(c *gin.Context) {
+	var req services.UpdateContactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, statusCode := h.portalService.UpdateContact(c.Param("token"), req)
+	c.JSON(statusCode, response)
+}
+
+func (h *CandidatePortalHandler) ReplaceResume(c *gin.Context) {
+	file, header, err := c.Request.FormFile("resumeFile")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not retrieve file from request"})
+		return
+	}
+	defer file.Close()
+
+	response, statusCode := h.portalService.ReplaceResume(c.Request.Context(), c.Param("token"), services.UploadedFile{File: file, Header: header})
+	c.JSON(statusCode, response)
+}
+
+func (h *CandidatePortalHandler) ReplaceCoverLetter(c *gin.Context) {
+	file, header, err := c.Request.FormFile("coverLetterFile")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not retrieve cover letter file from request"})
+		return
+	}
+	defer file.Close()
+
+	response, statusCode := h.portalService.ReplaceCoverLetter(c.Request.Context(), c.Param("token"), services.UploadedFile{File: file, Header: header})
+	c.JSON(statusCode, response)
+}
+
+func (h *CandidatePortalHandler) Withdraw(c *gin.Context) {
+	var req services.WithdrawRequest
+	// The body is optional; a bare POST withdraws without a reason.
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	response, statusCode := h.portalService.Withdraw(c.Param("token"), req)
+	c.JSON(statusCode, response)
+}
@@ -0,0 +1,17 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/resumelens/authservice/internal/services"
+)
+
+// callerFromContext reads the identity that JWTAuthMiddleware stored on the
+// request context.
+func callerFromContext(c *gin.Context) services.Caller {
+	return services.Caller{
+		UserID:         c.GetString("userID"),
+		Email:          c.GetString("email"),
+		RoleID:         c.GetString("role"),
+		OrganizationID: c.GetString("organizationID"),
+	}
+}
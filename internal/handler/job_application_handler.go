@@ -1,10 +1,15 @@
 package handler
 
 import (
+	"errors"
+	"log"
+	"mime"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/resumelens/authservice/internal/preview"
 	"github.com/resumelens/authservice/internal/services"
+	"github.com/resumelens/authservice/internal/upload"
 )
 
 type JobApplicationHandler struct {
@@ -16,12 +21,9 @@ func NewJobApplicationHandler(s *services.JobApplicationService) *JobApplication
 }
 
 func (h *JobApplicationHandler) UploadResume(c *gin.Context) {
-	orgID := c.PostForm("organization_id")
-	jobID := c.PostForm("job_id")
-	candidateID := c.PostForm("candidate_id")
-
-	if orgID == "" || jobID == "" || candidateID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "organization_id, job_id, and candidate_id are required"})
+	var req services.UploadDocumentRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -32,21 +34,19 @@ func (h *JobApplicationHandler) UploadResume(c *gin.Context) {
 	}
 	defer file.Close()
 
-	if err := h.service.UploadResume(c.Request.Context(), file, handler, orgID, jobID, candidateID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process resume file"})
+	application, err := h.service.UploadResume(c.Request.Context(), callerFromContext(c), file, handler, req)
+	if err != nil {
+		respondUploadError(c, err, "Failed to process resume file")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Resume processed and stored successfully."})
+	c.JSON(http.StatusOK, gin.H{"message": "Resume processed and stored successfully.", "application": application})
 }
 
 func (h *JobApplicationHandler) UploadCoverLetter(c *gin.Context) {
-	orgID := c.PostForm("organization_id")
-	jobID := c.PostForm("job_id")
-	candidateID := c.PostForm("candidate_id")
-
-	if orgID == "" || jobID == "" || candidateID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "organization_id, job_id, and candidate_id are required"})
+	var req services.UploadDocumentRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -57,10 +57,156 @@ func (h *JobApplicationHandler) UploadCoverLetter(c *gin.Context) {
 	}
 	defer file.Close()
 
-	if err := h.service.UploadCoverLetter(c.Request.Context(), file, handler, orgID, jobID, candidateID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process cover letter file"})
+	application, err := h.service.UploadCoverLetter(c.Request.Context(), callerFromContext(c), file, handler, req)
+	if err != nil {
+		respondUploadError(c, err, "Failed to process cover letter file")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Cover letter stored successfully.", "application": application})
+}
+
+func (h *JobApplicationHandler) GetApplicationScore(c *gin.Context) {
+	response, statusCode := h.service.GetApplicationScore(callerFromContext(c), c.Param("id"))
+	c.JSON(statusCode, response)
+}
+
+func respondUploadError(c *gin.Context, err error, fallback string) {
+	var rejected *upload.Error
+	switch {
+	case errors.As(err, &rejected):
+		c.JSON(rejected.Status, gin.H{"error": rejected.Message, "code": rejected.Code})
+	case errors.Is(err, services.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job or candidate not found"})
+	case errors.Is(err, services.ErrInvalidCandidate), errors.Is(err, services.ErrArchiveNotAllowed):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrCandidateExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+func (h *JobApplicationHandler) ImportResumes(c *gin.Context) {
+	file, header, err := c.Request.FormFile("archive")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not retrieve archive from request"})
 		return
 	}
+	defer file.Close()
 
-	c.JSON(http.StatusOK, gin.H{"message": "Cover letter stored successfully."})
+	report, err := h.service.ImportResumes(c.Request.Context(), callerFromContext(c), c.Param("id"), file, header)
+	if err != nil {
+		respondUploadError(c, err, "Failed to import resumes")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Import finished", "report": report})
+}
+
+func (h *JobApplicationHandler) ListDocumentVersions(c *gin.Context) {
+	var req services.ListDocumentVersionsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, statusCode := h.service.ListDocumentVersions(callerFromContext(c), c.Param("id"), req)
+	c.JSON(statusCode, response)
+}
+
+func (h *JobApplicationHandler) GetDocumentVersion(c *gin.Context) {
+	var req services.DocumentAccessRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	access, err := h.service.AccessDocumentVersion(c.Request.Context(), callerFromContext(c), c.Param("id"), c.Param("versionID"), req, accessClient(c))
+	respondDocumentAccess(c, access, err)
+}
+
+func (h *JobApplicationHandler) GetResume(c *gin.Context) {
+	var req services.DocumentAccessRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	access, err := h.service.AccessCurrentDocument(c.Request.Context(), callerFromContext(c), c.Param("id"), "resume", req, accessClient(c))
+	respondDocumentAccess(c, access, err)
+}
+
+func (h *JobApplicationHandler) GetCoverLetter(c *gin.Context) {
+	var req services.DocumentAccessRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	access, err := h.service.AccessCurrentDocument(c.Request.Context(), callerFromContext(c), c.Param("id"), "cover_letter", req, accessClient(c))
+	respondDocumentAccess(c, access, err)
+}
+
+func (h *JobApplicationHandler) ListDocumentAccess(c *gin.Context) {
+	var req services.ListDocumentAccessRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, statusCode := h.service.ListDocumentAccess(callerFromContext(c), c.Param("id"), req)
+	c.JSON(statusCode, response)
+}
+
+func accessClient(c *gin.Context) services.AccessClient {
+	return services.AccessClient{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+}
+
+// respondDocumentAccess returns the signed URL, or streams the document
+// when the storage backend can't sign: downloads as an attachment under the
+// name they were uploaded with, previews inline, and HTML renditions behind
+// a restrictive CSP.
+func respondDocumentAccess(c *gin.Context, access *services.DocumentAccess, err error) {
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+		return
+	case errors.Is(err, services.ErrDocumentUnavailable):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrPreviewUnavailable):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": services.ErrPreviewUnavailable.Error()})
+		return
+	case err != nil:
+		log.Printf("Failed to grant document access for application %s: %v", c.Param("id"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load document"})
+		return
+	}
+
+	version := access.Version
+	if access.Reader == nil {
+		c.JSON(http.StatusOK, gin.H{
+			"url":          access.URL,
+			"expires_at":   access.ExpiresAt,
+			"filename":     version.Filename,
+			"content_type": version.ContentType,
+		})
+		return
+	}
+	defer access.Reader.Close()
+
+	disposition := "attachment"
+	if access.Preview {
+		disposition = "inline"
+	}
+	headers := map[string]string{
+		"Content-Disposition":    mime.FormatMediaType(disposition, map[string]string{"filename": version.Filename}),
+		"Cache-Control":          "private, no-store",
+		"X-Content-Type-Options": "nosniff",
+	}
+	if version.ContentType == preview.ContentType {
+		headers["Content-Security-Policy"] = preview.ContentSecurityPolicy
+	}
+	c.DataFromReader(http.StatusOK, version.Size, version.ContentType, access.Reader, headers)
 }
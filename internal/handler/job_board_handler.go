@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/resumelens/authservice/internal/captcha"
+	"github.com/resumelens/authservice/internal/services"
+)
+
+type JobBoardHandler struct {
+	jobBoardService *services.JobBoardService
+	captcha         captcha.Verifier
+}
+
+func NewJobBoardHandler(jobBoardService *services.JobBoardService, verifier captcha.Verifier) *JobBoardHandler {
+	return &JobBoardHandler{jobBoardService: jobBoardService, captcha: verifier}
+}
+
+func (h *JobBoardHandler) GetJob(c *gin.Context) {
+	response, statusCode := h.jobBoardService.GetPublicJob(c.Param("orgID"), c.Param("jobID"))
+	c.JSON(statusCode, response)
+}
+
+func (h *JobBoardHandler) ListOrganizationJobs(c *gin.Context) {
+	response, statusCode := h.jobBoardService.ListOrganizationJobs(c.Param("orgID"))
+	c.JSON(statusCode, response)
+}
+
+func (h *JobBoardHandler) Apply(c *gin.Context) {
+	if err := h.captcha.Verify(c.Request.Context(), c.PostForm("captcha_token"), c.ClientIP()); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "CAPTCHA verification failed"})
+		return
+	}
+
+	var req services.ApplyRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	file, header, err := c.Request.FormFile("resumeFile")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not retrieve file from request"})
+		return
+	}
+	defer file.Close()
+	resume := services.UploadedFile{File: file, Header: header}
+
+	var coverLetter *services.UploadedFile
+	if clFile, clHeader, err := c.Request.FormFile("coverLetterFile"); err == nil {
+		defer clFile.Close()
+		coverLetter = &services.UploadedFile{File: clFile, Header: clHeader}
+	}
+
+	response, statusCode := h.jobBoardService.Apply(c.Request.Context(), c.Param("jobID"), req, resume, coverLetter)
+	c.JSON(statusCode, response)
+}
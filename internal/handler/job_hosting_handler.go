@@ -22,12 +22,55 @@ func (h *JobHostingHandler) CreateJob(c *gin.Context) {
 		return
 	}
 
-	response, statusCode := h.jobHostingService.CreateJob(req)
+	response, statusCode := h.jobHostingService.CreateJob(callerFromContext(c), req)
 	c.JSON(statusCode, response)
 }
 
 func (h *JobHostingHandler) GetJob(c *gin.Context) {
 	id := c.Param("id")
-	response, statusCode := h.jobHostingService.GetJob(id)
+	response, statusCode := h.jobHostingService.GetJob(callerFromContext(c), id)
+	c.JSON(statusCode, response)
+}
+
+func (h *JobHostingHandler) ListJobs(c *gin.Context) {
+	var req services.ListJobsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, statusCode := h.jobHostingService.ListJobs(callerFromContext(c), req)
+	c.JSON(statusCode, response)
+}
+
+func (h *JobHostingHandler) UpdateJob(c *gin.Context) {
+	var req services.UpdateJobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, statusCode := h.jobHostingService.UpdateJob(callerFromContext(c), c.Param("id"), req)
+	c.JSON(statusCode, response)
+}
+
+func (h *JobHostingHandler) ChangeJobStatus(c *gin.Context) {
+	var req services.ChangeJobStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, statusCode := h.jobHostingService.ChangeJobStatus(callerFromContext(c), c.Param("id"), req)
+	c.JSON(statusCode, response)
+}
+
+func (h *JobHostingHandler) DeleteJob(c *gin.Context) {
+	response, statusCode := h.jobHostingService.DeleteJob(callerFromContext(c), c.Param("id"))
+	c.JSON(statusCode, response)
+}
+
+func (h *JobHostingHandler) GetJobAudit(c *gin.Context) {
+	response, statusCode := h.jobHostingService.GetJobAudit(callerFromContext(c), c.Param("id"))
 	c.JSON(statusCode, response)
 }
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/resumelens/authservice/internal/services"
+)
+
+type PipelineHandler struct {
+	pipelineService *services.PipelineService
+}
+
+func NewPipelineHandler(pipelineService *services.PipelineService) *PipelineHandler {
+	return &PipelineHandler{pipelineService: pipelineService}
+}
+
+// GetPipeline serves both the organization workflow and, under /job/:id, a
+// job's effective workflow.
+func (h *PipelineHandler) GetPipeline(c *gin.Context) {
+	response, statusCode := h.pipelineService.GetPipeline(callerFromContext(c), c.Param("id"))
+	c.JSON(statusCode, response)
+}
+
+func (h *PipelineHandler) ConfigurePipeline(c *gin.Context) {
+	var req services.ConfigurePipelineRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, statusCode := h.pipelineService.ConfigurePipeline(callerFromContext(c), c.Param("id"), req)
+	c.JSON(statusCode, response)
+}
+
+func (h *PipelineHandler) MoveApplication(c *gin.Context) {
+	var req services.MoveStageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, statusCode := h.pipelineService.MoveApplication(callerFromContext(c), c.Param("id"), req)
+	c.JSON(statusCode, response)
+}
+
+func (h *PipelineHandler) BulkMoveApplications(c *gin.Context) {
+	var req services.BulkMoveStageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, statusCode := h.pipelineService.BulkMoveApplications(callerFromContext(c), req)
+	c.JSON(statusCode, response)
+}
+
+func (h *PipelineHandler) GetStageHistory(c *gin.Context) {
+	response, statusCode := h.pipelineService.GetStageHistory(callerFromContext(c), c.Param("id"))
+	c.JSON(statusCode, response)
+}
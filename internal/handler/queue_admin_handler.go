@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/resumelens/authservice/internal/services"
+)
+
+type QueueAdminHandler struct {
+	queueAdminService *services.QueueAdminService
+}
+
+func NewQueueAdminHandler(queueAdminService *services.QueueAdminService) *QueueAdminHandler {
+	return &QueueAdminHandler{queueAdminService: queueAdminService}
+}
+
+func (h *QueueAdminHandler) GetStats(c *gin.Context) {
+	response, statusCode := h.queueAdminService.GetStats(callerFromContext(c))
+	c.JSON(statusCode, response)
+}
+
+func (h *QueueAdminHandler) ListDeadLetters(c *gin.Context) {
+	var req services.ListDeadLettersRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, statusCode := h.queueAdminService.ListDeadLetters(callerFromContext(c), req)
+	c.JSON(statusCode, response)
+}
+
+func (h *QueueAdminHandler) Redrive(c *gin.Context) {
+	response, statusCode := h.queueAdminService.Redrive(callerFromContext(c), c.Param("id"))
+	c.JSON(statusCode, response)
+}
+
+func (h *QueueAdminHandler) Discard(c *gin.Context) {
+	response, statusCode := h.queueAdminService.Discard(callerFromContext(c), c.Param("id"))
+	c.JSON(statusCode, response)
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/resumelens/authservice/internal/services"
+)
+
+type SearchHandler struct {
+	searchService *services.SearchService
+}
+
+func NewSearchHandler(searchService *services.SearchService) *SearchHandler {
+	return &SearchHandler{searchService: searchService}
+}
+
+func (h *SearchHandler) RankApplications(c *gin.Context) {
+	var req services.RankApplicationsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, statusCode := h.searchService.RankApplications(c.Request.Context(), callerFromContext(c), c.Param("id"), req)
+	c.JSON(statusCode, response)
+}
+
+func (h *SearchHandler) SearchCandidates(c *gin.Context) {
+	var req services.SearchCandidatesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, statusCode := h.searchService.SearchCandidates(c.Request.Context(), callerFromContext(c), req)
+	c.JSON(statusCode, response)
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/resumelens/authservice/internal/services"
+	"gorm.io/gorm"
+)
+
+// RequirePermission allows the request through only if the caller's role
+// grants every listed permission. It must run after JWTAuthMiddleware.
+func RequirePermission(permissionService *services.PermissionService, permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		roleID := c.GetString("role")
+		orgID := c.GetString("organizationID")
+
+		role, err := permissionService.GetRole(roleID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			forbid(c, "Role not found", "")
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve role permissions"})
+			c.Abort()
+			return
+		}
+
+		// A role only grants permissions inside its own organization.
+		if role.OrganizationID != orgID {
+			forbid(c, "Role does not belong to your organization", "")
+			return
+		}
+
+		for _, permission := range permissions {
+			if !services.HasPermission(role, permission) {
+				forbid(c, "Insufficient permissions", permission)
+				return
+			}
+		}
+
+		c.Set("roleName", role.Name)
+		c.Next()
+	}
+}
+
+func forbid(c *gin.Context, message, permission string) {
+	body := gin.H{"error": message}
+	if permission != "" {
+		body["required_permission"] = permission
+	}
+	c.JSON(http.StatusForbidden, body)
+	c.Abort()
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/resumelens/authservice/internal/services"
+)
+
+func TestRequirePermissionInvalidRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for _, roleID := range []string{"", "not-a-uuid"} {
+		router := gin.New()
+		router.GET("/", func(c *gin.Context) {
+			c.Set("role", roleID)
+			c.Set("organizationID", "org")
+		}, RequirePermission(services.NewPermissionService(), services.PermissionHome), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		if w.Code != http.StatusForbidden {
+			t.Errorf("role %q: status = %d; want 403", roleID, w.Code)
+		}
+	}
+}
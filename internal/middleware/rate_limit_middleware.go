@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+// limiterIdleTTL is how long an IP's bucket is kept after its last request.
+const limiterIdleTTL = 10 * time.Minute
+
+type ipLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// RateLimitByIP allows each client IP perMinute requests per minute with
+// bursts of up to burst requests. Excess requests get a 429.
+func RateLimitByIP(perMinute, burst int) gin.HandlerFunc {
+	var mu sync.Mutex
+	limiters := make(map[string]*ipLimiter)
+	lastSweep := time.Now()
+
+	return func(c *gin.Context) {
+		ip := c.ClientIP()
+		now := time.Now()
+
+		mu.Lock()
+		if now.Sub(lastSweep) > limiterIdleTTL {
+			for key, l := range limiters {
+				if now.Sub(l.lastSeen) > limiterIdleTTL {
+					delete(limiters, key)
+				}
+			}
+			lastSweep = now
+		}
+		l, ok := limiters[ip]
+		if !ok {
+			l = &ipLimiter{limiter: rate.NewLimiter(rate.Every(time.Minute/time.Duration(perMinute)), burst)}
+			limiters[ip] = l
+		}
+		l.lastSeen = now
+		allowed := l.limiter.Allow()
+		mu.Unlock()
+
+		if !allowed {
+			c.Header("Retry-After", "60")
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests, please try again later"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// multipartMemory is how much of a multipart form is kept in memory; larger
+// files spill to temporary files.
+const multipartMemory = 32 << 20
+
+// LimitUploadSize rejects request bodies larger than maxBytes with a 413.
+// Multipart forms are parsed here so an oversized upload is refused before
+// the handler binds anything.
+func LimitUploadSize(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tooLarge := gin.H{
+			"error":    "Request is too large",
+			"code":     "request_too_large",
+			"limit_mb": maxBytes >> 20,
+		}
+		if c.Request.ContentLength > maxBytes {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, tooLarge)
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+		if strings.HasPrefix(c.ContentType(), "multipart/") {
+			if err := c.Request.ParseMultipartForm(multipartMemory); err != nil {
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, tooLarge)
+					return
+				}
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid multipart form"})
+				return
+			}
+		}
+		c.Next()
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/lib/pq"
+	"gorm.io/gorm"
 )
 
 type User struct {
@@ -16,6 +17,17 @@ type User struct {
 	UpdatedAt      time.Time `gorm:"autoUpdateTime"`
 }
 
+type RefreshToken struct {
+	ID           string    `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	UserID       string    `gorm:"type:uuid;not null;index"`
+	FamilyID     string    `gorm:"type:uuid;not null;index"` // shared by every token rotated from the same login
+	TokenHash    string    `gorm:"unique;not null"`          // sha256 of the opaque token, never the token itself
+	ReplacedByID *string   `gorm:"type:uuid"`
+	ExpiresAt    time.Time `gorm:"not null"`
+	RevokedAt    *time.Time
+	CreatedAt    time.Time
+}
+
 type Organization struct {
 	ID          string  `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
 	Name        string  `gorm:"unique;not null"`
@@ -36,37 +48,221 @@ type Invite struct {
 }
 
 type Candidate struct {
-	ID         string `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
-	UserID     string `gorm:"not null;type:uuid"`
-	FullName   string `gorm:"not null"`
-	Email      string `gorm:"not null"`
-	Phone      string `gorm:"not null"`
-	LinkedIn   string `gorm:"type:text"`
-	GitHub     string `gorm:"type:text"`
-	Location   string `gorm:"type:text"`
-	Experience string `gorm:"type:text"`
-	Education  string `gorm:"type:text"`
-	Skills     string `gorm:"not null"`
-	CreatedAt  time.Time
-	UpdatedAt  time.Time
+	ID             string         `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	OrganizationID string         `gorm:"type:uuid;index"`
+	UserID         *string        `gorm:"type:uuid"` // recruiter who added the candidate, nil when self-applied
+	FullName       string         `gorm:"not null"`
+	Email          string         `gorm:"not null"`
+	Phone          string         `gorm:"not null"`
+	LinkedIn       string         `gorm:"type:text"`
+	GitHub         string         `gorm:"type:text"`
+	Location       string         `gorm:"type:text"`
+	Experience     string         `gorm:"type:text"`
+	Education      string         `gorm:"type:text"`
+	Skills         string         `gorm:"not null"`
+	Tags           pq.StringArray `gorm:"type:text[]"` // lowercased, free-form
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// CandidateNote is a recruiter's note on a candidate, optionally about one
+// of their applications. Mentions holds the ids of the users @mentioned in
+// Body.
+type CandidateNote struct {
+	ID             string         `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	OrganizationID string         `gorm:"type:uuid;not null;index"`
+	CandidateID    string         `gorm:"type:uuid;not null;index"`
+	ApplicationID  *string        `gorm:"type:uuid;index"`
+	AuthorID       string         `gorm:"type:uuid;not null"`
+	Body           string         `gorm:"type:text;not null"`
+	Mentions       pq.StringArray `gorm:"type:text[]"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// ApplicationRating is one interviewer's 1–5 rating of an application. Each
+// user has at most one rating per application and updates it in place.
+type ApplicationRating struct {
+	ID             string `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	OrganizationID string `gorm:"type:uuid;not null;index"`
+	ApplicationID  string `gorm:"type:uuid;not null;uniqueIndex:idx_application_rating_user,priority:1"`
+	UserID         string `gorm:"type:uuid;not null;uniqueIndex:idx_application_rating_user,priority:2"`
+	Score          int    `gorm:"not null"`
+	Comment        string `gorm:"type:text"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// CandidateIdentity is a normalized value that identifies a person, such as
+// an email address or the hash of a resume, kept for duplicate detection.
+type CandidateIdentity struct {
+	ID             string `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	OrganizationID string `gorm:"type:uuid;not null;index:idx_candidate_identity_lookup,priority:1"`
+	Kind           string `gorm:"not null;index:idx_candidate_identity_lookup,priority:2"` // email, phone, linkedin, github or resume
+	Value          string `gorm:"not null;index:idx_candidate_identity_lookup,priority:3"`
+	CandidateID    string `gorm:"type:uuid;not null;index"`
+}
+
+// CandidateDuplicate is a pair of candidates suspected to be the same
+// person. Each pair is stored once, with CandidateID < DuplicateID, until it
+// is merged or dismissed; merged pairs are kept as a record of the merge.
+type CandidateDuplicate struct {
+	ID             string         `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	OrganizationID string         `gorm:"type:uuid;not null;index"`
+	CandidateID    string         `gorm:"type:uuid;not null;uniqueIndex:idx_candidate_duplicate_pair,priority:1"`
+	DuplicateID    string         `gorm:"type:uuid;not null;uniqueIndex:idx_candidate_duplicate_pair,priority:2"`
+	Reasons        pq.StringArray `gorm:"type:text[]"`                   // identity kinds the two share
+	Status         string         `gorm:"not null;default:'open';index"` // open, dismissed or merged
+	ResolvedBy     *string        `gorm:"type:uuid"`
+	ResolvedAt     *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
 }
 
 type JobApplication struct {
 	ID          string `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
-	CandidateID string `gorm:"type:uuid"`
-	JobID       string `gorm:"type:uuid"`
+	CandidateID string `gorm:"type:uuid;uniqueIndex:idx_application_candidate_job"`
+	JobID       string `gorm:"type:uuid;uniqueIndex:idx_application_candidate_job"`
 
-	ResumeGCSPath      string  `gorm:"not null"`
-	CoverLetterGCSPath string  `gorm:"type:text"`
-	ParsedResume       string  `gorm:"type:jsonb"`
-	PinecodeID         string  `gorm:"type:text"` // pinecone id for embedding retrieval
-	Status             string  `gorm:"not null;default:'pending'"`
-	AI_Score           float64 `gorm:"not null;default:0"`
-	MagicLinkToken     string  `gorm:"unique;not null"`
+	ResumeGCSPath         string  `gorm:"not null"`
+	CoverLetterGCSPath    string  `gorm:"type:text"`
+	CurrentResumeID       *string `gorm:"type:uuid"` // DocumentVersion at ResumeGCSPath; nil for files uploaded before versioning
+	CurrentCoverLetterID  *string `gorm:"type:uuid"`
+	ResumeScanStatus      string  `gorm:"type:text"` // pending, clean or infected; empty for files uploaded before scanning
+	CoverLetterScanStatus string  `gorm:"type:text"`
+	ParsedResume          *string `gorm:"type:jsonb"`
+	PinecodeID            string  `gorm:"type:text"`                  // id of the resume embedding in the vector index
+	Status                string  `gorm:"not null;default:'applied'"` // key of the current PipelineStage
+	AI_Score              float64 `gorm:"not null;default:0"`
+	ScoreBreakdown        *string `gorm:"type:jsonb"` // per-criterion explanation of AI_Score
+	MagicLinkToken        string  `gorm:"unique;not null"`
+	MagicLinkExpiresAt    time.Time
 
 	CreatedAt time.Time
 }
 
+// QueueJob is a unit of background work. Workers claim pending jobs with
+// FOR UPDATE SKIP LOCKED; finished jobs are deleted.
+type QueueJob struct {
+	ID             string    `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	OrganizationID *string   `gorm:"type:uuid;index"`
+	Kind           string    `gorm:"not null"`
+	Payload        string    `gorm:"type:jsonb;not null"`
+	Status         string    `gorm:"not null;default:'pending';index:idx_queue_jobs_claim,priority:1"` // pending, running
+	RunAt          time.Time `gorm:"not null;index:idx_queue_jobs_claim,priority:2"`
+	Attempts       int       `gorm:"not null;default:0"`
+	MaxAttempts    int       `gorm:"not null"`
+	LockedAt       *time.Time
+	LastError      string `gorm:"type:text"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// DeadLetterJob is a QueueJob that failed on every attempt, kept until
+// someone re-drives or discards it.
+type DeadLetterJob struct {
+	ID             string    `gorm:"primaryKey;type:uuid"` // id of the original QueueJob
+	OrganizationID *string   `gorm:"type:uuid;index"`
+	Kind           string    `gorm:"not null"`
+	Payload        string    `gorm:"type:jsonb;not null"`
+	Attempts       int       `gorm:"not null"`
+	LastError      string    `gorm:"type:text"`
+	CreatedAt      time.Time // when the job was first enqueued
+	FailedAt       time.Time
+}
+
+// DocumentVersion is one upload of a resume or cover letter. Every upload
+// gets its own object, so versions are never overwritten; the application's
+// Current*ID columns point at the one in use.
+type DocumentVersion struct {
+	ID             string  `gorm:"primaryKey;type:uuid"`
+	OrganizationID string  `gorm:"type:uuid;not null;index"`
+	ApplicationID  string  `gorm:"type:uuid;not null;uniqueIndex:idx_document_version,priority:1"`
+	Kind           string  `gorm:"not null;uniqueIndex:idx_document_version,priority:2"` // resume or cover_letter
+	Version        int     `gorm:"not null;uniqueIndex:idx_document_version,priority:3"`
+	ObjectPath     string  `gorm:"not null"`
+	SHA256         string  `gorm:"column:sha256;not null"`
+	Size           int64   `gorm:"not null"`
+	ContentType    string  `gorm:"not null"`
+	Filename       string  `gorm:"type:text"` // as uploaded
+	UploadedBy     *string `gorm:"type:uuid"` // nil when the candidate uploaded it
+	ScanStatus     string  `gorm:"not null"`
+	CreatedAt      time.Time
+}
+
+// DocumentAccessLog records every download of a candidate document or its
+// preview that was handed out, whether as a signed URL or streamed by the
+// server. ObjectPath is the object actually served.
+type DocumentAccessLog struct {
+	ID             string  `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	OrganizationID string  `gorm:"type:uuid;not null;index"`
+	ApplicationID  string  `gorm:"type:uuid;not null;index"`
+	VersionID      *string `gorm:"type:uuid"` // nil for files uploaded before versioning
+	Kind           string  `gorm:"not null"`
+	ObjectPath     string  `gorm:"not null"`
+	UserID         string  `gorm:"type:uuid;not null;index"`
+	Method         string  `gorm:"not null"` // signed_url or stream
+	Preview        bool    `gorm:"not null;default:false"`
+	IPAddress      string  `gorm:"type:text"`
+	UserAgent      string  `gorm:"type:text"`
+	CreatedAt      time.Time
+}
+
+// QuarantinedFile is an upload the malware scanner rejected. The file is
+// moved to QuarantinePath, outside the organization's folders.
+type QuarantinedFile struct {
+	ID             string  `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	OrganizationID string  `gorm:"type:uuid;not null;index"`
+	ApplicationID  string  `gorm:"type:uuid;not null;index"`
+	VersionID      string  `gorm:"type:uuid;not null"`
+	Kind           string  `gorm:"not null"` // resume or cover_letter
+	Filename       string  `gorm:"type:text"`
+	OriginalPath   string  `gorm:"not null"`
+	QuarantinePath string  `gorm:"not null"`
+	Signature      string  `gorm:"not null"`
+	Scanner        string  `gorm:"not null"`
+	UploadedBy     *string `gorm:"type:uuid"` // nil when the candidate uploaded it
+	CreatedAt      time.Time
+}
+
+// Embedding is a stored vector for similarity search, written by the
+// postgres vector index. ID is the owning record's id, e.g. an application.
+type Embedding struct {
+	ID             string          `gorm:"primaryKey;type:text"`
+	OrganizationID string          `gorm:"type:uuid;not null;index"`
+	JobID          string          `gorm:"type:uuid;index"`
+	CandidateID    string          `gorm:"type:uuid"`
+	Model          string          `gorm:"not null"`
+	Vector         pq.Float32Array `gorm:"type:real[];not null"`
+	Metadata       string          `gorm:"type:jsonb"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// PipelineStage is one step of an organization's hiring workflow. Stages with
+// a JobID override the organization's stages for that job only.
+type PipelineStage struct {
+	ID             string         `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	OrganizationID string         `gorm:"type:uuid;not null;index"`
+	JobID          *string        `gorm:"type:uuid;index"`
+	Key            string         `gorm:"not null"`
+	Name           string         `gorm:"not null"`
+	Position       int            `gorm:"not null"`
+	AllowedNext    pq.StringArray `gorm:"type:text[]"`
+	IsTerminal     bool           `gorm:"not null;default:false"`
+	CreatedAt      time.Time
+}
+
+type ApplicationStageHistory struct {
+	ID            string  `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	ApplicationID string  `gorm:"type:uuid;not null;index"`
+	FromStage     string  `gorm:"not null"`
+	ToStage       string  `gorm:"not null"`
+	ActorID       *string `gorm:"type:uuid"` // nil when the candidate made the change
+	Note          string  `gorm:"type:text"`
+	CreatedAt     time.Time
+}
+
 type Job struct {
 	ID             string `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
 	OrganizationID string `gorm:"type:uuid;not null"`
@@ -78,14 +274,27 @@ type Job struct {
 	SkillsRequired  pq.StringArray `gorm:"type:text[]"`
 	EmploymentType  pq.StringArray `gorm:"type:text[]"`
 	SalaryRange     pq.StringArray `gorm:"type:text[]"`
-	IsActive        bool           `gorm:"not null;default:true"`
+	Status          string         `gorm:"not null;default:'open';index"` // draft, open, paused, closed, archived
 
 	CreatedByID      string `gorm:"type:uuid;not null"`
 	ApplicationCount int    `gorm:"default:0"`
 
-	PublicLink string `gorm:"unique;not null"` // resumelens.com/job/{org_id}/{job_id}
-	ShortLink  string `gorm:"unique"`          // resumelens.com/job/{job_id}
+	PublicLink string `gorm:"unique;not null"`  // resumelens.com/job/{org_id}/{job_id}
+	ShortLink  string `gorm:"unique"`           // resumelens.com/job/{job_id}
+	Slug       string `gorm:"type:text;unique"` // set from the title at creation and never changed, so shared links keep working
 	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	DeletedAt  gorm.DeletedAt `gorm:"index"`
+}
+
+type JobAuditLog struct {
+	ID             string `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	JobID          string `gorm:"type:uuid;not null;index"`
+	OrganizationID string `gorm:"type:uuid;not null"`
+	ActorID        string `gorm:"type:uuid;not null"`
+	Action         string `gorm:"not null"`   // create, update, status, delete
+	Changes        string `gorm:"type:jsonb"` // {"field": {"from": ..., "to": ...}}
+	CreatedAt      time.Time
 }
 
 type JobAnalytics struct {
@@ -0,0 +1,79 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	degreePattern      = regexp.MustCompile(`(?i)\b(ph\.?\s?d|doctor(?:ate)?|master(?:'?s)?|m\.?\s?sc|m\.?\s?s\.?|m\.?\s?a\.?|mba|m\.?\s?tech|m\.?\s?eng|bachelor(?:'?s)?|b\.?\s?sc|b\.?\s?s\.?|b\.?\s?a\.?|b\.?\s?tech|b\.?\s?eng|b\.?\s?e\.?|associate(?:'?s)?|diploma|high school|ged)\b`)
+	institutionPattern = regexp.MustCompile(`(?i)\b(university|universität|université|college|institute|school|academy|polytechnic|iit|mit)\b`)
+)
+
+// parseEducation groups lines into entries, starting a new entry whenever a
+// line names an institution or degree that the current entry already has.
+func parseEducation(lines []string) []Education {
+	entries := []Education{}
+	var current *Education
+
+	flush := func() {
+		if current == nil {
+			return
+		}
+		switch {
+		case current.Institution != "" && current.Degree != "":
+			current.Confidence = 0.8
+		default:
+			current.Confidence = 0.5
+		}
+		entries = append(entries, *current)
+		current = nil
+	}
+
+	for _, line := range lines {
+		var start, end string
+		text := line
+		if loc := dateRangePattern.FindStringSubmatchIndex(line); loc != nil {
+			start, end = line[loc[2]:loc[3]], line[loc[4]:loc[5]]
+			text = strings.TrimSpace(line[:loc[0]] + " " + line[loc[1]:])
+		} else if years := yearPattern.FindAllString(line, -1); len(years) == 1 {
+			end = years[0]
+		}
+		text = strings.Trim(text, " ,|()-–—")
+
+		isInstitution := institutionPattern.MatchString(text)
+		isDegree := degreePattern.MatchString(text)
+		if !isInstitution && !isDegree {
+			if current != nil && current.EndDate == "" && end != "" {
+				current.StartDate, current.EndDate = start, end
+			}
+			continue
+		}
+
+		if current == nil || (isInstitution && current.Institution != "") || (isDegree && !isInstitution && current.Degree != "") {
+			flush()
+			current = &Education{}
+		}
+
+		// A single line often holds both: "BSc Computer Science, MIT".
+		if isInstitution && isDegree {
+			parts := roleSeparators.Split(text, 2)
+			if len(parts) == 2 && institutionPattern.MatchString(parts[1]) {
+				current.Degree, current.Institution = strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+			} else if len(parts) == 2 {
+				current.Institution, current.Degree = strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+			} else {
+				current.Institution = text
+			}
+		} else if isInstitution {
+			current.Institution = text
+		} else {
+			current.Degree = text
+		}
+		if end != "" {
+			current.StartDate, current.EndDate = start, end
+		}
+	}
+	flush()
+	return entries
+}
@@ -0,0 +1,172 @@
+package parser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const monthNames = `jan(?:uary)?|feb(?:ruary)?|mar(?:ch)?|apr(?:il)?|may|jun(?:e)?|jul(?:y)?|aug(?:ust)?|sep(?:t(?:ember)?)?|oct(?:ober)?|nov(?:ember)?|dec(?:ember)?`
+
+var (
+	datePart         = `(?:(?:` + monthNames + `)\.?\s+\d{4}|\d{1,2}/\d{4}|\d{4})`
+	dateRangePattern = regexp.MustCompile(`(?i)(` + datePart + `)\s*(?:-|–|—|to|until)\s*(` + datePart + `|present|current|now|today|ongoing)`)
+	monthPattern     = regexp.MustCompile(`(?i)^(` + monthNames + `)`)
+	yearPattern      = regexp.MustCompile(`\d{4}`)
+	bulletPrefix     = regexp.MustCompile(`^[•·\-–*▪●◦]\s*`)
+	roleSeparators   = regexp.MustCompile(`\s+(?:at|@)\s+|\s*[|,]\s*|\s+[-–—]\s+`)
+)
+
+func isOngoing(s string) bool {
+	switch strings.ToLower(s) {
+	case "present", "current", "now", "today", "ongoing":
+		return true
+	}
+	return false
+}
+
+// parseDate turns "Mar 2020", "03/2020" or "2020" into a year and month.
+// Bare years count as January so ranges err on the short side.
+func parseDate(s string) (int, time.Month, bool) {
+	if isOngoing(s) {
+		t := time.Now()
+		return t.Year(), t.Month(), true
+	}
+	year, err := strconv.Atoi(yearPattern.FindString(s))
+	if err != nil {
+		return 0, 0, false
+	}
+	month := time.January
+	if m := monthPattern.FindString(s); m != "" {
+		month = monthIndex(strings.ToLower(m[:3]))
+	} else if i := strings.Index(s, "/"); i > 0 {
+		if n, err := strconv.Atoi(s[:i]); err == nil && n >= 1 && n <= 12 {
+			month = time.Month(n)
+		}
+	}
+	return year, month, true
+}
+
+func monthIndex(abbr string) time.Month {
+	for m := time.January; m <= time.December; m++ {
+		if strings.ToLower(m.String()[:3]) == abbr {
+			return m
+		}
+	}
+	return time.January
+}
+
+func monthsBetween(start, end string) int {
+	sy, sm, ok1 := parseDate(start)
+	ey, em, ok2 := parseDate(end)
+	if !ok1 || !ok2 {
+		return 0
+	}
+	months := (ey-sy)*12 + int(em-sm) + 1
+	if months < 0 || months > 50*12 {
+		return 0
+	}
+	return months
+}
+
+// parseExperience finds employment entries by their date ranges. The role
+// and employer come from the text around the dates on the same line, or the
+// line(s) just above it; everything up to the next entry is its description.
+func parseExperience(lines []string) []Experience {
+	entries := []Experience{}
+	var current *Experience
+	var description []string
+
+	flush := func() {
+		if current == nil {
+			description = nil
+			return
+		}
+		current.Description = strings.Join(description, "\n")
+		entries = append(entries, *current)
+		current = nil
+		description = nil
+	}
+
+	for _, line := range lines {
+		loc := dateRangePattern.FindStringSubmatchIndex(line)
+		if loc == nil {
+			description = append(description, bulletPrefix.ReplaceAllString(line, ""))
+			continue
+		}
+
+		// The previous line may have been the title of this entry rather
+		// than part of the last entry's description.
+		var heading string
+		rest := strings.TrimSpace(strings.Trim(line[:loc[0]]+" "+line[loc[1]:], " ,|()-–—"))
+		if rest == "" && len(description) > 0 {
+			heading = description[len(description)-1]
+			description = description[:len(description)-1]
+		}
+		flush()
+
+		start, end := line[loc[2]:loc[3]], line[loc[4]:loc[5]]
+		entry := Experience{
+			StartDate: strings.TrimSpace(start),
+			EndDate:   strings.TrimSpace(end),
+			Current:   isOngoing(strings.TrimSpace(end)),
+			Months:    monthsBetween(start, end),
+		}
+		if rest == "" {
+			rest = heading
+		}
+		entry.Title, entry.Company = splitRole(rest)
+
+		switch {
+		case entry.Title != "" && entry.Company != "":
+			entry.Confidence = 0.8
+		case entry.Title != "" || entry.Company != "":
+			entry.Confidence = 0.5
+		default:
+			entry.Confidence = 0.3
+		}
+		current = &entry
+	}
+	flush()
+	return entries
+}
+
+// splitRole separates "Senior Engineer at Acme" or "Acme | Senior Engineer"
+// into title and company. When the order is ambiguous the first part is
+// taken as the title.
+func splitRole(s string) (string, string) {
+	parts := roleSeparators.Split(s, 3)
+	var cleaned []string
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			cleaned = append(cleaned, p)
+		}
+	}
+	switch len(cleaned) {
+	case 0:
+		return "", ""
+	case 1:
+		return cleaned[0], ""
+	}
+	if looksLikeCompany(cleaned[0]) && !looksLikeCompany(cleaned[1]) {
+		return cleaned[1], cleaned[0]
+	}
+	return cleaned[0], cleaned[1]
+}
+
+var companySuffixes = []string{"inc", "inc.", "llc", "ltd", "ltd.", "gmbh", "corp", "corp.", "corporation", "co.", "plc", "ag", "sa", "bv", "pvt", "limited", "technologies", "labs", "group"}
+
+func looksLikeCompany(s string) bool {
+	words := strings.Fields(strings.ToLower(s))
+	if len(words) == 0 {
+		return false
+	}
+	last := words[len(words)-1]
+	for _, suffix := range companySuffixes {
+		if last == suffix {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,302 @@
+// Package parser turns extracted resume text into structured candidate data
+// using plain rules: section headings, regular expressions and a skills
+// vocabulary. Every field carries a confidence so callers can decide what is
+// safe to apply automatically.
+package parser
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Section names returned in Resume.Sections.
+const (
+	SectionHeader         = "header"
+	SectionSummary        = "summary"
+	SectionExperience     = "experience"
+	SectionEducation      = "education"
+	SectionSkills         = "skills"
+	SectionProjects       = "projects"
+	SectionCertifications = "certifications"
+	SectionOther          = "other"
+)
+
+// Field is a single extracted value with a 0–1 confidence.
+type Field struct {
+	Value      string  `json:"value"`
+	Confidence float64 `json:"confidence"`
+}
+
+type Experience struct {
+	Title       string  `json:"title,omitempty"`
+	Company     string  `json:"company,omitempty"`
+	StartDate   string  `json:"start_date,omitempty"`
+	EndDate     string  `json:"end_date,omitempty"`
+	Current     bool    `json:"current"`
+	Months      int     `json:"months,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Confidence  float64 `json:"confidence"`
+}
+
+type Education struct {
+	Institution string  `json:"institution,omitempty"`
+	Degree      string  `json:"degree,omitempty"`
+	StartDate   string  `json:"start_date,omitempty"`
+	EndDate     string  `json:"end_date,omitempty"`
+	Confidence  float64 `json:"confidence"`
+}
+
+type Resume struct {
+	Name       Field        `json:"name"`
+	Email      Field        `json:"email"`
+	Phone      Field        `json:"phone"`
+	LinkedIn   Field        `json:"linkedin"`
+	GitHub     Field        `json:"github"`
+	Location   Field        `json:"location"`
+	Experience []Experience `json:"experience"`
+	Education  []Education  `json:"education"`
+	Skills     []string     `json:"skills"`
+	// SkillsConfidence is 0.9 when a skills section was found and lower
+	// when skills were only spotted in running text.
+	SkillsConfidence float64  `json:"skills_confidence"`
+	TotalMonths      int      `json:"total_experience_months"`
+	Sections         []string `json:"sections"`
+}
+
+// Parse extracts structured data from resume text.
+func Parse(text string) *Resume {
+	sections := splitSections(text)
+
+	r := &Resume{
+		Experience: []Experience{},
+		Education:  []Education{},
+		Skills:     []string{},
+	}
+	for _, s := range sections {
+		r.Sections = append(r.Sections, s.name)
+	}
+
+	parseContact(r, text, sectionLines(sections, SectionHeader))
+	r.Experience = parseExperience(sectionLines(sections, SectionExperience))
+	r.Education = parseEducation(sectionLines(sections, SectionEducation))
+	r.Skills, r.SkillsConfidence = parseSkills(sectionLines(sections, SectionSkills), text)
+
+	for _, e := range r.Experience {
+		r.TotalMonths += e.Months
+	}
+	return r
+}
+
+type section struct {
+	name  string
+	lines []string
+}
+
+// sectionHeadings maps normalized heading text to a section.
+var sectionHeadings = map[string]string{
+	"summary":                     SectionSummary,
+	"professional summary":        SectionSummary,
+	"profile":                     SectionSummary,
+	"about me":                    SectionSummary,
+	"objective":                   SectionSummary,
+	"career objective":            SectionSummary,
+	"experience":                  SectionExperience,
+	"work experience":             SectionExperience,
+	"professional experience":     SectionExperience,
+	"employment":                  SectionExperience,
+	"employment history":          SectionExperience,
+	"work history":                SectionExperience,
+	"career history":              SectionExperience,
+	"relevant experience":         SectionExperience,
+	"education":                   SectionEducation,
+	"education and training":      SectionEducation,
+	"academic background":         SectionEducation,
+	"academic qualifications":     SectionEducation,
+	"qualifications":              SectionEducation,
+	"skills":                      SectionSkills,
+	"technical skills":            SectionSkills,
+	"key skills":                  SectionSkills,
+	"core skills":                 SectionSkills,
+	"core competencies":           SectionSkills,
+	"competencies":                SectionSkills,
+	"technologies":                SectionSkills,
+	"tech stack":                  SectionSkills,
+	"tools and technologies":      SectionSkills,
+	"skills and tools":            SectionSkills,
+	"projects":                    SectionProjects,
+	"personal projects":           SectionProjects,
+	"selected projects":           SectionProjects,
+	"certifications":              SectionCertifications,
+	"certificates":                SectionCertifications,
+	"licenses and certifications": SectionCertifications,
+	"awards":                      SectionOther,
+	"publications":                SectionOther,
+	"languages":                   SectionOther,
+	"interests":                   SectionOther,
+	"hobbies":                     SectionOther,
+	"references":                  SectionOther,
+	"volunteering":                SectionOther,
+	"volunteer experience":        SectionOther,
+}
+
+// headingName returns the section a line introduces, if it is a heading.
+func headingName(line string) (string, bool) {
+	if len(line) > 40 {
+		return "", false
+	}
+	key := strings.ToLower(strings.TrimSpace(strings.TrimRight(line, ":")))
+	key = strings.ReplaceAll(strings.ReplaceAll(key, "&amp;", "&"), "&", " and ")
+	key = strings.Join(strings.FieldsFunc(key, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}), " ")
+	name, ok := sectionHeadings[key]
+	return name, ok
+}
+
+func splitSections(text string) []section {
+	sections := []section{{name: SectionHeader}}
+	for _, raw := range strings.Split(text, "\n") {
+		line := strings.TrimSpace(raw)
+		if line == "" {
+			continue
+		}
+		if name, ok := headingName(line); ok {
+			sections = append(sections, section{name: name})
+			continue
+		}
+		current := &sections[len(sections)-1]
+		current.lines = append(current.lines, line)
+	}
+	return sections
+}
+
+// sectionLines concatenates every section with the given name; resumes
+// sometimes split experience into several headed blocks.
+func sectionLines(sections []section, name string) []string {
+	var lines []string
+	for _, s := range sections {
+		if s.name == name {
+			lines = append(lines, s.lines...)
+		}
+	}
+	return lines
+}
+
+var (
+	emailPattern    = regexp.MustCompile(`(?i)[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,}`)
+	phonePattern    = regexp.MustCompile(`(?:\+\d{1,3}[\s.\-]?)?(?:\(\d{1,4}\)[\s.\-]?)?\d[\d\s.\-]{6,14}\d`)
+	linkedInPattern = regexp.MustCompile(`(?i)(?:https?://)?(?:[a-z]{2,3}\.)?linkedin\.com/in/[a-z0-9_\-%]+/?`)
+	gitHubPattern   = regexp.MustCompile(`(?i)(?:https?://)?(?:www\.)?github\.com/[a-z0-9](?:[a-z0-9\-]{0,38})`)
+	locationPattern = regexp.MustCompile(`\b([A-Z][a-zA-Z.\-]+(?:\s[A-Z][a-zA-Z.\-]+){0,2}),\s*([A-Z]{2}|[A-Z][a-zA-Z]+(?:\s[A-Z][a-zA-Z]+)?)\b`)
+)
+
+func parseContact(r *Resume, text string, header []string) {
+	if m := emailPattern.FindString(text); m != "" {
+		r.Email = Field{Value: strings.ToLower(m), Confidence: 0.95}
+	}
+	if m := linkedInPattern.FindString(text); m != "" {
+		r.LinkedIn = Field{Value: NormalizeURL(m), Confidence: 0.95}
+	}
+	if m := gitHubPattern.FindString(text); m != "" {
+		r.GitHub = Field{Value: NormalizeURL(m), Confidence: 0.9}
+	}
+
+	// Contact details normally sit in the header; only fall back to the
+	// whole text for the phone number, with lower confidence, because dates
+	// and ids elsewhere look a lot like phone numbers.
+	for _, line := range header {
+		if phone := findPhone(line); phone != "" {
+			r.Phone = Field{Value: phone, Confidence: 0.85}
+			break
+		}
+	}
+	if r.Phone.Value == "" {
+		if phone := findPhone(text); phone != "" {
+			r.Phone = Field{Value: phone, Confidence: 0.5}
+		}
+	}
+
+	for i, line := range header {
+		if i >= 5 {
+			break
+		}
+		if looksLikeName(line) {
+			confidence := 0.6
+			if i == 0 {
+				confidence = 0.8
+			}
+			r.Name = Field{Value: line, Confidence: confidence}
+			break
+		}
+	}
+
+	for _, line := range header {
+		if emailPattern.MatchString(line) && !strings.ContainsAny(line, "|•·") {
+			continue
+		}
+		for _, part := range splitContactLine(line) {
+			if m := locationPattern.FindString(part); m != "" && len(m) == len(strings.TrimSpace(part)) {
+				r.Location = Field{Value: m, Confidence: 0.6}
+				return
+			}
+		}
+	}
+}
+
+func splitContactLine(line string) []string {
+	return strings.FieldsFunc(line, func(r rune) bool {
+		return r == '|' || r == '•' || r == '·' || r == '\t'
+	})
+}
+
+func findPhone(text string) string {
+	for _, m := range phonePattern.FindAllString(text, -1) {
+		digits := 0
+		for _, c := range m {
+			if unicode.IsDigit(c) {
+				digits++
+			}
+		}
+		// Year ranges like "2019 - 2021" have 8 digits; real numbers have
+		// at least 9.
+		if digits >= 9 && digits <= 15 && !dateRangePattern.MatchString(m) {
+			return strings.TrimSpace(m)
+		}
+	}
+	return ""
+}
+
+// looksLikeName accepts 2–4 capitalized words made only of letters.
+func looksLikeName(line string) bool {
+	words := strings.Fields(line)
+	if len(words) < 2 || len(words) > 4 {
+		return false
+	}
+	for _, w := range words {
+		runes := []rune(w)
+		if !unicode.IsUpper(runes[0]) {
+			return false
+		}
+		for _, c := range runes {
+			if !unicode.IsLetter(c) && c != '-' && c != '\'' && c != '.' {
+				return false
+			}
+		}
+	}
+	_, isHeading := headingName(line)
+	return !isHeading
+}
+
+// NormalizeURL lowercases a profile URL and strips the scheme, "www." and
+// trailing slashes so the same profile always compares equal.
+func NormalizeURL(raw string) string {
+	u := strings.ToLower(strings.TrimSpace(raw))
+	u = strings.TrimPrefix(u, "https://")
+	u = strings.TrimPrefix(u, "http://")
+	u = strings.TrimPrefix(u, "www.")
+	if strings.Contains(u, "linkedin.com/") {
+		u = u[strings.Index(u, "linkedin.com/"):]
+	}
+	return strings.TrimRight(u, "/")
+}
@@ -0,0 +1,170 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+const fixture = `Jane Doe
+San Francisco, CA | +1 (415) 555-0134 | jane.doe@example.com
+linkedin.com/in/janedoe | https://www.github.com/janedoe
+
+Summary
+Backend engineer who likes boring technology.
+
+WORK EXPERIENCE:
+Senior Engineer at Acme Inc
+Jan 2020 - Dec 2022
+- Built Kafka pipelines
+Globex Corp | Developer | 03/2018 - 2019
+Maintained billing services.
+
+Education
+BSc Computer Science, Stanford University
+2013 - 2017
+
+Skills & Tools
+Languages: Golang, Python
+Docker, K8s
+
+Interests
+Hiking
+`
+
+func TestParse(t *testing.T) {
+	r := Parse(fixture)
+
+	wantSections := []string{SectionHeader, SectionSummary, SectionExperience, SectionEducation, SectionSkills, SectionOther}
+	if !reflect.DeepEqual(r.Sections, wantSections) {
+		t.Errorf("Sections = %v; want %v", r.Sections, wantSections)
+	}
+
+	fields := []struct {
+		name string
+		got  Field
+		want Field
+	}{
+		{"Name", r.Name, Field{"Jane Doe", 0.8}},
+		{"Email", r.Email, Field{"jane.doe@example.com", 0.95}},
+		{"Phone", r.Phone, Field{"+1 (415) 555-0134", 0.85}},
+		{"LinkedIn", r.LinkedIn, Field{"linkedin.com/in/janedoe", 0.95}},
+		{"GitHub", r.GitHub, Field{"github.com/janedoe", 0.9}},
+		{"Location", r.Location, Field{"San Francisco, CA", 0.6}},
+	}
+	for _, f := range fields {
+		if f.got != f.want {
+			t.Errorf("%s = %+v; want %+v", f.name, f.got, f.want)
+		}
+	}
+
+	wantExperience := []Experience{
+		{Title: "Senior Engineer", Company: "Acme Inc", StartDate: "Jan 2020", EndDate: "Dec 2022", Months: 36, Description: "Built Kafka pipelines", Confidence: 0.8},
+		{Title: "Developer", Company: "Globex Corp", StartDate: "03/2018", EndDate: "2019", Months: 11, Description: "Maintained billing services.", Confidence: 0.8},
+	}
+	if !reflect.DeepEqual(r.Experience, wantExperience) {
+		t.Errorf("Experience = %+v; want %+v", r.Experience, wantExperience)
+	}
+	if r.TotalMonths != 47 {
+		t.Errorf("TotalMonths = %d; want 47", r.TotalMonths)
+	}
+
+	wantEducation := []Education{
+		{Institution: "Stanford University", Degree: "BSc Computer Science", StartDate: "2013", EndDate: "2017", Confidence: 0.8},
+	}
+	if !reflect.DeepEqual(r.Education, wantEducation) {
+		t.Errorf("Education = %+v; want %+v", r.Education, wantEducation)
+	}
+
+	// Kafka only appears in running text; the rest come from the section.
+	wantSkills := []string{"Docker", "Go", "Kafka", "Kubernetes", "Python"}
+	if !reflect.DeepEqual(r.Skills, wantSkills) || r.SkillsConfidence != 0.9 {
+		t.Errorf("Skills = %v (%.1f); want %v (0.9)", r.Skills, r.SkillsConfidence, wantSkills)
+	}
+}
+
+func TestParseContactFallbacks(t *testing.T) {
+	r := Parse("curriculum vitae\nJane Doe\n\nExperience\nCall me on 415 555 0134 any time\n")
+	if want := (Field{"Jane Doe", 0.6}); r.Name != want {
+		t.Errorf("Name = %+v; want %+v", r.Name, want)
+	}
+	if want := (Field{"415 555 0134", 0.5}); r.Phone != want {
+		t.Errorf("Phone = %+v; want %+v", r.Phone, want)
+	}
+
+	// Year ranges have too few digits to pass for a phone number.
+	if r := Parse("Jane Doe\n2019 - 2021\n"); r.Phone.Value != "" {
+		t.Errorf("Phone = %q; want none", r.Phone.Value)
+	}
+}
+
+func TestHeadingName(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+		ok   bool
+	}{
+		{"Experience", SectionExperience, true},
+		{"PROFESSIONAL EXPERIENCE:", SectionExperience, true},
+		{"Education & Training", SectionEducation, true},
+		{"Skills &amp; Tools", SectionSkills, true},
+		{"Licenses and Certifications", SectionCertifications, true},
+		{"Experience building distributed systems", "", false},
+		{"Jane Doe", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := headingName(tt.line)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("headingName(%q) = %q, %v; want %q, %v", tt.line, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestMonthsBetween(t *testing.T) {
+	now := time.Now()
+	sinceMarch2020 := (now.Year()-2020)*12 + int(now.Month()-time.March) + 1
+
+	tests := []struct {
+		start, end string
+		want       int
+	}{
+		{"Jan 2020", "Dec 2022", 36},
+		{"March 2021", "Mar 2021", 1},
+		{"03/2018", "2019", 11},
+		{"2015", "2017", 25},
+		{"Mar 2020", "Present", sinceMarch2020},
+		{"2022", "2020", 0},
+		{"1950", "2020", 0},
+	}
+	for _, tt := range tests {
+		if got := monthsBetween(tt.start, tt.end); got != tt.want {
+			t.Errorf("monthsBetween(%q, %q) = %d; want %d", tt.start, tt.end, got, tt.want)
+		}
+	}
+}
+
+func TestParseExperienceConfidence(t *testing.T) {
+	tests := []struct {
+		line       string
+		title      string
+		company    string
+		current    bool
+		confidence float64
+	}{
+		{"Acme Ltd - Staff Engineer, 2019 - present", "Staff Engineer", "Acme Ltd", true, 0.8},
+		{"Consultant (Jun 2016 - Aug 2017)", "Consultant", "", false, 0.5},
+		{"2015 - 2016", "", "", false, 0.3},
+	}
+	for _, tt := range tests {
+		entries := parseExperience([]string{tt.line})
+		if len(entries) != 1 {
+			t.Errorf("parseExperience(%q) = %d entries; want 1", tt.line, len(entries))
+			continue
+		}
+		e := entries[0]
+		if e.Title != tt.title || e.Company != tt.company || e.Current != tt.current || e.Confidence != tt.confidence {
+			t.Errorf("parseExperience(%q) = %+v; want title %q, company %q, current %v, confidence %.1f",
+				tt.line, e, tt.title, tt.company, tt.current, tt.confidence)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package parser
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// skillAliases maps lowercase spellings to a canonical skill name. Anything
+// not listed is kept as written when it comes from a skills section.
+var skillAliases = map[string]string{
+	"go": "Go", "golang": "Go",
+	"python": "Python", "python3": "Python",
+	"java":       "Java",
+	"javascript": "JavaScript", "js": "JavaScript", "ecmascript": "JavaScript",
+	"typescript": "TypeScript", "ts": "TypeScript",
+	"c": "C", "c++": "C++", "cpp": "C++", "c#": "C#", "csharp": "C#", ".net": ".NET", "dotnet": ".NET",
+	"ruby": "Ruby", "rails": "Ruby on Rails", "ruby on rails": "Ruby on Rails",
+	"php": "PHP", "laravel": "Laravel",
+	"rust": "Rust", "kotlin": "Kotlin", "swift": "Swift", "scala": "Scala", "elixir": "Elixir",
+	"r": "R", "matlab": "MATLAB", "perl": "Perl", "haskell": "Haskell",
+	"sql": "SQL", "nosql": "NoSQL",
+	"postgres": "PostgreSQL", "postgresql": "PostgreSQL", "psql": "PostgreSQL",
+	"mysql": "MySQL", "mariadb": "MariaDB", "sqlite": "SQLite", "oracle": "Oracle",
+	"mongodb": "MongoDB", "mongo": "MongoDB", "redis": "Redis", "cassandra": "Cassandra",
+	"elasticsearch": "Elasticsearch", "dynamodb": "DynamoDB", "kafka": "Kafka", "apache kafka": "Kafka",
+	"rabbitmq": "RabbitMQ",
+	"react":    "React", "reactjs": "React", "react.js": "React",
+	"angular": "Angular", "angularjs": "Angular", "vue": "Vue.js", "vuejs": "Vue.js", "vue.js": "Vue.js",
+	"svelte": "Svelte", "next.js": "Next.js", "nextjs": "Next.js",
+	"node": "Node.js", "nodejs": "Node.js", "node.js": "Node.js", "express": "Express",
+	"django": "Django", "flask": "Flask", "fastapi": "FastAPI", "spring": "Spring", "spring boot": "Spring Boot",
+	"html": "HTML", "html5": "HTML", "css": "CSS", "css3": "CSS", "sass": "Sass", "tailwind": "Tailwind CSS",
+	"graphql": "GraphQL", "rest": "REST", "grpc": "gRPC",
+	"docker": "Docker", "kubernetes": "Kubernetes", "k8s": "Kubernetes", "helm": "Helm",
+	"terraform": "Terraform", "ansible": "Ansible", "jenkins": "Jenkins",
+	"aws": "AWS", "amazon web services": "AWS", "gcp": "GCP", "google cloud": "GCP", "azure": "Azure",
+	"linux": "Linux", "bash": "Bash", "git": "Git", "ci/cd": "CI/CD",
+	"machine learning": "Machine Learning", "ml": "Machine Learning", "deep learning": "Deep Learning",
+	"tensorflow": "TensorFlow", "pytorch": "PyTorch", "pandas": "pandas", "numpy": "NumPy", "scikit-learn": "scikit-learn",
+	"nlp": "NLP", "data analysis": "Data Analysis", "tableau": "Tableau", "power bi": "Power BI", "excel": "Excel",
+	"spark": "Spark", "hadoop": "Hadoop", "airflow": "Airflow",
+	"microservices": "Microservices", "agile": "Agile", "scrum": "Scrum",
+	"figma": "Figma", "jira": "Jira",
+	"ios": "iOS", "android": "Android", "flutter": "Flutter", "react native": "React Native",
+	"project management": "Project Management", "communication": "Communication", "leadership": "Leadership",
+}
+
+// ambiguousSkills are only trusted inside a skills section, never when
+// spotted in running text ("go to market", "r&d", "rest of the team").
+var ambiguousSkills = map[string]bool{
+	"go": true, "c": true, "r": true, "rest": true, "spring": true, "express": true, "swift": true,
+	"node": true, "ml": true, "ts": true, "js": true, "communication": true, "leadership": true,
+	"excel": true, "oracle": true, "agile": true, "git": true,
+}
+
+var (
+	skillSeparators = regexp.MustCompile(`\s*(?:[,;|•·▪●/]|\band\b|\n)\s*`)
+	skillLabel      = regexp.MustCompile(`^[A-Za-z &]{2,30}:\s*`)
+	wordBoundary    = regexp.MustCompile(`[^a-z0-9+#.]+`)
+)
+
+// NormalizeSkill returns the canonical spelling of a skill, or the trimmed
+// input when the vocabulary doesn't know it.
+func NormalizeSkill(s string) string {
+	s = strings.TrimSpace(strings.Trim(s, ".-*"))
+	if canonical, ok := skillAliases[strings.ToLower(s)]; ok {
+		return canonical
+	}
+	return s
+}
+
+// parseSkills reads the skills section when there is one and additionally
+// scans the whole text for unambiguous known skills.
+func parseSkills(lines []string, text string) ([]string, float64) {
+	seen := make(map[string]bool)
+	var skills []string
+	add := func(skill string) {
+		key := strings.ToLower(skill)
+		if skill == "" || seen[key] {
+			return
+		}
+		seen[key] = true
+		skills = append(skills, skill)
+	}
+
+	for _, line := range lines {
+		// "Languages: Go, Python" style category labels.
+		line = skillLabel.ReplaceAllString(line, "")
+		for _, part := range skillSeparators.Split(line, -1) {
+			part = bulletPrefix.ReplaceAllString(strings.TrimSpace(part), "")
+			if len(part) == 0 || len(part) > 40 || len(strings.Fields(part)) > 4 {
+				continue
+			}
+			add(NormalizeSkill(part))
+		}
+	}
+	fromSection := len(skills) > 0
+
+	lower := " " + strings.Join(wordBoundary.Split(strings.ToLower(text), -1), " ") + " "
+	for alias, canonical := range skillAliases {
+		if ambiguousSkills[alias] {
+			continue
+		}
+		if strings.Contains(lower, " "+alias+" ") {
+			add(canonical)
+		}
+	}
+	sort.Strings(skills)
+
+	switch {
+	case fromSection:
+		return skills, 0.9
+	case len(skills) > 0:
+		return skills, 0.6
+	}
+	return []string{}, 0
+}
@@ -0,0 +1,200 @@
+package preview
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+func parseDOCX(data []byte) ([]block, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("preview: invalid docx: %w", err)
+	}
+
+	var document *zip.File
+	for _, f := range archive.File {
+		if f.Name == "word/document.xml" {
+			document = f
+			break
+		}
+	}
+	if document == nil {
+		return nil, fmt.Errorf("preview: invalid docx: word/document.xml missing")
+	}
+
+	rc, err := document.Open()
+	if err != nil {
+		return nil, fmt.Errorf("preview: invalid docx: %w", err)
+	}
+	defer rc.Close()
+
+	return docxBlocks(io.LimitReader(rc, maxPartSize))
+}
+
+// docxBuilder tracks where in the WordprocessingML tree the decoder is.
+// Paragraphs nest (text boxes sit inside runs) and so do tables, hence the
+// stacks.
+type docxBuilder struct {
+	containers []*[]block
+	paragraphs []*block
+	tables     []*[][][]block
+	props      run // formatting of the current run
+	inRunProps bool
+	inText     bool
+}
+
+func (d *docxBuilder) container() *[]block { return d.containers[len(d.containers)-1] }
+
+func (d *docxBuilder) paragraph() *block {
+	if len(d.paragraphs) == 0 {
+		return nil
+	}
+	return d.paragraphs[len(d.paragraphs)-1]
+}
+
+func (d *docxBuilder) add(r run) {
+	if p := d.paragraph(); p != nil {
+		p.runs = append(p.runs, r)
+	}
+}
+
+// docxBlocks walks word/document.xml, keeping text with its bold, italic
+// and underline formatting, heading and list paragraphs, and tables.
+func docxBlocks(r io.Reader) ([]block, error) {
+	var blocks []block
+	d := &docxBuilder{containers: []*[]block{&blocks}}
+	decoder := xml.NewDecoder(r)
+
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("preview: invalid docx xml: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			d.start(t)
+		case xml.EndElement:
+			d.end(t)
+		case xml.CharData:
+			if d.inText {
+				d.add(run{text: string(t), bold: d.props.bold, italic: d.props.italic, underline: d.props.underline})
+			}
+		}
+	}
+	return blocks, nil
+}
+
+func (d *docxBuilder) start(t xml.StartElement) {
+	switch t.Name.Local {
+	case "p":
+		d.paragraphs = append(d.paragraphs, &block{tag: "p"})
+	case "pStyle":
+		if p := d.paragraph(); p != nil {
+			p.tag = headingTag(attr(t, "val"), p.tag)
+		}
+	case "numPr":
+		if p := d.paragraph(); p != nil && p.tag == "p" {
+			p.tag = "li"
+		}
+	case "r":
+		d.props = run{}
+	case "rPr":
+		d.inRunProps = true
+	case "b", "i", "u":
+		if !d.inRunProps {
+			break
+		}
+		val := attr(t, "val")
+		on := val != "0" && val != "false" && val != "none"
+		switch t.Name.Local {
+		case "b":
+			d.props.bold = on
+		case "i":
+			d.props.italic = on
+		case "u":
+			d.props.underline = on
+		}
+	case "t":
+		d.inText = true
+	case "tab":
+		if !d.inRunProps {
+			d.add(run{text: "\t"})
+		}
+	case "br", "cr":
+		d.add(run{lineBreak: true})
+	case "tbl":
+		d.tables = append(d.tables, &[][][]block{})
+	case "tr":
+		if n := len(d.tables); n > 0 {
+			*d.tables[n-1] = append(*d.tables[n-1], nil)
+		}
+	case "tc":
+		if n := len(d.tables); n > 0 && len(*d.tables[n-1]) > 0 {
+			rows := *d.tables[n-1]
+			row := &rows[len(rows)-1]
+			*row = append(*row, nil)
+			d.containers = append(d.containers, &(*row)[len(*row)-1])
+		}
+	}
+}
+
+func (d *docxBuilder) end(t xml.EndElement) {
+	switch t.Name.Local {
+	case "p":
+		if n := len(d.paragraphs); n > 0 {
+			p := d.paragraphs[n-1]
+			d.paragraphs = d.paragraphs[:n-1]
+			if hasText(p.runs) {
+				c := d.container()
+				*c = append(*c, *p)
+			}
+		}
+	case "rPr":
+		d.inRunProps = false
+	case "t":
+		d.inText = false
+	case "tc":
+		if len(d.containers) > 1 {
+			d.containers = d.containers[:len(d.containers)-1]
+		}
+	case "tbl":
+		if n := len(d.tables); n > 0 {
+			rows := *d.tables[n-1]
+			d.tables = d.tables[:n-1]
+			c := d.container()
+			*c = append(*c, block{tag: "table", rows: rows})
+		}
+	}
+}
+
+// headingTag maps Word's built-in paragraph styles to heading tags.
+func headingTag(style, fallback string) string {
+	switch s := strings.ToLower(style); {
+	case s == "title" || s == "heading1":
+		return "h1"
+	case s == "subtitle" || s == "heading2":
+		return "h2"
+	case strings.HasPrefix(s, "heading"):
+		return "h3"
+	case strings.HasPrefix(s, "listparagraph"), strings.HasPrefix(s, "listbullet"), strings.HasPrefix(s, "listnumber"):
+		return "li"
+	}
+	return fallback
+}
+
+func attr(t xml.StartElement, local string) string {
+	for _, a := range t.Attr {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,146 @@
+// Package preview renders stored documents as self-contained HTML that a
+// browser can show inline. Documents are first read into a small model of
+// paragraphs, headings, list items and tables, and the HTML is written from
+// that model with every piece of text escaped, so no markup, script or
+// external reference from the input survives.
+package preview
+
+import (
+	"errors"
+	"html"
+	"strings"
+)
+
+// Version identifies the renderer. It is part of cached rendition names so
+// a change to the output invalidates earlier renditions.
+const Version = 1
+
+// ContentType is the content type of every rendition.
+const ContentType = "text/html; charset=utf-8"
+
+// ContentSecurityPolicy is sent with renditions served directly. It matches
+// the policy embedded in the document itself.
+const ContentSecurityPolicy = "default-src 'none'; style-src 'unsafe-inline'; sandbox"
+
+var ErrUnsupported = errors.New("preview: format cannot be previewed")
+
+// maxPartSize caps how much of a DOCX part is read.
+const maxPartSize = 32 << 20
+
+type run struct {
+	text      string
+	bold      bool
+	italic    bool
+	underline bool
+	lineBreak bool
+}
+
+type block struct {
+	tag  string // p, h1, h2, h3, li or table
+	runs []run
+	rows [][][]block // for tables: rows of cells of blocks
+}
+
+// Render converts a document of the given upload type name ("docx", "rtf"
+// or "txt") to HTML.
+func Render(format string, data []byte) ([]byte, error) {
+	var blocks []block
+	var err error
+
+	switch format {
+	case "docx":
+		blocks, err = parseDOCX(data)
+	case "rtf":
+		blocks = parseRTF(data)
+	case "txt":
+		blocks = parseText(string(data))
+	default:
+		return nil, ErrUnsupported
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	b.WriteString(`<!DOCTYPE html>
+<html><head><meta charset="utf-8">
+<meta http-equiv="Content-Security-Policy" content="default-src 'none'; style-src 'unsafe-inline'">
+<style>
+body{font-family:Georgia,serif;line-height:1.45;max-width:50em;margin:2em auto;padding:0 1em;color:#222}
+p,li,h1,h2,h3{white-space:pre-wrap;margin:.4em 0}
+h1,h2,h3{font-family:Helvetica,Arial,sans-serif;line-height:1.2}
+table{border-collapse:collapse;margin:1em 0}
+td{border:1px solid #ccc;padding:.3em .5em;vertical-align:top}
+</style></head><body>
+`)
+	writeBlocks(&b, blocks)
+	b.WriteString("</body></html>\n")
+	return []byte(b.String()), nil
+}
+
+func writeBlocks(b *strings.Builder, blocks []block) {
+	inList := false
+	for _, blk := range blocks {
+		if blk.tag == "li" && !inList {
+			b.WriteString("<ul>\n")
+			inList = true
+		} else if blk.tag != "li" && inList {
+			b.WriteString("</ul>\n")
+			inList = false
+		}
+
+		if blk.tag == "table" {
+			b.WriteString("<table>\n")
+			for _, row := range blk.rows {
+				b.WriteString("<tr>")
+				for _, cell := range row {
+					b.WriteString("<td>")
+					writeBlocks(b, cell)
+					b.WriteString("</td>")
+				}
+				b.WriteString("</tr>\n")
+			}
+			b.WriteString("</table>\n")
+			continue
+		}
+
+		b.WriteString("<" + blk.tag + ">")
+		writeRuns(b, blk.runs)
+		b.WriteString("</" + blk.tag + ">\n")
+	}
+	if inList {
+		b.WriteString("</ul>\n")
+	}
+}
+
+func writeRuns(b *strings.Builder, runs []run) {
+	for _, r := range runs {
+		if r.lineBreak {
+			b.WriteString("<br>")
+			continue
+		}
+		open, close := "", ""
+		if r.bold {
+			open, close = open+"<strong>", "</strong>"+close
+		}
+		if r.italic {
+			open, close = open+"<em>", "</em>"+close
+		}
+		if r.underline {
+			open, close = open+"<u>", "</u>"+close
+		}
+		b.WriteString(open)
+		b.WriteString(html.EscapeString(strings.ToValidUTF8(r.text, "�")))
+		b.WriteString(close)
+	}
+}
+
+// hasText reports whether runs contain anything visible.
+func hasText(runs []run) bool {
+	for _, r := range runs {
+		if strings.TrimSpace(r.text) != "" {
+			return true
+		}
+	}
+	return false
+}
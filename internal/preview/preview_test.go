@@ -0,0 +1,179 @@
+package preview
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// docx packs document.xml body content into a minimal DOCX.
+func docx(t testing.TB, body string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("word/document.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>` +
+		`<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` +
+		body + `</w:body></w:document>`))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// body returns the rendition without the fixed head.
+func body(t *testing.T, format string, data []byte) string {
+	t.Helper()
+	out, err := Render(format, data)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	s := string(out)
+	start := strings.Index(s, "<body>\n")
+	end := strings.LastIndex(s, "</body>")
+	if start < 0 || end < start {
+		t.Fatalf("no body in %q", s)
+	}
+	return s[start+len("<body>\n") : end]
+}
+
+func TestRenderRTF(t *testing.T) {
+	tests := []struct {
+		name string
+		rtf  string
+		want string
+	}{
+		{
+			name: "formatting and paragraphs",
+			rtf:  `{\rtf1\ansi{\fonttbl{\f0 Arial;}}\f0 Jane \b Doe\b0\par \i Go\i0  and \ul SQL\ulnone\par}`,
+			want: "<p>Jane <strong>Doe</strong></p>\n<p><em>Go</em> and <u>SQL</u></p>\n",
+		},
+		{
+			name: "escapes and symbols",
+			rtf:  `{\rtf1 caf\'e9 \{x\} \u8364?5\emdash 6\line next\par}`,
+			want: "<p>café {x} €5—6<br>next</p>\n",
+		},
+		{
+			name: "skipped destinations",
+			rtf:  `{\rtf1{\*\generator Word;}{\info{\title Secret}}Visible\par}`,
+			want: "<p>Visible</p>\n",
+		},
+		{
+			name: "markup is escaped",
+			rtf:  `{\rtf1 <script>alert("x")</script> & more\par}`,
+			want: "<p>&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt; &amp; more</p>\n",
+		},
+		{
+			name: "binary data is skipped",
+			rtf:  `{\rtf1 before{\bin4 \par}after\par}`,
+			want: "<p>beforeafter</p>\n",
+		},
+		{
+			name: "binary length beyond the document",
+			rtf:  `{\rtf1 text\bin9223372036854775807 rest`,
+			want: "<p>text</p>\n",
+		},
+		{
+			name: "binary length beyond int range",
+			rtf:  `{\rtf1 text\bin99999999999999999999 rest\par}`,
+			want: "<p>textrest</p>\n",
+		},
+		{
+			name: "unterminated",
+			rtf:  `{\rtf1 text\'`,
+			want: "<p>text</p>\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := body(t, "rtf", []byte(tt.rtf)); got != tt.want {
+				t.Errorf("body = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderDOCX(t *testing.T) {
+	tests := []struct {
+		name string
+		xml  string
+		want string
+	}{
+		{
+			name: "runs and headings",
+			xml: `<w:p><w:pPr><w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t>Jane Doe</w:t></w:r></w:p>` +
+				`<w:p><w:r><w:rPr><w:b/></w:rPr><w:t>Go</w:t></w:r><w:r><w:rPr><w:i w:val="0"/></w:rPr><w:t xml:space="preserve"> engineer</w:t></w:r></w:p>`,
+			want: "<h1>Jane Doe</h1>\n<p><strong>Go</strong> engineer</p>\n",
+		},
+		{
+			name: "lists",
+			xml: `<w:p><w:pPr><w:numPr><w:numId w:val="1"/></w:numPr></w:pPr><w:r><w:t>one</w:t></w:r></w:p>` +
+				`<w:p><w:pPr><w:pStyle w:val="ListBullet"/></w:pPr><w:r><w:t>two</w:t></w:r></w:p>` +
+				`<w:p><w:r><w:t>after</w:t><w:br/><w:tab/><w:t>tab</w:t></w:r></w:p>`,
+			want: "<ul>\n<li>one</li>\n<li>two</li>\n</ul>\n<p>after<br>\ttab</p>\n",
+		},
+		{
+			name: "tables",
+			xml: `<w:tbl><w:tr><w:tc><w:p><w:r><w:t>2019</w:t></w:r></w:p></w:tc>` +
+				`<w:tc><w:p><w:r><w:t>Acme &amp; Co</w:t></w:r></w:p></w:tc></w:tr></w:tbl>`,
+			want: "<table>\n<tr><td><p>2019</p>\n</td><td><p>Acme &amp; Co</p>\n</td></tr>\n</table>\n",
+		},
+		{
+			name: "markup is escaped",
+			xml:  `<w:p><w:r><w:t>&lt;img src=x onerror=alert(1)&gt;</w:t></w:r></w:p>`,
+			want: "<p>&lt;img src=x onerror=alert(1)&gt;</p>\n",
+		},
+		{
+			name: "cell outside a table",
+			xml:  `<w:tr><w:tc><w:p><w:r><w:t>cell</w:t></w:r></w:p></w:tc></w:tr>`,
+			want: "<p>cell</p>\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := body(t, "docx", docx(t, tt.xml)); got != tt.want {
+				t.Errorf("body = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderDOCXInvalid(t *testing.T) {
+	for name, data := range map[string][]byte{
+		"not a zip":        []byte("PK\x03\x04 not really"),
+		"missing document": func() []byte { var b bytes.Buffer; zip.NewWriter(&b).Close(); return b.Bytes() }(),
+		"bad xml":          docx(t, `<w:p><w:r><w:t>open`),
+	} {
+		if _, err := Render("docx", data); err == nil {
+			t.Errorf("%s: Render succeeded; want an error", name)
+		}
+	}
+}
+
+func TestRenderText(t *testing.T) {
+	got := body(t, "txt", []byte("Jane Doe\r\nGo <engineer>\n\n\n  \nReferences\rupon request\n\xff"))
+	want := "<p>Jane Doe<br>Go &lt;engineer&gt;</p>\n<p>References<br>upon request<br>�</p>\n"
+	if got != want {
+		t.Errorf("body = %q; want %q", got, want)
+	}
+}
+
+func TestRenderUnsupported(t *testing.T) {
+	if _, err := Render("pdf", []byte("%PDF-1.4")); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("err = %v; want ErrUnsupported", err)
+	}
+}
+
+func FuzzRender(f *testing.F) {
+	f.Add("rtf", []byte(`{\rtf1 caf\'e9 {\b bold}\bin3 xyz\u8364?\par}`))
+	f.Add("txt", []byte("line\n\nline"))
+	f.Add("docx", docx(f, `<w:tbl><w:tr><w:tc><w:p><w:r><w:t>x</w:t></w:r></w:p></w:tc></w:tr></w:tbl>`))
+	f.Fuzz(func(t *testing.T, format string, data []byte) {
+		Render(format, data)
+	})
+}
@@ -0,0 +1,241 @@
+package preview
+
+import (
+	"strconv"
+	"strings"
+)
+
+// rtfSkipped are destinations whose content is not document text.
+var rtfSkipped = map[string]bool{
+	"fonttbl": true, "colortbl": true, "stylesheet": true, "info": true,
+	"pict": true, "object": true, "header": true, "headerl": true,
+	"headerr": true, "headerf": true, "footer": true, "footerl": true,
+	"footerr": true, "footerf": true, "listtable": true,
+	"listoverridetable": true, "rsidtbl": true, "themedata": true,
+	"datastore": true, "latentstyles": true, "xmlnstbl": true,
+	"fldinst": true, "generator": true, "filetbl": true, "revtbl": true,
+}
+
+// rtfSymbols are control words that stand for a character.
+var rtfSymbols = map[string]string{
+	"emdash": "—", "endash": "–", "bullet": "•", "lquote": "‘",
+	"rquote": "’", "ldblquote": "“", "rdblquote": "”", "tab": "\t",
+	"cell": "\t", "emspace": " ", "enspace": " ",
+}
+
+// cp1252 maps the bytes 0x80–0x9F of Windows-1252, the usual RTF code page,
+// that differ from Latin-1.
+var cp1252 = map[byte]rune{
+	0x80: '€', 0x82: '‚', 0x83: 'ƒ', 0x84: '„', 0x85: '…', 0x86: '†',
+	0x87: '‡', 0x88: 'ˆ', 0x89: '‰', 0x8A: 'Š', 0x8B: '‹', 0x8C: 'Œ',
+	0x8E: 'Ž', 0x91: '‘', 0x92: '’', 0x93: '“', 0x94: '”', 0x95: '•',
+	0x96: '–', 0x97: '—', 0x98: '˜', 0x99: '™', 0x9A: 'š', 0x9B: '›',
+	0x9C: 'œ', 0x9E: 'ž', 0x9F: 'Ÿ',
+}
+
+type rtfState struct {
+	bold, italic, underline bool
+	skip                    bool
+	uc                      int // characters to skip after \uN
+}
+
+// rtfParser keeps text with bold, italic and underline formatting and
+// turns \par and \row into paragraphs. Everything else, including tables,
+// is flattened to text.
+type rtfParser struct {
+	data   []byte
+	pos    int
+	stack  []rtfState
+	state  rtfState
+	blocks []block
+	runs   []run
+	text   strings.Builder
+	// pendingSkip counts fallback characters still to drop after \uN.
+	pendingSkip int
+}
+
+func parseRTF(data []byte) []block {
+	p := &rtfParser{data: data, state: rtfState{uc: 1}}
+	p.parse()
+	p.endParagraph()
+	return p.blocks
+}
+
+func (p *rtfParser) parse() {
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		p.pos++
+		switch c {
+		case '{':
+			p.flushText()
+			p.pendingSkip = 0
+			p.stack = append(p.stack, p.state)
+		case '}':
+			p.flushText()
+			p.pendingSkip = 0
+			if n := len(p.stack); n > 0 {
+				p.state = p.stack[n-1]
+				p.stack = p.stack[:n-1]
+			}
+		case '\\':
+			p.control()
+		case '\r', '\n':
+		default:
+			p.char(decodeByte(c))
+		}
+	}
+}
+
+func (p *rtfParser) control() {
+	if p.pos >= len(p.data) {
+		return
+	}
+	c := p.data[p.pos]
+	if !isLetter(c) {
+		p.pos++
+		switch c {
+		case '\\', '{', '}':
+			p.char(rune(c))
+		case '\'':
+			if p.pos+2 <= len(p.data) {
+				if b, err := strconv.ParseUint(string(p.data[p.pos:p.pos+2]), 16, 8); err == nil {
+					p.char(decodeByte(byte(b)))
+				}
+				p.pos += 2
+			}
+		case '*':
+			p.state.skip = true
+		case '~':
+			p.char(' ')
+		case '_':
+			p.char('-')
+		case '\r', '\n':
+			p.endParagraph()
+		}
+		return
+	}
+
+	start := p.pos
+	for p.pos < len(p.data) && isLetter(p.data[p.pos]) {
+		p.pos++
+	}
+	word := string(p.data[start:p.pos])
+
+	param, hasParam := 0, false
+	numStart := p.pos
+	if p.pos < len(p.data) && p.data[p.pos] == '-' {
+		p.pos++
+	}
+	for p.pos < len(p.data) && p.data[p.pos] >= '0' && p.data[p.pos] <= '9' {
+		p.pos++
+	}
+	if p.pos > numStart {
+		if n, err := strconv.Atoi(string(p.data[numStart:p.pos])); err == nil {
+			param, hasParam = n, true
+		}
+	}
+	if p.pos < len(p.data) && p.data[p.pos] == ' ' {
+		p.pos++
+	}
+
+	p.word(word, param, hasParam)
+}
+
+func (p *rtfParser) word(word string, param int, hasParam bool) {
+	if rtfSkipped[word] {
+		p.state.skip = true
+		return
+	}
+	if s, ok := rtfSymbols[word]; ok {
+		for _, r := range s {
+			p.char(r)
+		}
+		return
+	}
+
+	on := !hasParam || param != 0
+	switch word {
+	case "par", "row", "sect", "page":
+		p.endParagraph()
+	case "line":
+		p.flushText()
+		if !p.state.skip {
+			p.runs = append(p.runs, run{lineBreak: true})
+		}
+	case "b":
+		p.setFormat(func(s *rtfState) { s.bold = on })
+	case "i":
+		p.setFormat(func(s *rtfState) { s.italic = on })
+	case "ul":
+		p.setFormat(func(s *rtfState) { s.underline = on })
+	case "ulnone":
+		p.setFormat(func(s *rtfState) { s.underline = false })
+	case "plain":
+		p.setFormat(func(s *rtfState) { s.bold, s.italic, s.underline = false, false, false })
+	case "uc":
+		if hasParam && param >= 0 {
+			p.state.uc = param
+		}
+	case "u":
+		if hasParam {
+			if param < 0 {
+				param += 65536
+			}
+			p.char(rune(param))
+			p.pendingSkip = p.state.uc
+		}
+	case "bin":
+		if !hasParam || param <= 0 {
+			break
+		}
+		if param > len(p.data)-p.pos {
+			p.pos = len(p.data)
+		} else {
+			p.pos += param
+		}
+	}
+}
+
+func (p *rtfParser) setFormat(apply func(*rtfState)) {
+	p.flushText()
+	apply(&p.state)
+}
+
+func (p *rtfParser) char(r rune) {
+	if p.pendingSkip > 0 {
+		p.pendingSkip--
+		return
+	}
+	if p.state.skip {
+		return
+	}
+	p.text.WriteRune(r)
+}
+
+// flushText ends the current run so a formatting change starts a new one.
+func (p *rtfParser) flushText() {
+	if p.text.Len() == 0 {
+		return
+	}
+	p.runs = append(p.runs, run{text: p.text.String(), bold: p.state.bold, italic: p.state.italic, underline: p.state.underline})
+	p.text.Reset()
+}
+
+func (p *rtfParser) endParagraph() {
+	p.flushText()
+	if hasText(p.runs) {
+		p.blocks = append(p.blocks, block{tag: "p", runs: p.runs})
+	}
+	p.runs = nil
+}
+
+func decodeByte(b byte) rune {
+	if r, ok := cp1252[b]; ok {
+		return r
+	}
+	return rune(b)
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,31 @@
+package preview
+
+import "strings"
+
+// parseText makes a paragraph of every run of non-blank lines, keeping the
+// line breaks within it.
+func parseText(text string) []block {
+	text = strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\r", "\n")
+
+	var blocks []block
+	var runs []run
+	flush := func() {
+		if hasText(runs) {
+			blocks = append(blocks, block{tag: "p", runs: runs})
+		}
+		runs = nil
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+		if len(runs) > 0 {
+			runs = append(runs, run{lineBreak: true})
+		}
+		runs = append(runs, run{text: line})
+	}
+	flush()
+	return blocks
+}
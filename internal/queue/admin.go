@@ -0,0 +1,130 @@
+package queue
+
+import (
+	"sort"
+	"time"
+
+	"github.com/resumelens/authservice/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// KindStats counts an organization's jobs of one kind.
+type KindStats struct {
+	Kind        string `json:"kind"`
+	Pending     int    `json:"pending"`
+	Running     int    `json:"running"`
+	Retrying    int    `json:"retrying"` // pending after at least one failure
+	DeadLetters int    `json:"dead_letters"`
+}
+
+// Stats summarizes the queue for one organization.
+func (q *Queue) Stats(orgID string) ([]KindStats, error) {
+	var live []KindStats
+	err := q.db.Model(&models.QueueJob{}).
+		Select("kind, "+
+			"COUNT(*) FILTER (WHERE status = ?) AS pending, "+
+			"COUNT(*) FILTER (WHERE status = ?) AS running, "+
+			"COUNT(*) FILTER (WHERE status = ? AND attempts > 0) AS retrying",
+			StatusPending, StatusRunning, StatusPending).
+		Where("organization_id = ?", orgID).
+		Group("kind").
+		Scan(&live).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var dead []KindStats
+	err = q.db.Model(&models.DeadLetterJob{}).
+		Select("kind, COUNT(*) AS dead_letters").
+		Where("organization_id = ?", orgID).
+		Group("kind").
+		Scan(&dead).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byKind := make(map[string]KindStats)
+	for _, s := range live {
+		byKind[s.Kind] = s
+	}
+	for _, d := range dead {
+		s := byKind[d.Kind]
+		s.Kind = d.Kind
+		s.DeadLetters = d.DeadLetters
+		byKind[d.Kind] = s
+	}
+
+	stats := make([]KindStats, 0, len(byKind))
+	for _, s := range byKind {
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Kind < stats[j].Kind })
+	return stats, nil
+}
+
+// DeadLetters returns a page of an organization's failed jobs, newest
+// first, and the total number of them.
+func (q *Queue) DeadLetters(orgID, kind string, limit, offset int) ([]models.DeadLetterJob, int64, error) {
+	query := q.db.Model(&models.DeadLetterJob{}).Where("organization_id = ?", orgID)
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	jobs := []models.DeadLetterJob{}
+	if err := query.Order("failed_at DESC").Limit(limit).Offset(offset).Find(&jobs).Error; err != nil {
+		return nil, 0, err
+	}
+	return jobs, total, nil
+}
+
+// Redrive moves a dead-lettered job back onto the queue, under its original
+// id, with a fresh set of attempts.
+func (q *Queue) Redrive(orgID, id string) (*models.QueueJob, error) {
+	var job models.QueueJob
+	err := q.db.Transaction(func(tx *gorm.DB) error {
+		var dead []models.DeadLetterJob
+		if err := tx.Clauses(clause.Returning{}).
+			Where("id = ? AND organization_id = ?", id, orgID).
+			Delete(&dead).Error; err != nil {
+			return err
+		}
+		if len(dead) == 0 {
+			return ErrNotFound
+		}
+
+		job = models.QueueJob{
+			ID:             dead[0].ID,
+			OrganizationID: dead[0].OrganizationID,
+			Kind:           dead[0].Kind,
+			Payload:        dead[0].Payload,
+			Status:         StatusPending,
+			RunAt:          time.Now(),
+			MaxAttempts:    q.opts.MaxAttempts,
+			LastError:      dead[0].LastError,
+			CreatedAt:      dead[0].CreatedAt,
+		}
+		return tx.Create(&job).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	q.notify()
+	return &job, nil
+}
+
+// Discard deletes a dead-lettered job for good.
+func (q *Queue) Discard(orgID, id string) error {
+	result := q.db.Where("id = ? AND organization_id = ?", id, orgID).Delete(&models.DeadLetterJob{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
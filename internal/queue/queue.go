@@ -0,0 +1,282 @@
+// Package queue runs background work from a Postgres table. Jobs are claimed
+// with FOR UPDATE SKIP LOCKED so any number of workers, in any number of
+// processes, can share the table; failures are retried with exponential
+// backoff and end up in a dead-letter table after the last attempt.
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/resumelens/authservice/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	StatusPending = "pending"
+	StatusRunning = "running"
+)
+
+var ErrNotFound = errors.New("queue: job not found")
+
+// Handler runs one job. Returning an error schedules a retry unless the
+// error is wrapped with Permanent.
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks an error that retrying cannot fix, such as a payload that
+// refers to a deleted row. The job is dead-lettered immediately.
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+type Options struct {
+	Workers      int
+	MaxAttempts  int
+	PollInterval time.Duration
+	// BaseBackoff is the delay before the first retry; it doubles with
+	// every attempt up to MaxBackoff.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// JobTimeout bounds a single run. A job still marked running after
+	// twice this long is assumed to belong to a dead worker and is claimed
+	// again.
+	JobTimeout time.Duration
+}
+
+type Queue struct {
+	db       *gorm.DB
+	opts     Options
+	mu       sync.RWMutex
+	handlers map[string]Handler
+	wake     chan struct{}
+}
+
+func New(db *gorm.DB, opts Options) *Queue {
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 5
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	if opts.BaseBackoff <= 0 {
+		opts.BaseBackoff = 10 * time.Second
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = time.Hour
+	}
+	if opts.JobTimeout <= 0 {
+		opts.JobTimeout = 5 * time.Minute
+	}
+	return &Queue{
+		db:       db,
+		opts:     opts,
+		handlers: make(map[string]Handler),
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Register sets the handler for a kind of job. It must be called before
+// Start.
+func (q *Queue) Register(kind string, h Handler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[kind] = h
+}
+
+// Enqueue adds a job. Pass the transaction that writes the data the job
+// works on so the job only becomes visible if that write commits; tx may be
+// nil to use the queue's own connection. orgID may be empty for jobs that
+// belong to no organization.
+//
+// Idle workers are only woken for jobs written outside a transaction. A
+// worker woken before the transaction commits would find nothing and go
+// back to sleep, so jobs enqueued in one are picked up on the next poll.
+func (q *Queue) Enqueue(tx *gorm.DB, orgID, kind string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	if tx == nil {
+		tx = q.db
+	}
+	job := models.QueueJob{
+		Kind:        kind,
+		Payload:     string(data),
+		Status:      StatusPending,
+		RunAt:       time.Now(),
+		MaxAttempts: q.opts.MaxAttempts,
+	}
+	if orgID != "" {
+		job.OrganizationID = &orgID
+	}
+	if err := tx.Create(&job).Error; err != nil {
+		return fmt.Errorf("failed to enqueue %s: %w", kind, err)
+	}
+	if _, inTx := tx.Statement.ConnPool.(gorm.TxCommitter); !inTx {
+		q.notify()
+	}
+	return nil
+}
+
+// notify wakes an idle worker in this process. Workers in other processes
+// pick the job up on their next poll.
+func (q *Queue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start launches the workers. They stop when ctx is cancelled.
+func (q *Queue) Start(ctx context.Context) {
+	for i := 0; i < q.opts.Workers; i++ {
+		go q.work(ctx)
+	}
+}
+
+func (q *Queue) work(ctx context.Context) {
+	for {
+		job, err := q.claim(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Queue: failed to claim job: %v", err)
+		}
+		if job != nil {
+			q.run(ctx, job)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.wake:
+		case <-time.After(q.opts.PollInterval):
+		}
+	}
+}
+
+// claim marks the next due job as running and returns it, or nil when there
+// is nothing to do.
+func (q *Queue) claim(ctx context.Context) (*models.QueueJob, error) {
+	now := time.Now()
+	stale := now.Add(-2 * q.opts.JobTimeout)
+
+	var jobs []models.QueueJob
+	err := q.db.WithContext(ctx).Raw(`
+		UPDATE queue_jobs SET status = ?, attempts = attempts + 1, locked_at = ?, updated_at = ?
+		WHERE id = (
+			SELECT id FROM queue_jobs
+			WHERE (status = ? AND run_at <= ?) OR (status = ? AND locked_at < ?)
+			ORDER BY run_at
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING *`,
+		StatusRunning, now, now,
+		StatusPending, now, StatusRunning, stale,
+	).Scan(&jobs).Error
+	if err != nil || len(jobs) == 0 {
+		return nil, err
+	}
+	return &jobs[0], nil
+}
+
+func (q *Queue) run(ctx context.Context, job *models.QueueJob) {
+	q.mu.RLock()
+	handler, ok := q.handlers[job.Kind]
+	q.mu.RUnlock()
+
+	var err error
+	if !ok {
+		err = Permanent(fmt.Errorf("no handler registered for %q", job.Kind))
+	} else {
+		err = q.call(ctx, handler, job)
+	}
+
+	if err == nil {
+		if err := q.db.Delete(&models.QueueJob{}, "id = ?", job.ID).Error; err != nil {
+			log.Printf("Queue: failed to delete finished job %s: %v", job.ID, err)
+		}
+		return
+	}
+
+	var permanent permanentError
+	if errors.As(err, &permanent) || job.Attempts >= job.MaxAttempts {
+		log.Printf("Queue: %s job %s failed permanently after %d attempt(s): %v", job.Kind, job.ID, job.Attempts, err)
+		if err := q.deadLetter(job, err); err != nil {
+			log.Printf("Queue: failed to dead-letter job %s: %v", job.ID, err)
+		}
+		return
+	}
+
+	delay := q.backoff(job.Attempts)
+	log.Printf("Queue: %s job %s failed (attempt %d of %d), retrying in %s: %v", job.Kind, job.ID, job.Attempts, job.MaxAttempts, delay, err)
+	err = q.db.Model(&models.QueueJob{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+		"status":     StatusPending,
+		"run_at":     time.Now().Add(delay),
+		"locked_at":  nil,
+		"last_error": err.Error(),
+	}).Error
+	if err != nil {
+		log.Printf("Queue: failed to reschedule job %s: %v", job.ID, err)
+	}
+}
+
+// call runs handler with the job timeout, turning a panic into an error so
+// one bad job can't take a worker down.
+func (q *Queue) call(ctx context.Context, handler Handler, job *models.QueueJob) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, q.opts.JobTimeout)
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(ctx, json.RawMessage(job.Payload))
+}
+
+// backoff returns the delay before the given attempt's retry, with ±20%
+// jitter so jobs that failed together don't retry together.
+func (q *Queue) backoff(attempt int) time.Duration {
+	delay := q.opts.BaseBackoff
+	for i := 1; i < attempt && delay < q.opts.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > q.opts.MaxBackoff {
+		delay = q.opts.MaxBackoff
+	}
+	jitter := time.Duration(rand.Int63n(int64(delay)/5*2+1)) - delay/5
+	return delay + jitter
+}
+
+func (q *Queue) deadLetter(job *models.QueueJob, cause error) error {
+	return q.db.Transaction(func(tx *gorm.DB) error {
+		entry := models.DeadLetterJob{
+			ID:             job.ID,
+			OrganizationID: job.OrganizationID,
+			Kind:           job.Kind,
+			Payload:        job.Payload,
+			Attempts:       job.Attempts,
+			LastError:      cause.Error(),
+			CreatedAt:      job.CreatedAt,
+			FailedAt:       time.Now(),
+		}
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.QueueJob{}, "id = ?", job.ID).Error
+	})
+}
@@ -0,0 +1,279 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/resumelens/authservice/internal/models"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestBackoff(t *testing.T) {
+	q := New(nil, Options{BaseBackoff: 10 * time.Second, MaxBackoff: time.Minute})
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{3, 40 * time.Second},
+		{4, time.Minute},
+		{30, time.Minute},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 100; i++ {
+			got := q.backoff(tt.attempt)
+			if got < tt.want*4/5 || got > tt.want*6/5 {
+				t.Fatalf("backoff(%d) = %s; want %s ±20%%", tt.attempt, got, tt.want)
+			}
+		}
+	}
+}
+
+// testDB connects to TEST_DATABASE_URL, skipping the test without it. The
+// queue tables live in a schema of their own so workers in other packages'
+// tests never claim these jobs, nor these tests theirs.
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	conn, err := gorm.Open(postgres.Open(url), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := "queue_test_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	if err := conn.Exec(`CREATE EXTENSION IF NOT EXISTS "uuid-ossp"`).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Exec("DROP SCHEMA " + schema + " CASCADE") })
+
+	sep := "?"
+	if strings.Contains(url, "?") {
+		sep = "&"
+	}
+	if strings.Contains(url, "://") {
+		url += sep + "search_path=" + schema + ",public"
+	} else {
+		url += " search_path=" + schema + ",public"
+	}
+	scoped, err := gorm.Open(postgres.Open(url), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := scoped.AutoMigrate(&models.QueueJob{}, &models.DeadLetterJob{}); err != nil {
+		t.Fatal(err)
+	}
+	if sqlDB, err := scoped.DB(); err == nil {
+		t.Cleanup(func() { sqlDB.Close() })
+	}
+	return scoped
+}
+
+func enqueue(t *testing.T, q *Queue, orgID, kind string) models.QueueJob {
+	t.Helper()
+	if err := q.Enqueue(nil, orgID, kind, map[string]string{"kind": kind}); err != nil {
+		t.Fatal(err)
+	}
+	var job models.QueueJob
+	if err := q.db.Where("kind = ?", kind).First(&job).Error; err != nil {
+		t.Fatal(err)
+	}
+	return job
+}
+
+func claim(t *testing.T, q *Queue) *models.QueueJob {
+	t.Helper()
+	job, err := q.claim(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return job
+}
+
+// TestClaim checks that a claim skips rows another worker has locked rather
+// than waiting for them, and only takes jobs that are due.
+func TestClaim(t *testing.T) {
+	conn := testDB(t)
+	q := New(conn, Options{JobTimeout: time.Minute})
+	org := uuid.NewString()
+	first := enqueue(t, q, org, "first")
+	second := enqueue(t, q, org, "second")
+	later := enqueue(t, q, org, "later")
+	conn.Model(&models.QueueJob{}).Where("id = ?", later.ID).Update("run_at", time.Now().Add(time.Hour))
+
+	// Another worker holds the first job.
+	tx := conn.Begin()
+	defer tx.Rollback()
+	if err := tx.Exec("SELECT id FROM queue_jobs WHERE id = ? FOR UPDATE", first.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	job, err := q.claim(ctx)
+	if err != nil {
+		t.Fatalf("claim with a locked row: %v", err)
+	}
+	if job == nil || job.ID != second.ID {
+		t.Fatalf("claimed %v; want the second job", job)
+	}
+	if job.Status != StatusRunning || job.Attempts != 1 || job.LockedAt == nil {
+		t.Errorf("claimed job = %+v; want running, attempt 1, locked", job)
+	}
+	if job := claim(t, q); job != nil {
+		t.Fatalf("claimed %s while the rest are locked or not due", job.Kind)
+	}
+
+	tx.Rollback()
+	if job := claim(t, q); job == nil || job.ID != first.ID {
+		t.Fatalf("claimed %v after the lock was released; want the first job", job)
+	}
+
+	// A job left running by a worker that died is claimed again.
+	stale := time.Now().Add(-3 * time.Minute)
+	conn.Model(&models.QueueJob{}).Where("id = ?", second.ID).Update("locked_at", stale)
+	if job := claim(t, q); job == nil || job.ID != second.ID || job.Attempts != 2 {
+		t.Fatalf("claimed %+v; want the stale second job on attempt 2", job)
+	}
+}
+
+// TestRetries runs a failing job until it is dead-lettered, then redrives it.
+func TestRetries(t *testing.T) {
+	conn := testDB(t)
+	q := New(conn, Options{MaxAttempts: 3, BaseBackoff: time.Hour, MaxBackoff: 4 * time.Hour})
+	org := uuid.NewString()
+	calls := 0
+	q.Register("flaky", func(ctx context.Context, payload json.RawMessage) error {
+		calls++
+		return errors.New("boom")
+	})
+	enqueued := enqueue(t, q, org, "flaky")
+
+	ctx := context.Background()
+	for attempt := 1; attempt <= 3; attempt++ {
+		job := claim(t, q)
+		if job == nil {
+			t.Fatalf("attempt %d: nothing to claim", attempt)
+		}
+		before := time.Now()
+		q.run(ctx, job)
+		if attempt == 3 {
+			break
+		}
+
+		var retry models.QueueJob
+		if err := conn.First(&retry, "id = ?", enqueued.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		wait := retry.RunAt.Sub(before)
+		want := time.Hour << (attempt - 1)
+		if retry.Status != StatusPending || retry.Attempts != attempt || retry.LastError != "boom" ||
+			wait < want*4/5-time.Second || wait > want*6/5+time.Second {
+			t.Fatalf("after attempt %d: %+v, retry in %s; want pending, %d attempts, retry in %s ±20%%",
+				attempt, retry, wait, attempt, want)
+		}
+		// Make the retry due now.
+		conn.Model(&models.QueueJob{}).Where("id = ?", retry.ID).Update("run_at", time.Now())
+	}
+	if calls != 3 {
+		t.Errorf("handler ran %d times; want 3", calls)
+	}
+
+	var live int64
+	conn.Model(&models.QueueJob{}).Count(&live)
+	dead, total, err := q.DeadLetters(org, "", 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if live != 0 || total != 1 || dead[0].ID != enqueued.ID || dead[0].Attempts != 3 || dead[0].LastError != "boom" {
+		t.Fatalf("%d live jobs, dead letters %+v; want the job dead-lettered after 3 attempts", live, dead)
+	}
+
+	if _, err := q.Redrive(uuid.NewString(), enqueued.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Redrive from another organization = %v; want ErrNotFound", err)
+	}
+	redriven, err := q.Redrive(org, enqueued.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if redriven.ID != enqueued.ID || redriven.Status != StatusPending || redriven.Attempts != 0 || redriven.MaxAttempts != 3 {
+		t.Errorf("redriven job = %+v; want pending under the same id with no attempts", redriven)
+	}
+	if _, total, _ := q.DeadLetters(org, "", 10, 0); total != 0 {
+		t.Errorf("%d dead letters after redrive; want 0", total)
+	}
+	if job := claim(t, q); job == nil || job.ID != enqueued.ID || job.Attempts != 1 {
+		t.Errorf("claimed %+v after redrive; want the job on attempt 1", job)
+	}
+}
+
+func TestPermanentAndSuccess(t *testing.T) {
+	conn := testDB(t)
+	q := New(conn, Options{MaxAttempts: 5})
+	org := uuid.NewString()
+	q.Register("ok", func(ctx context.Context, payload json.RawMessage) error { return nil })
+	q.Register("gone", func(ctx context.Context, payload json.RawMessage) error {
+		return Permanent(errors.New("row deleted"))
+	})
+	ok := enqueue(t, q, org, "ok")
+	gone := enqueue(t, q, org, "gone")
+	orphan := enqueue(t, q, org, "orphan") // no handler
+
+	for job := claim(t, q); job != nil; job = claim(t, q) {
+		q.run(context.Background(), job)
+	}
+
+	var live int64
+	conn.Model(&models.QueueJob{}).Count(&live)
+	if live != 0 {
+		t.Errorf("%d jobs left; want 0", live)
+	}
+	var dead []models.DeadLetterJob
+	conn.Order("kind").Find(&dead)
+	if len(dead) != 2 || dead[0].ID != gone.ID || dead[1].ID != orphan.ID || dead[0].Attempts != 1 || dead[1].Attempts != 1 {
+		t.Errorf("dead letters = %+v; want gone and orphan after one attempt each", dead)
+	}
+	var found int64
+	conn.Model(&models.DeadLetterJob{}).Where("id = ?", ok.ID).Count(&found)
+	if found != 0 {
+		t.Error("successful job was dead-lettered")
+	}
+}
+
+// TestEnqueueWakesAfterCommit checks that only jobs that are visible to a
+// worker wake one.
+func TestEnqueueWakesAfterCommit(t *testing.T) {
+	conn := testDB(t)
+	q := New(conn, Options{})
+	org := uuid.NewString()
+
+	err := conn.Transaction(func(tx *gorm.DB) error {
+		return q.Enqueue(tx, org, "in-tx", nil)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(q.wake) != 0 {
+		t.Error("Enqueue in a transaction woke a worker before the commit")
+	}
+
+	if err := q.Enqueue(nil, org, "direct", nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(q.wake) != 1 {
+		t.Error("Enqueue outside a transaction didn't wake a worker")
+	}
+}
@@ -1,32 +1,52 @@
 package routes
 
 import (
+	"log"
+
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/resumelens/authservice/internal/config"
 	"github.com/resumelens/authservice/internal/handler"
 	"github.com/resumelens/authservice/internal/middleware"
+	"github.com/resumelens/authservice/internal/services"
 
 	"time"
 )
 
 func SetupRouter(
+	cfg *config.Config,
 	jobApplicationHandler *handler.JobApplicationHandler,
 	authHandler *handler.AuthHandler,
 	jobHostingHandler *handler.JobHostingHandler,
+	jobBoardHandler *handler.JobBoardHandler,
+	pipelineHandler *handler.PipelineHandler,
+	candidatePortalHandler *handler.CandidatePortalHandler,
+	searchHandler *handler.SearchHandler,
+	queueAdminHandler *handler.QueueAdminHandler,
+	candidateHandler *handler.CandidateHandler,
+	permissionService *services.PermissionService,
 ) *gin.Engine {
+	registerValidations()
 	router := gin.Default()
+	// c.ClientIP() feeds rate limits, CAPTCHA checks and the document access
+	// log, so X-Forwarded-For is only honoured from configured proxies.
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
 
 	router.MaxMultipartMemory = 30 << 20
 
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:3000"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
+	limitUpload := middleware.LimitUploadSize(cfg.UploadMaxRequestMB << 20)
+
 	api := router.Group("/api/v1")
 	{
 		api.GET("/health", func(c *gin.Context) {
@@ -42,15 +62,81 @@ func SetupRouter(
 		api.GET("/validate-invite", authHandler.ValidateInvite)
 		api.POST("/accept-invite", authHandler.AcceptInvite)
 		api.POST("/refresh-token", authHandler.RefreshToken)
+		api.POST("/logout", authHandler.Logout)
+
+		// Candidate-facing job board; no account required.
+		public := api.Group("/public")
+		public.Use(middleware.RateLimitByIP(cfg.PublicRateLimit, cfg.PublicRateLimit))
+		{
+			public.GET("/jobs/:jobID", jobBoardHandler.GetJob)
+			public.GET("/orgs/:orgID/jobs", jobBoardHandler.ListOrganizationJobs)
+			public.GET("/orgs/:orgID/jobs/:jobID", jobBoardHandler.GetJob)
+			public.POST("/jobs/:jobID/apply", middleware.RateLimitByIP(cfg.ApplyRateLimit, cfg.ApplyRateLimit), limitUpload, jobBoardHandler.Apply)
+
+			// Magic-link candidate portal; the token is the credential.
+			public.GET("/applications/:token", candidatePortalHandler.GetApplication)
+			public.PATCH("/applications/:token/contact", candidatePortalHandler.UpdateContact)
+			public.POST("/applications/:token/resume", limitUpload, candidatePortalHandler.ReplaceResume)
+			public.POST("/applications/:token/cover-letter", limitUpload, candidatePortalHandler.ReplaceCoverLetter)
+			public.POST("/applications/:token/withdraw", candidatePortalHandler.Withdraw)
+		}
 
 		secured := api.Group("/")
 		secured.Use(middleware.JWTAuthMiddleware())
 		{
-			secured.POST("/invite", authHandler.Invite)
-			secured.POST("/upload-resume", jobApplicationHandler.UploadResume)
-			secured.POST("/upload-cover-letter", jobApplicationHandler.UploadCoverLetter)
-			secured.POST("/job", jobHostingHandler.CreateJob)
-			secured.GET("/job/:id", jobHostingHandler.GetJob)
+			requireIAM := middleware.RequirePermission(permissionService, services.PermissionIAM)
+			requireCreateJob := middleware.RequirePermission(permissionService, services.PermissionCreateJob)
+			requireViewJob := middleware.RequirePermission(permissionService, services.PermissionViewJob)
+
+			secured.POST("/invite", requireIAM, authHandler.Invite)
+			secured.POST("/upload-resume", requireCreateJob, limitUpload, jobApplicationHandler.UploadResume)
+			secured.POST("/upload-cover-letter", requireCreateJob, limitUpload, jobApplicationHandler.UploadCoverLetter)
+			secured.POST("/job", requireCreateJob, jobHostingHandler.CreateJob)
+			secured.GET("/job/:id", requireViewJob, jobHostingHandler.GetJob)
+			secured.PUT("/job/:id", requireCreateJob, jobHostingHandler.UpdateJob)
+			secured.PATCH("/job/:id", requireCreateJob, jobHostingHandler.UpdateJob)
+			secured.DELETE("/job/:id", requireCreateJob, jobHostingHandler.DeleteJob)
+			secured.POST("/job/:id/status", requireCreateJob, jobHostingHandler.ChangeJobStatus)
+			secured.GET("/job/:id/audit", requireCreateJob, jobHostingHandler.GetJobAudit)
+			secured.GET("/job/:id/matches", requireViewJob, searchHandler.RankApplications)
+			secured.POST("/job/:id/import", requireCreateJob, middleware.LimitUploadSize(cfg.ImportMaxArchiveMB<<20), jobApplicationHandler.ImportResumes)
+			secured.GET("/jobs", requireViewJob, jobHostingHandler.ListJobs)
+			secured.GET("/candidates/search", requireViewJob, searchHandler.SearchCandidates)
+			secured.GET("/candidates/duplicates", requireViewJob, candidateHandler.ListDuplicates)
+			secured.POST("/candidates/duplicates/scan", requireIAM, candidateHandler.RescanDuplicates)
+			secured.POST("/candidates/duplicates/:id/dismiss", requireViewJob, candidateHandler.DismissDuplicate)
+			secured.POST("/candidates", requireViewJob, candidateHandler.CreateCandidate)
+			secured.GET("/candidates", requireViewJob, candidateHandler.ListCandidates)
+			secured.GET("/candidates/tags", requireViewJob, candidateHandler.ListTags)
+			secured.GET("/candidates/:id", requireViewJob, candidateHandler.GetCandidate)
+			secured.PATCH("/candidates/:id", requireViewJob, candidateHandler.UpdateCandidate)
+			secured.GET("/candidates/:id/notes", requireViewJob, candidateHandler.ListNotes)
+			secured.POST("/candidates/:id/notes", requireViewJob, candidateHandler.CreateNote)
+			secured.PATCH("/candidates/:id/notes/:noteID", requireViewJob, candidateHandler.UpdateNote)
+			secured.DELETE("/candidates/:id/notes/:noteID", requireViewJob, candidateHandler.DeleteNote)
+			secured.POST("/candidates/:id/merge", requireCreateJob, candidateHandler.MergeCandidates)
+
+			secured.GET("/pipeline", requireViewJob, pipelineHandler.GetPipeline)
+			secured.PUT("/pipeline", requireCreateJob, pipelineHandler.ConfigurePipeline)
+			secured.GET("/job/:id/pipeline", requireViewJob, pipelineHandler.GetPipeline)
+			secured.PUT("/job/:id/pipeline", requireCreateJob, pipelineHandler.ConfigurePipeline)
+			secured.POST("/applications/:id/stage", requireCreateJob, pipelineHandler.MoveApplication)
+			secured.GET("/applications/:id/history", requireViewJob, pipelineHandler.GetStageHistory)
+			secured.GET("/applications/:id/score", requireViewJob, jobApplicationHandler.GetApplicationScore)
+			secured.GET("/applications/:id/documents", requireViewJob, jobApplicationHandler.ListDocumentVersions)
+			secured.GET("/applications/:id/documents/:versionID", requireViewJob, jobApplicationHandler.GetDocumentVersion)
+			secured.GET("/applications/:id/resume", requireViewJob, jobApplicationHandler.GetResume)
+			secured.GET("/applications/:id/cover-letter", requireViewJob, jobApplicationHandler.GetCoverLetter)
+			secured.GET("/applications/:id/document-access", requireIAM, jobApplicationHandler.ListDocumentAccess)
+			secured.GET("/applications/:id/ratings", requireViewJob, candidateHandler.ListRatings)
+			secured.PUT("/applications/:id/rating", requireViewJob, candidateHandler.RateApplication)
+			secured.DELETE("/applications/:id/rating", requireViewJob, candidateHandler.DeleteRating)
+			secured.POST("/applications/bulk-stage", requireCreateJob, pipelineHandler.BulkMoveApplications)
+
+			secured.GET("/admin/queue", requireIAM, queueAdminHandler.GetStats)
+			secured.GET("/admin/queue/dead-letters", requireIAM, queueAdminHandler.ListDeadLetters)
+			secured.POST("/admin/queue/dead-letters/:id/redrive", requireIAM, queueAdminHandler.Redrive)
+			secured.DELETE("/admin/queue/dead-letters/:id", requireIAM, queueAdminHandler.Discard)
 		}
 	}
 
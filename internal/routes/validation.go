@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"log"
+	"unicode"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// registerValidations adds the custom binding tags:
+//
+//	singleline: no control characters, including line breaks. Candidate
+//	names end up in email subjects.
+func registerValidations() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	err := v.RegisterValidation("singleline", func(fl validator.FieldLevel) bool {
+		for _, r := range fl.Field().String() {
+			if unicode.IsControl(r) {
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		log.Fatalf("Failed to register validations: %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// clamdChunkSize is the size of the INSTREAM chunks sent to clamd. It has to
+// stay below clamd's StreamMaxLength.
+const clamdChunkSize = 64 << 10
+
+// ErrClamd is wrapped around errors reported by the daemon itself, such as
+// a stream that exceeds StreamMaxLength.
+var ErrClamd = errors.New("clamd error")
+
+// ClamdScanner talks the clamd protocol over TCP or a unix socket. Each scan
+// opens its own connection, so one scanner is safe for concurrent use.
+type ClamdScanner struct {
+	network string
+	address string
+	timeout time.Duration
+}
+
+// NewClamdScanner parses address as tcp://host:port, unix:///path/to/socket
+// or a bare host:port.
+func NewClamdScanner(address string, timeout time.Duration) (*ClamdScanner, error) {
+	network, addr := "tcp", address
+	switch {
+	case strings.HasPrefix(address, "unix://"):
+		network, addr = "unix", strings.TrimPrefix(address, "unix://")
+	case strings.HasPrefix(address, "tcp://"):
+		addr = strings.TrimPrefix(address, "tcp://")
+	}
+	if addr == "" {
+		return nil, fmt.Errorf("invalid clamd address %q", address)
+	}
+	return &ClamdScanner{network: network, address: addr, timeout: timeout}, nil
+}
+
+func (s *ClamdScanner) Name() string { return BackendClamd }
+
+// Ping checks that the daemon is reachable.
+func (s *ClamdScanner) Ping(ctx context.Context) error {
+	reply, err := s.command(ctx, func(conn net.Conn) error {
+		_, err := conn.Write([]byte("zPING\x00"))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if reply != "PONG" {
+		return fmt.Errorf("%w: unexpected reply to PING: %q", ErrClamd, reply)
+	}
+	return nil
+}
+
+// Scan streams r to clamd with the INSTREAM command.
+func (s *ClamdScanner) Scan(ctx context.Context, r io.Reader) (Result, error) {
+	reply, err := s.command(ctx, func(conn net.Conn) error {
+		if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+			return err
+		}
+		buf := make([]byte, 4+clamdChunkSize)
+		for {
+			n, readErr := r.Read(buf[4:])
+			if n > 0 {
+				binary.BigEndian.PutUint32(buf[:4], uint32(n))
+				if _, err := conn.Write(buf[:4+n]); err != nil {
+					return err
+				}
+			}
+			if readErr == io.EOF {
+				break
+			}
+			if readErr != nil {
+				return readErr
+			}
+		}
+		_, err := conn.Write([]byte{0, 0, 0, 0})
+		return err
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	return parseClamdReply(reply)
+}
+
+// parseClamdReply interprets "stream: OK", "stream: <name> FOUND" and
+// "<message> ERROR".
+func parseClamdReply(reply string) (Result, error) {
+	_, verdict, ok := strings.Cut(reply, ": ")
+	if !ok {
+		verdict = reply
+	}
+	switch {
+	case verdict == "OK":
+		return Result{}, nil
+	case strings.HasSuffix(verdict, " FOUND"):
+		return Result{Infected: true, Signature: strings.TrimSuffix(verdict, " FOUND")}, nil
+	case strings.HasSuffix(verdict, " ERROR"):
+		return Result{}, fmt.Errorf("%w: %s", ErrClamd, strings.TrimSuffix(verdict, " ERROR"))
+	default:
+		return Result{}, fmt.Errorf("%w: unexpected reply %q", ErrClamd, reply)
+	}
+}
+
+// command dials the daemon, runs send and reads the NUL-terminated reply.
+func (s *ClamdScanner) command(ctx context.Context, send func(net.Conn) error) (string, error) {
+	dialer := net.Dialer{Timeout: s.timeout}
+	conn, err := dialer.DialContext(ctx, s.network, s.address)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to clamd: %w", err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(s.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	if err := send(conn); err != nil {
+		// clamd closes the connection early when the stream is too long;
+		// its reply explains why, so prefer it over the write error.
+		if reply, readErr := readClamdReply(conn); readErr == nil && reply != "" {
+			return reply, nil
+		}
+		return "", fmt.Errorf("failed to send to clamd: %w", err)
+	}
+	reply, err := readClamdReply(conn)
+	if err != nil {
+		return "", fmt.Errorf("failed to read clamd reply: %w", err)
+	}
+	return reply, nil
+}
+
+func readClamdReply(conn net.Conn) (string, error) {
+	reply, err := bufio.NewReader(conn).ReadBytes(0)
+	if err != nil && !(errors.Is(err, io.EOF) && len(reply) > 0) {
+		return "", err
+	}
+	return string(bytes.TrimRight(reply, "\x00\n")), nil
+}
@@ -0,0 +1,247 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const eicar = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// fakeClamd answers zPING and zINSTREAM like clamd does. Streams longer
+// than maxLength get the daemon's size limit error and the connection is
+// closed without reading the rest.
+type fakeClamd struct {
+	maxLength int
+	// verdict returns the reply to a complete stream.
+	verdict func(data []byte) string
+	// commands receives every command the daemon was sent.
+	commands chan string
+}
+
+func (f *fakeClamd) listen(t *testing.T, network, address string) net.Listener {
+	t.Helper()
+	l, err := net.Listen(network, address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return l
+}
+
+func (f *fakeClamd) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	command, err := r.ReadString(0)
+	if err != nil {
+		return
+	}
+	command = strings.TrimSuffix(command, "\x00")
+	if f.commands != nil {
+		f.commands <- command
+	}
+
+	switch command {
+	case "zPING":
+		conn.Write([]byte("PONG\x00"))
+	case "zINSTREAM":
+		var data []byte
+		for {
+			var size uint32
+			if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+				return
+			}
+			if size == 0 {
+				break
+			}
+			if len(data)+int(size) > f.maxLength {
+				conn.Write([]byte("INSTREAM size limit exceeded. ERROR\x00"))
+				return
+			}
+			chunk := make([]byte, size)
+			if _, err := io.ReadFull(r, chunk); err != nil {
+				return
+			}
+			data = append(data, chunk...)
+		}
+		conn.Write([]byte(f.verdict(data) + "\x00"))
+	default:
+		conn.Write([]byte("UNKNOWN COMMAND\x00"))
+	}
+}
+
+func newFakeClamd() *fakeClamd {
+	return &fakeClamd{
+		maxLength: 1 << 20,
+		verdict: func(data []byte) string {
+			switch {
+			case bytes.Contains(data, []byte(eicar)):
+				return "stream: Eicar-Test-Signature FOUND"
+			case bytes.Contains(data, []byte("corrupt")):
+				return "stream: Can't allocate memory ERROR"
+			}
+			return "stream: OK"
+		},
+	}
+}
+
+func TestClamdScan(t *testing.T) {
+	daemon := newFakeClamd()
+	daemon.commands = make(chan string, 16)
+	l := daemon.listen(t, "tcp", "127.0.0.1:0")
+	s, err := NewClamdScanner("tcp://"+l.Addr().String(), 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if err := s.Ping(ctx); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	if got := <-daemon.commands; got != "zPING" {
+		t.Errorf("command = %q; want zPING", got)
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		want    Result
+		wantErr bool
+	}{
+		{name: "clean", data: []byte("Jane Doe, Go engineer"), want: Result{}},
+		{name: "empty", data: nil, want: Result{}},
+		// Several chunks, with the signature straddling a chunk boundary.
+		{name: "infected", data: append(bytes.Repeat([]byte("a"), clamdChunkSize-10), eicar...), want: Result{Infected: true, Signature: "Eicar-Test-Signature"}},
+		{name: "daemon error", data: []byte("corrupt"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Scan(ctx, bytes.NewReader(tt.data))
+			if tt.wantErr {
+				if !errors.Is(err, ErrClamd) {
+					t.Fatalf("err = %v; want ErrClamd", err)
+				}
+				if !strings.Contains(err.Error(), "Can't allocate memory") {
+					t.Errorf("err = %v; want the daemon's message", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Scan: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Scan = %+v; want %+v", got, tt.want)
+			}
+			if cmd := <-daemon.commands; cmd != "zINSTREAM" {
+				t.Errorf("command = %q; want zINSTREAM", cmd)
+			}
+		})
+	}
+}
+
+// TestClamdStreamTooLong covers clamd closing the connection part way
+// through a stream: the write fails, and the daemon's reply is reported
+// instead of the write error.
+func TestClamdStreamTooLong(t *testing.T) {
+	daemon := newFakeClamd()
+	daemon.maxLength = 2 * clamdChunkSize
+	socket := filepath.Join(t.TempDir(), "clamd.sock")
+	daemon.listen(t, "unix", socket)
+	s, err := NewClamdScanner("unix://"+socket, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.Scan(context.Background(), bytes.NewReader(make([]byte, 16<<20)))
+	if !errors.Is(err, ErrClamd) || !strings.Contains(err.Error(), "size limit exceeded") {
+		t.Fatalf("err = %v; want the size limit error", err)
+	}
+}
+
+func TestClamdUnreachable(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := l.Addr().String()
+	l.Close()
+
+	s, err := NewClamdScanner(address, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Scan(context.Background(), strings.NewReader("x")); err == nil || errors.Is(err, ErrClamd) {
+		t.Errorf("err = %v; want a connection error", err)
+	}
+}
+
+func TestClamdTimeout(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		// Accept and never answer.
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { conn.Close() })
+		}
+	}()
+
+	s, err := NewClamdScanner(l.Addr().String(), 100*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if err := s.Ping(context.Background()); err == nil {
+		t.Fatal("Ping succeeded; want a timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Ping took %v; want it to give up after the timeout", elapsed)
+	}
+}
+
+func TestNewClamdScanner(t *testing.T) {
+	tests := []struct {
+		address, network, addr string
+	}{
+		{"tcp://clamav:3310", "tcp", "clamav:3310"},
+		{"clamav:3310", "tcp", "clamav:3310"},
+		{"unix:///run/clamav/clamd.sock", "unix", "/run/clamav/clamd.sock"},
+	}
+	for _, tt := range tests {
+		s, err := NewClamdScanner(tt.address, time.Second)
+		if err != nil {
+			t.Fatalf("NewClamdScanner(%q): %v", tt.address, err)
+		}
+		if s.network != tt.network || s.address != tt.addr {
+			t.Errorf("NewClamdScanner(%q) = %s %s; want %s %s", tt.address, s.network, s.address, tt.network, tt.addr)
+		}
+	}
+	for _, address := range []string{"tcp://", "unix://"} {
+		if _, err := NewClamdScanner(address, time.Second); err == nil {
+			t.Errorf("NewClamdScanner(%q) succeeded; want an error", address)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+// Package scanner checks uploaded files for malware before they are made
+// available to anyone. Scanner is an interface so another engine can
+// replace the ClamAV client.
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/resumelens/authservice/internal/config"
+)
+
+// Supported values for SCANNER_BACKEND.
+const (
+	BackendNone  = "none"
+	BackendClamd = "clamd"
+)
+
+// Result is the verdict for one file. Signature names the threat when
+// Infected is set.
+type Result struct {
+	Infected  bool
+	Signature string
+}
+
+// Scanner inspects a stream. An error means the file could not be scanned,
+// not that it is infected.
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) (Result, error)
+	Name() string
+}
+
+// New builds the scanner selected by cfg.ScannerBackend.
+func New(cfg *config.Config) (Scanner, error) {
+	switch cfg.ScannerBackend {
+	case BackendNone:
+		return Disabled{}, nil
+	case BackendClamd:
+		return NewClamdScanner(cfg.ClamdAddress, time.Duration(cfg.ScanTimeoutSeconds)*time.Second)
+	default:
+		return nil, fmt.Errorf("unknown scanner backend %q", cfg.ScannerBackend)
+	}
+}
+
+// Disabled reports every file as clean. It is meant for development
+// machines without a virus scanner.
+type Disabled struct{}
+
+func (Disabled) Scan(ctx context.Context, r io.Reader) (Result, error) {
+	return Result{}, nil
+}
+
+func (Disabled) Name() string { return BackendNone }
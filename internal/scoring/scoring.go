@@ -0,0 +1,260 @@
+// Package scoring rates how well a parsed resume fits a job. The score is a
+// weighted sum of simple, explainable criteria so a recruiter can always see
+// why a candidate scored the way they did.
+package scoring
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/resumelens/authservice/internal/models"
+	"github.com/resumelens/authservice/internal/parser"
+)
+
+// Criterion names used in Result.Breakdown.
+const (
+	CriterionSkills         = "skills"
+	CriterionExperience     = "experience"
+	CriterionLocation       = "location"
+	CriterionEmploymentType = "employment_type"
+)
+
+// Version is stored with every result so scores computed by an older set of
+// rules can be told apart and recomputed.
+const Version = 1
+
+var weights = map[string]float64{
+	CriterionSkills:         50,
+	CriterionExperience:     25,
+	CriterionLocation:       15,
+	CriterionEmploymentType: 10,
+}
+
+// Criterion is one line of the breakdown. Score is 0–1; criteria the job
+// doesn't specify are left out rather than scored.
+type Criterion struct {
+	Name    string   `json:"name"`
+	Weight  float64  `json:"weight"`
+	Score   float64  `json:"score"`
+	Matched []string `json:"matched,omitempty"`
+	Missing []string `json:"missing,omitempty"`
+	Detail  string   `json:"detail"`
+}
+
+type Result struct {
+	Score     float64     `json:"score"` // 0–100
+	Version   int         `json:"version"`
+	Breakdown []Criterion `json:"breakdown"`
+}
+
+// Score compares a parsed resume with the job's requirements.
+func Score(profile *parser.Resume, job *models.Job) Result {
+	var breakdown []Criterion
+	if c, ok := scoreSkills(profile, job.SkillsRequired); ok {
+		breakdown = append(breakdown, c)
+	}
+	if c, ok := scoreExperience(profile, job.ExperienceLevel); ok {
+		breakdown = append(breakdown, c)
+	}
+	if c, ok := scoreLocation(profile, job.Location); ok {
+		breakdown = append(breakdown, c)
+	}
+	if c, ok := scoreEmploymentType(profile, job.EmploymentType); ok {
+		breakdown = append(breakdown, c)
+	}
+
+	// Weights are relative: a job without a location requirement is scored
+	// on the remaining criteria alone.
+	var total, weighted float64
+	for i := range breakdown {
+		breakdown[i].Weight = weights[breakdown[i].Name]
+		total += breakdown[i].Weight
+		weighted += breakdown[i].Weight * breakdown[i].Score
+		breakdown[i].Score = round(breakdown[i].Score, 2)
+	}
+
+	result := Result{Version: Version, Breakdown: breakdown}
+	if total > 0 {
+		result.Score = round(weighted/total*100, 1)
+	}
+	if result.Breakdown == nil {
+		result.Breakdown = []Criterion{}
+	}
+	return result
+}
+
+func scoreSkills(profile *parser.Resume, required []string) (Criterion, bool) {
+	if len(required) == 0 {
+		return Criterion{}, false
+	}
+	have := make(map[string]bool, len(profile.Skills))
+	for _, s := range profile.Skills {
+		have[strings.ToLower(parser.NormalizeSkill(s))] = true
+	}
+
+	c := Criterion{Name: CriterionSkills}
+	for _, s := range required {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		if have[strings.ToLower(parser.NormalizeSkill(s))] {
+			c.Matched = append(c.Matched, s)
+		} else {
+			c.Missing = append(c.Missing, s)
+		}
+	}
+	n := len(c.Matched) + len(c.Missing)
+	if n == 0 {
+		return Criterion{}, false
+	}
+	c.Score = float64(len(c.Matched)) / float64(n)
+	c.Detail = fmt.Sprintf("%d of %d required skills found", len(c.Matched), n)
+	return c, true
+}
+
+// experienceYears maps a job's experience level to the years of experience
+// it expects. Unknown levels are not scored.
+var experienceYears = map[string]float64{
+	"intern":       0,
+	"internship":   0,
+	"entry":        0,
+	"junior":       1,
+	"mid":          3,
+	"intermediate": 3,
+	"senior":       5,
+	"lead":         7,
+	"staff":        8,
+	"principal":    10,
+	"executive":    10,
+}
+
+func scoreExperience(profile *parser.Resume, level string) (Criterion, bool) {
+	key := strings.ToLower(strings.TrimSpace(level))
+	key = strings.TrimSuffix(strings.TrimSuffix(key, " level"), "-level")
+	want, ok := experienceYears[key]
+	if !ok {
+		return Criterion{}, false
+	}
+
+	years := float64(profile.TotalMonths) / 12
+	c := Criterion{Name: CriterionExperience}
+	switch {
+	case want == 0 || years >= want:
+		c.Score = 1
+	default:
+		c.Score = years / want
+	}
+	c.Detail = fmt.Sprintf("%.1f years of experience, %s expects %.0f", years, level, want)
+	return c, true
+}
+
+func scoreLocation(profile *parser.Resume, locations []string) (Criterion, bool) {
+	if len(locations) == 0 {
+		return Criterion{}, false
+	}
+	c := Criterion{Name: CriterionLocation}
+	for _, l := range locations {
+		if strings.EqualFold(strings.TrimSpace(l), "remote") {
+			c.Score = 1
+			c.Matched = []string{l}
+			c.Detail = "Job is remote"
+			return c, true
+		}
+	}
+
+	candidate := strings.ToLower(profile.Location.Value)
+	if candidate == "" {
+		// Missing data shouldn't count as a mismatch.
+		c.Score = 0.5
+		c.Detail = "Candidate location unknown"
+		return c, true
+	}
+	for _, l := range locations {
+		if locationMatches(candidate, strings.ToLower(l)) {
+			c.Score = 1
+			c.Matched = []string{l}
+			c.Detail = "Candidate is in " + profile.Location.Value
+			return c, true
+		}
+	}
+	c.Missing = locations
+	c.Detail = "Candidate is in " + profile.Location.Value
+	return c, true
+}
+
+// locationMatches compares city-level parts so "San Francisco, CA" matches a
+// job in "San Francisco".
+func locationMatches(candidate, job string) bool {
+	for _, part := range strings.Split(job, ",") {
+		part = strings.TrimSpace(part)
+		if len(part) > 2 && strings.Contains(candidate, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// employmentKeywords are the words in a resume's experience that show
+// history with each employment type. They match whole words only, so "temp"
+// doesn't match "template" nor "intern" match "internal".
+var employmentKeywords = map[string]*regexp.Regexp{
+	"full-time":  keywords("full-time", "full time"),
+	"part-time":  keywords("part-time", "part time"),
+	"contract":   keywords("contract", "contractor", "freelance", "consultant"),
+	"internship": keywords("intern", "internship"),
+	"temporary":  keywords("temporary", "temp"),
+}
+
+func keywords(words ...string) *regexp.Regexp {
+	for i, w := range words {
+		words[i] = regexp.QuoteMeta(w)
+	}
+	return regexp.MustCompile(`\b(?:` + strings.Join(words, "|") + `)\b`)
+}
+
+func scoreEmploymentType(profile *parser.Resume, types []string) (Criterion, bool) {
+	if len(types) == 0 {
+		return Criterion{}, false
+	}
+	c := Criterion{Name: CriterionEmploymentType}
+	if len(profile.Experience) == 0 {
+		c.Score = 0.5
+		c.Detail = "No work history to compare"
+		return c, true
+	}
+
+	var history strings.Builder
+	for _, e := range profile.Experience {
+		history.WriteString(strings.ToLower(e.Title + " " + e.Company + " " + e.Description + "\n"))
+	}
+	text := history.String()
+
+	for _, t := range types {
+		key := strings.ToLower(strings.TrimSpace(t))
+		// Regular employment is what resumes list by default, so any work
+		// history counts towards a full-time position.
+		if key == "full-time" || key == "full time" {
+			c.Matched = append(c.Matched, t)
+			continue
+		}
+		if pattern, ok := employmentKeywords[key]; ok && pattern.MatchString(text) {
+			c.Matched = append(c.Matched, t)
+		}
+	}
+	if len(c.Matched) > 0 {
+		c.Score = 1
+		c.Detail = "Has " + strings.Join(c.Matched, ", ") + " experience"
+	} else {
+		c.Score = 0.5
+		c.Missing = types
+		c.Detail = "No " + strings.Join(types, " or ") + " experience mentioned"
+	}
+	return c, true
+}
+
+func round(v float64, places int) float64 {
+	p := math.Pow(10, float64(places))
+	return math.Round(v*p) / p
+}
@@ -0,0 +1,106 @@
+package scoring
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/resumelens/authservice/internal/models"
+	"github.com/resumelens/authservice/internal/parser"
+)
+
+func profile() *parser.Resume {
+	return &parser.Resume{
+		Skills:      []string{"golang", "Docker"},
+		TotalMonths: 36,
+		Location:    parser.Field{Value: "San Francisco, CA", Confidence: 0.6},
+		Experience: []parser.Experience{
+			{Title: "Backend Contractor", Company: "Acme Inc", Months: 36},
+		},
+	}
+}
+
+func TestScore(t *testing.T) {
+	job := &models.Job{
+		SkillsRequired:  []string{"Go", "Kubernetes", "docker"},
+		ExperienceLevel: "Senior",
+		Location:        []string{"San Francisco"},
+		EmploymentType:  []string{"contract"},
+	}
+	result := Score(profile(), job)
+
+	want := []Criterion{
+		{Name: CriterionSkills, Weight: 50, Score: 0.67, Matched: []string{"Go", "docker"}, Missing: []string{"Kubernetes"}, Detail: "2 of 3 required skills found"},
+		{Name: CriterionExperience, Weight: 25, Score: 0.6, Detail: "3.0 years of experience, Senior expects 5"},
+		{Name: CriterionLocation, Weight: 15, Score: 1, Matched: []string{"San Francisco"}, Detail: "Candidate is in San Francisco, CA"},
+		{Name: CriterionEmploymentType, Weight: 10, Score: 1, Matched: []string{"contract"}, Detail: "Has contract experience"},
+	}
+	if !reflect.DeepEqual(result.Breakdown, want) {
+		t.Errorf("Breakdown = %+v\nwant %+v", result.Breakdown, want)
+	}
+	// (50·2/3 + 25·0.6 + 15 + 10) / 100
+	if result.Score != 73.3 || result.Version != Version {
+		t.Errorf("Score = %v (version %d); want 73.3 (version %d)", result.Score, result.Version, Version)
+	}
+}
+
+// TestScoreRenormalizes checks that criteria the job leaves out don't count
+// against the candidate.
+func TestScoreRenormalizes(t *testing.T) {
+	tests := []struct {
+		name        string
+		job         models.Job
+		criteria    []string
+		score       float64
+		totalWeight float64
+	}{
+		{"skills and location", models.Job{SkillsRequired: []string{"Go", "Kubernetes", "Docker"}, Location: []string{"Berlin"}},
+			[]string{CriterionSkills, CriterionLocation}, 51.3, 65}, // 50·2/3 / 65
+		{"remote only", models.Job{Location: []string{"Remote"}}, []string{CriterionLocation}, 100, 15},
+		{"unknown experience level", models.Job{ExperienceLevel: "wizard", EmploymentType: []string{"part-time"}},
+			[]string{CriterionEmploymentType}, 50, 10},
+		{"no requirements", models.Job{SkillsRequired: []string{" "}}, []string{}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Score(profile(), &tt.job)
+			criteria := []string{}
+			var total float64
+			for _, c := range result.Breakdown {
+				criteria = append(criteria, c.Name)
+				total += c.Weight
+			}
+			if !reflect.DeepEqual(criteria, tt.criteria) || total != tt.totalWeight {
+				t.Errorf("criteria = %v (weight %v); want %v (weight %v)", criteria, total, tt.criteria, tt.totalWeight)
+			}
+			if result.Score != tt.score {
+				t.Errorf("Score = %v; want %v", result.Score, tt.score)
+			}
+		})
+	}
+}
+
+func TestScoreEmploymentTypeWholeWords(t *testing.T) {
+	tests := []struct {
+		history string
+		kind    string
+		matched bool
+	}{
+		{"Built a template engine for temperature sensors", "temporary", false},
+		{"Temp role covering parental leave", "temporary", true},
+		{"Maintained internal tools", "internship", false},
+		{"Summer intern", "internship", true},
+		{"Negotiated contracts with suppliers", "contract", false},
+		{"Freelance designer", "contract", true},
+		{"Part-time barista", "part-time", true},
+	}
+	for _, tt := range tests {
+		p := &parser.Resume{Experience: []parser.Experience{{Description: tt.history}}}
+		c, ok := scoreEmploymentType(p, []string{tt.kind})
+		if !ok {
+			t.Fatalf("scoreEmploymentType(%q) not scored", tt.kind)
+		}
+		if matched := len(c.Matched) > 0; matched != tt.matched {
+			t.Errorf("%q for %s: matched = %v; want %v", tt.history, tt.kind, matched, tt.matched)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/resumelens/authservice/internal/db"
+	"github.com/resumelens/authservice/internal/models"
+	"gorm.io/gorm/clause"
+)
+
+type RateApplicationRequest struct {
+	Score   int    `json:"score" binding:"required,min=1,max=5"`
+	Comment string `json:"comment" binding:"max=5000"`
+}
+
+type RatingView struct {
+	UserID    string    `json:"user_id"`
+	Email     string    `json:"email"`
+	Score     int       `json:"score"`
+	Comment   string    `json:"comment"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ratingSummary struct {
+	ApplicationID string
+	Average       float64
+	Count         int
+}
+
+// ratingSummaries returns the average rating and number of ratings of each
+// of the given applications that has any.
+func ratingSummaries(applicationIDs []string) (map[string]ratingSummary, error) {
+	result := make(map[string]ratingSummary)
+	if len(applicationIDs) == 0 {
+		return result, nil
+	}
+	var rows []ratingSummary
+	if err := db.DB.Model(&models.ApplicationRating{}).
+		Select("application_id, AVG(score) AS average, COUNT(*) AS count").
+		Where("application_id IN ?", applicationIDs).
+		Group("application_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		result[r.ApplicationID] = r
+	}
+	return result, nil
+}
+
+// RateApplication records the caller's rating of an application, replacing
+// their earlier one.
+func (s *CandidateService) RateApplication(caller Caller, applicationID string, req RateApplicationRequest) (gin.H, int) {
+	application, err := loadApplication(caller.OrganizationID, applicationID)
+	if errors.Is(err, ErrNotFound) {
+		return gin.H{"error": "Application not found"}, http.StatusNotFound
+	}
+	if err != nil {
+		return gin.H{"error": "Failed to load application"}, http.StatusInternalServerError
+	}
+
+	now := time.Now()
+	rating := models.ApplicationRating{
+		OrganizationID: caller.OrganizationID,
+		ApplicationID:  application.ID,
+		UserID:         caller.UserID,
+		Score:          req.Score,
+		Comment:        strings.TrimSpace(req.Comment),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := db.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "application_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"score", "comment", "updated_at"}),
+	}).Create(&rating).Error; err != nil {
+		return gin.H{"error": "Failed to save rating"}, http.StatusInternalServerError
+	}
+	return gin.H{"message": "Rating saved"}, http.StatusOK
+}
+
+// ListRatings returns every interviewer's rating of an application and
+// their average.
+func (s *CandidateService) ListRatings(caller Caller, applicationID string) (gin.H, int) {
+	application, err := loadApplication(caller.OrganizationID, applicationID)
+	if errors.Is(err, ErrNotFound) {
+		return gin.H{"error": "Application not found"}, http.StatusNotFound
+	}
+	if err != nil {
+		return gin.H{"error": "Failed to load application"}, http.StatusInternalServerError
+	}
+
+	ratings := []RatingView{}
+	if err := db.DB.Model(&models.ApplicationRating{}).
+		Select("application_ratings.user_id, users.email, application_ratings.score, application_ratings.comment, application_ratings.created_at, application_ratings.updated_at").
+		Joins("LEFT JOIN users ON users.id = application_ratings.user_id").
+		Where("application_ratings.application_id = ?", application.ID).
+		Order("application_ratings.updated_at DESC").
+		Scan(&ratings).Error; err != nil {
+		return gin.H{"error": "Failed to load ratings"}, http.StatusInternalServerError
+	}
+
+	var average *float64
+	if len(ratings) > 0 {
+		total := 0
+		for _, r := range ratings {
+			total += r.Score
+		}
+		v := round2(float64(total) / float64(len(ratings)))
+		average = &v
+	}
+	return gin.H{"ratings": ratings, "average": average, "count": len(ratings)}, http.StatusOK
+}
+
+// DeleteRating removes the caller's own rating of an application.
+func (s *CandidateService) DeleteRating(caller Caller, applicationID string) (gin.H, int) {
+	application, err := loadApplication(caller.OrganizationID, applicationID)
+	if errors.Is(err, ErrNotFound) {
+		return gin.H{"error": "Application not found"}, http.StatusNotFound
+	}
+	if err != nil {
+		return gin.H{"error": "Failed to load application"}, http.StatusInternalServerError
+	}
+
+	result := db.DB.Where("application_id = ? AND user_id = ?", application.ID, caller.UserID).Delete(&models.ApplicationRating{})
+	if result.Error != nil {
+		return gin.H{"error": "Failed to delete rating"}, http.StatusInternalServerError
+	}
+	if result.RowsAffected == 0 {
+		return gin.H{"error": "Rating not found"}, http.StatusNotFound
+	}
+	return gin.H{"message": "Rating deleted"}, http.StatusOK
+}
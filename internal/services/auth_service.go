@@ -1,25 +1,34 @@
 package services
 
 import (
+	"errors"
+	"log"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/resumelens/authservice/internal/config"
 	"github.com/resumelens/authservice/internal/db"
 	"github.com/resumelens/authservice/internal/models"
+	"github.com/resumelens/authservice/internal/queue"
 	"github.com/resumelens/authservice/internal/utils"
+	"gorm.io/gorm"
 )
 
+var errRefreshTokenReused = errors.New("refresh token reuse detected")
+
 type AuthService struct {
 	config            *config.Config
 	permissionService *PermissionService
+	queue             *queue.Queue
 }
 
-func NewAuthService(cfg *config.Config) *AuthService {
+func NewAuthService(cfg *config.Config, permissionService *PermissionService, q *queue.Queue) *AuthService {
 	return &AuthService{
 		config:            cfg,
-		permissionService: NewPermissionService(),
+		permissionService: permissionService,
+		queue:             q,
 	}
 }
 
@@ -118,6 +127,11 @@ func (s *AuthService) Login(req LoginRequest) (gin.H, int) {
 		return gin.H{"error": "Failed to generate token"}, http.StatusInternalServerError
 	}
 
+	refreshToken, _, err := s.issueRefreshToken(db.DB, user.ID, "")
+	if err != nil {
+		return gin.H{"error": "Failed to generate refresh token"}, http.StatusInternalServerError
+	}
+
 	permissions, err := s.permissionService.GetUserPermissions(user.RoleID)
 	if err != nil {
 		return gin.H{"error": "Failed to get user permissions"}, http.StatusInternalServerError
@@ -129,11 +143,13 @@ func (s *AuthService) Login(req LoginRequest) (gin.H, int) {
 	}
 
 	return gin.H{
-		"access_token": token,
-		"user":         user,
-		"role":         user.RoleID,
-		"organization": org,
-		"permissions":  permissions,
+		"access_token":  token,
+		"refresh_token": refreshToken,
+		"expires_in":    s.config.JWTExpiry,
+		"user":          user,
+		"role":          user.RoleID,
+		"organization":  org,
+		"permissions":   permissions,
 	}, http.StatusOK
 }
 
@@ -142,9 +158,12 @@ type InviteRequest struct {
 	RoleID string `json:"role_id" binding:"required"`
 }
 
-func (s *AuthService) Invite(req InviteRequest, inviterRole, inviterOrgID string) (gin.H, int) {
-	if inviterRole != "admin" {
-		return gin.H{"error": "Only admins can invite members"}, http.StatusForbidden
+// Invite is gated by IamPermission in the router; here we only make sure the
+// invitee is given a role from the inviter's own organization.
+func (s *AuthService) Invite(caller Caller, req InviteRequest) (gin.H, int) {
+	role, err := s.permissionService.GetRole(req.RoleID)
+	if err != nil || role.OrganizationID != caller.OrganizationID {
+		return gin.H{"error": "Role not found"}, http.StatusBadRequest
 	}
 
 	var existingUser models.User
@@ -156,7 +175,7 @@ func (s *AuthService) Invite(req InviteRequest, inviterRole, inviterOrgID string
 
 	invite := models.Invite{
 		Email:          req.Email,
-		OrganizationID: inviterOrgID,
+		OrganizationID: caller.OrganizationID,
 		RoleID:         req.RoleID,
 		Token:          inviteToken,
 		Expiry:         time.Now().Add(48 * time.Hour),
@@ -164,13 +183,14 @@ func (s *AuthService) Invite(req InviteRequest, inviterRole, inviterOrgID string
 		CreatedAt:      time.Now(),
 	}
 
-	if err := db.DB.Create(&invite).Error; err != nil {
-		return gin.H{"error": "Failed to create invite"}, http.StatusInternalServerError
-	}
-
-	err := utils.SendInviteEmail(req.Email, invite.Token, s.config)
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&invite).Error; err != nil {
+			return err
+		}
+		return s.queue.Enqueue(tx, caller.OrganizationID, TaskSendInvite, inviteTask{InviteID: invite.ID})
+	})
 	if err != nil {
-		return gin.H{"error": "Failed to send invite email"}, http.StatusInternalServerError
+		return gin.H{"error": "Failed to create invite"}, http.StatusInternalServerError
 	}
 
 	return gin.H{
@@ -240,10 +260,23 @@ func (s *AuthService) AcceptInvite(req AcceptInviteRequest) (gin.H, int) {
 	invite.IsAccepted = true
 	db.DB.Save(&invite)
 
+	token, err := utils.GenerateJWT(user.ID, user.Email, user.RoleID, user.OrganizationID)
+	if err != nil {
+		return gin.H{"error": "Failed to generate token"}, http.StatusInternalServerError
+	}
+
+	refreshToken, _, err := s.issueRefreshToken(db.DB, user.ID, "")
+	if err != nil {
+		return gin.H{"error": "Failed to generate refresh token"}, http.StatusInternalServerError
+	}
+
 	return gin.H{
 		"message":         "Account created successfully via invite",
 		"user_id":         user.ID,
 		"organization_id": user.OrganizationID,
+		"access_token":    token,
+		"refresh_token":   refreshToken,
+		"expires_in":      s.config.JWTExpiry,
 	}, http.StatusCreated
 }
 
@@ -251,19 +284,134 @@ type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
+// RefreshToken rotates a refresh token: the presented token is retired and a
+// new one from the same family is returned with a fresh access token.
+// Presenting a token that was already rotated or revoked revokes the whole
+// family, since it means the token has leaked.
 func (s *AuthService) RefreshToken(req RefreshTokenRequest) (gin.H, int) {
-	claims, err := utils.ValidateToken(req.RefreshToken)
-	if err != nil {
+	var user models.User
+	var newRefreshToken string
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var current models.RefreshToken
+		if err := tx.Where("token_hash = ?", utils.HashToken(req.RefreshToken)).First(&current).Error; err != nil {
+			return err
+		}
+
+		if current.RevokedAt != nil {
+			return errRefreshTokenReused
+		}
+		if time.Now().After(current.ExpiresAt) {
+			return gorm.ErrRecordNotFound
+		}
+
+		if err := tx.Where("id = ?", current.UserID).First(&user).Error; err != nil {
+			return err
+		}
+
+		next, nextID, err := s.issueRefreshToken(tx, current.UserID, current.FamilyID)
+		if err != nil {
+			return err
+		}
+		newRefreshToken = next
+
+		// Only one concurrent rotation of the same token may win; the loser is
+		// treated exactly like a replayed token.
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", current.ID).
+			Updates(map[string]interface{}{
+				"revoked_at":     time.Now(),
+				"replaced_by_id": nextID,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errRefreshTokenReused
+		}
+		return nil
+	})
+
+	if errors.Is(err, errRefreshTokenReused) {
+		s.revokeFamilyOf(req.RefreshToken)
+		return gin.H{"error": "Refresh token has already been used"}, http.StatusUnauthorized
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return gin.H{"error": "Invalid or expired refresh token"}, http.StatusUnauthorized
 	}
+	if err != nil {
+		return gin.H{"error": "Failed to refresh token"}, http.StatusInternalServerError
+	}
 
-	newAccessToken, err := utils.GenerateJWT(claims.UserID, claims.Email, claims.Role, claims.OrganizationID)
+	newAccessToken, err := utils.GenerateJWT(user.ID, user.Email, user.RoleID, user.OrganizationID)
 	if err != nil {
 		return gin.H{"error": "Failed to generate new access token"}, http.StatusInternalServerError
 	}
 
 	return gin.H{
-		"access_token": newAccessToken,
-		"expires_in":   s.config.JWTExpiry,
+		"access_token":  newAccessToken,
+		"refresh_token": newRefreshToken,
+		"expires_in":    s.config.JWTExpiry,
 	}, http.StatusOK
 }
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// Logout revokes the presented refresh token together with every token
+// rotated from the same login.
+func (s *AuthService) Logout(req LogoutRequest) (gin.H, int) {
+	var token models.RefreshToken
+	if err := db.DB.Where("token_hash = ?", utils.HashToken(req.RefreshToken)).First(&token).Error; err != nil {
+		return gin.H{"error": "Invalid refresh token"}, http.StatusUnauthorized
+	}
+
+	if err := revokeFamily(token.FamilyID); err != nil {
+		return gin.H{"error": "Failed to revoke refresh token"}, http.StatusInternalServerError
+	}
+
+	return gin.H{"message": "Logged out successfully"}, http.StatusOK
+}
+
+// issueRefreshToken persists a new refresh token for userID and returns the
+// opaque value to hand to the client along with the row id. An empty familyID
+// starts a new family.
+func (s *AuthService) issueRefreshToken(tx *gorm.DB, userID, familyID string) (string, string, error) {
+	token := utils.GenerateRandomToken(32)
+	if token == "" {
+		return "", "", errors.New("failed to generate random token")
+	}
+	if familyID == "" {
+		familyID = uuid.NewString()
+	}
+
+	record := models.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(time.Duration(s.config.RefreshTokenExpiry) * time.Hour),
+		CreatedAt: time.Now(),
+	}
+	if err := tx.Create(&record).Error; err != nil {
+		return "", "", err
+	}
+	return token, record.ID, nil
+}
+
+func (s *AuthService) revokeFamilyOf(rawToken string) {
+	var token models.RefreshToken
+	if err := db.DB.Where("token_hash = ?", utils.HashToken(rawToken)).First(&token).Error; err != nil {
+		return
+	}
+	log.Printf("Refresh token reuse detected for user %s, revoking family %s", token.UserID, token.FamilyID)
+	if err := revokeFamily(token.FamilyID); err != nil {
+		log.Printf("Failed to revoke refresh token family %s: %v", token.FamilyID, err)
+	}
+}
+
+func revokeFamily(familyID string) error {
+	return db.DB.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/resumelens/authservice/internal/db"
+	"github.com/resumelens/authservice/internal/extract"
+	"github.com/resumelens/authservice/internal/models"
+	"github.com/resumelens/authservice/internal/parser"
+	"gorm.io/gorm"
+)
+
+// maxImportFiles caps the number of resumes in one archive.
+const maxImportFiles = 200
+
+// Values of ImportFileResult.Status.
+const (
+	ImportCreated = "created" // new candidate
+	ImportUpdated = "updated" // existing candidate, resume replaced
+	ImportSkipped = "skipped"
+	ImportFailed  = "failed"
+)
+
+// ImportFileResult is one line of the import report.
+type ImportFileResult struct {
+	File          string `json:"file"`
+	Status        string `json:"status"`
+	CandidateID   string `json:"candidate_id,omitempty"`
+	ApplicationID string `json:"application_id,omitempty"`
+	FullName      string `json:"full_name,omitempty"`
+	Email         string `json:"email,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+type ImportReport struct {
+	JobID   string             `json:"job_id"`
+	Total   int                `json:"total"`
+	Created int                `json:"created"`
+	Updated int                `json:"updated"`
+	Skipped int                `json:"skipped"`
+	Failed  int                `json:"failed"`
+	Files   []ImportFileResult `json:"files"`
+}
+
+func (r *ImportReport) add(result ImportFileResult) {
+	r.Files = append(r.Files, result)
+	r.Total++
+	switch result.Status {
+	case ImportCreated:
+		r.Created++
+	case ImportUpdated:
+		r.Updated++
+	case ImportSkipped:
+		r.Skipped++
+	case ImportFailed:
+		r.Failed++
+	}
+}
+
+// ImportResumes treats every PDF, DOCX and TXT file in a zip archive as a separate
+// candidate's resume. Contact details are parsed from each file to find the
+// existing candidate by email or create a new one, and the candidate gets an
+// application for the job. One file failing doesn't stop the others, but
+// cancelling ctx skips the files not yet started; the report says what
+// happened to each.
+func (s *JobApplicationService) ImportResumes(ctx context.Context, caller Caller, jobID string, file multipart.File, header *multipart.FileHeader) (*ImportReport, error) {
+	job, err := loadJob(caller.OrganizationID, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	archiveLimits := s.limits
+	archiveLimits.MaxArchiveSize = s.config.ImportMaxArchiveMB << 20
+	archive, err := archiveLimits.Archive(file, header.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ImportReport{JobID: job.ID, Files: []ImportFileResult{}}
+	seen := make(map[string]string) // email -> file that claimed it
+	imported := 0
+
+	for _, entry := range archive.File {
+		name := entry.Name
+		if entry.FileInfo().IsDir() || isArchiveJunk(name) {
+			continue
+		}
+		if ext := strings.ToLower(path.Ext(name)); ext != ".pdf" && ext != ".docx" && ext != ".txt" {
+			report.add(ImportFileResult{File: name, Status: ImportSkipped, Error: "not a PDF, DOCX or TXT file"})
+			continue
+		}
+		if imported == maxImportFiles {
+			report.add(ImportFileResult{File: name, Status: ImportSkipped, Error: fmt.Sprintf("archive has more than %d resumes", maxImportFiles)})
+			continue
+		}
+		if ctx.Err() != nil {
+			// The client went away; what was imported so far stays.
+			report.add(ImportFileResult{File: name, Status: ImportSkipped, Error: "import cancelled"})
+			continue
+		}
+		imported++
+		report.add(s.importEntry(ctx, caller, job, entry, seen))
+	}
+
+	return report, nil
+}
+
+func (s *JobApplicationService) importEntry(ctx context.Context, caller Caller, job *models.Job, entry *zip.File, seen map[string]string) ImportFileResult {
+	result := ImportFileResult{File: entry.Name, Status: ImportFailed}
+
+	data, err := s.limits.ReadEntry(entry)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	fileType, err := s.limits.Document(bytes.NewReader(data), int64(len(data)), entry.Name)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	extracted, err := extract.Text(entry.Name, data)
+	if err != nil {
+		result.Error = "could not read resume: " + err.Error()
+		return result
+	}
+	profile := parser.Parse(extracted.Text)
+
+	email := strings.ToLower(profile.Email.Value)
+	if email == "" {
+		result.Error = "no email address found in resume"
+		return result
+	}
+	result.Email = email
+	if other, ok := seen[email]; ok {
+		result.Status = ImportSkipped
+		result.Error = "same candidate as " + other
+		return result
+	}
+	seen[email] = entry.Name
+
+	candidate, isNew, err := importCandidate(job.OrganizationID, caller.UserID, email, entry.Name, profile)
+	if err != nil {
+		result.Error = "failed to look up candidate"
+		return result
+	}
+
+	application, _, err := s.storeDocument(ctx, job, candidate, isNew, documentResume, fileType, bytes.NewReader(data), path.Base(entry.Name), &caller.UserID, false)
+	if errors.Is(err, ErrCandidateExists) {
+		// Another request saved a candidate with this email since the
+		// lookup; attach the resume to theirs instead.
+		if candidate, isNew, err = importCandidate(job.OrganizationID, caller.UserID, email, entry.Name, profile); err != nil {
+			result.Error = "failed to look up candidate"
+			return result
+		}
+		application, _, err = s.storeDocument(ctx, job, candidate, isNew, documentResume, fileType, bytes.NewReader(data), path.Base(entry.Name), &caller.UserID, false)
+	}
+	if err != nil {
+		result.Error = "failed to store resume"
+		return result
+	}
+
+	result.Status = ImportUpdated
+	if isNew {
+		result.Status = ImportCreated
+	}
+	result.CandidateID = candidate.ID
+	result.ApplicationID = application.ID
+	result.FullName = candidate.FullName
+	return result
+}
+
+// importCandidate finds the organization's candidate with email or builds a
+// new, unsaved one from the parsed resume. Without a confident name the
+// file name stands in so the row can be created; recruiters can fix it.
+func importCandidate(orgID, addedBy, email, filename string, profile *parser.Resume) (*models.Candidate, bool, error) {
+	var candidate models.Candidate
+	err := db.DB.Scopes(ForOrganization(orgID)).Where("lower(email) = ?", email).First(&candidate).Error
+	if err == nil {
+		return &candidate, false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, err
+	}
+
+	confident := func(f parser.Field) string {
+		if f.Confidence >= minPrefillConfidence {
+			return f.Value
+		}
+		return ""
+	}
+	fullName := confident(profile.Name)
+	if fullName == "" {
+		fullName = strings.TrimSuffix(path.Base(filename), path.Ext(filename))
+	}
+
+	candidate = models.Candidate{
+		ID:             uuid.NewString(),
+		OrganizationID: orgID,
+		UserID:         &addedBy,
+		FullName:       fullName,
+		Email:          email,
+		Phone:          confident(profile.Phone),
+		LinkedIn:       confident(profile.LinkedIn),
+		GitHub:         confident(profile.GitHub),
+		Location:       confident(profile.Location),
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+	return &candidate, true, nil
+}
+
+// isArchiveJunk reports entries that archivers add on their own, such as
+// macOS resource forks.
+func isArchiveJunk(name string) bool {
+	base := path.Base(name)
+	return strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(base, ".") || strings.HasPrefix(base, "~$")
+}
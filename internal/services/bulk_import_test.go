@@ -0,0 +1,50 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/resumelens/authservice/internal/config"
+	"github.com/resumelens/authservice/internal/storage"
+)
+
+// TestImportResumesCancelled checks that a cancelled import stops before the
+// next file instead of running through the whole archive.
+func TestImportResumesCancelled(t *testing.T) {
+	statements := dryRunDB(t)
+	var archive bytes.Buffer
+	w := zip.NewWriter(&archive)
+	for _, name := range []string{"a.txt", "b.txt", "notes.md"} {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte("Jane Doe\njane@example.com\n"))
+	}
+	w.Close()
+
+	store := storage.NewMemoryStore()
+	applications := NewJobApplicationService(&config.Config{UploadMaxFileMB: 1, ImportMaxArchiveMB: 1}, store, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	upload := uploadedFile(t, "resumes.zip", archive.String())
+	report, err := applications.ImportResumes(ctx, Caller{UserID: "user-a", OrganizationID: "org-a"}, "job-a", upload.File, upload.Header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Total != 3 || report.Skipped != 3 || report.Created+report.Updated+report.Failed != 0 {
+		t.Errorf("report = %+v; want all 3 files skipped", report)
+	}
+	for _, f := range report.Files[:2] {
+		if f.Error != "import cancelled" {
+			t.Errorf("%s: %q; want import cancelled", f.File, f.Error)
+		}
+	}
+	// Only the job lookup ran.
+	if len(*statements) != 1 {
+		t.Errorf("ran %d statements; want 1: %v", len(*statements), *statements)
+	}
+}
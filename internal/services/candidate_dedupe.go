@@ -0,0 +1,441 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"github.com/resumelens/authservice/internal/db"
+	"github.com/resumelens/authservice/internal/models"
+	"github.com/resumelens/authservice/internal/queue"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Values of CandidateIdentity.Kind, which are also the reasons given for a
+// suspected duplicate.
+const (
+	IdentityEmail    = "email"
+	IdentityPhone    = "phone"
+	IdentityLinkedIn = "linkedin"
+	IdentityGitHub   = "github"
+	IdentityResume   = "resume"
+)
+
+// Values of CandidateDuplicate.Status.
+const (
+	DuplicateOpen      = "open"
+	DuplicateDismissed = "dismissed"
+	DuplicateMerged    = "merged"
+)
+
+const defaultDuplicatePageSize = 50
+
+// phoneDigits is how many trailing digits of a phone number are compared,
+// so numbers written with and without a country code or trunk prefix match.
+const phoneDigits = 10
+
+type candidateTask struct {
+	CandidateID string `json:"candidate_id"`
+}
+
+// CandidateService finds candidates that are probably the same person and
+// merges them.
+type CandidateService struct {
+	queue *queue.Queue
+}
+
+func NewCandidateService(q *queue.Queue) *CandidateService {
+	return &CandidateService{queue: q}
+}
+
+// normalizeEmail lowercases an address and drops any +tag. Gmail ignores
+// dots in the local part, so they are dropped there too.
+func normalizeEmail(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return ""
+	}
+	local, domain := email[:at], email[at+1:]
+	if plus := strings.Index(local, "+"); plus > 0 {
+		local = local[:plus]
+	}
+	if domain == "googlemail.com" {
+		domain = "gmail.com"
+	}
+	if domain == "gmail.com" {
+		local = strings.ReplaceAll(local, ".", "")
+	}
+	return local + "@" + domain
+}
+
+// normalizePhone keeps the last phoneDigits digits of a number. Numbers too
+// short to be distinctive are ignored.
+func normalizePhone(phone string) string {
+	var digits strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	d := digits.String()
+	if len(d) < 7 {
+		return ""
+	}
+	if len(d) > phoneDigits {
+		d = d[len(d)-phoneDigits:]
+	}
+	return d
+}
+
+// normalizeProfileURL reduces a LinkedIn or GitHub profile link to
+// host/path, lowercased, without scheme, "www.", query or trailing slash.
+// GitHub links keep only the user, since people link to repositories too;
+// a bare GitHub username is accepted as well.
+func normalizeProfileURL(raw, host string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return ""
+	}
+	if host == "github.com" && !strings.ContainsAny(raw, "/.") {
+		return host + "/" + strings.ToLower(raw)
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	h := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if h != host && !strings.HasSuffix(h, "."+host) {
+		return ""
+	}
+
+	var segments []string
+	for _, s := range strings.Split(strings.ToLower(u.Path), "/") {
+		if s != "" {
+			segments = append(segments, s)
+		}
+	}
+	if len(segments) == 0 {
+		return ""
+	}
+	switch host {
+	case "github.com":
+		segments = segments[:1]
+	case "linkedin.com":
+		// linkedin.com/in/<name>/details/... is still <name>'s profile.
+		if segments[0] == "in" && len(segments) > 2 {
+			segments = segments[:2]
+		}
+	}
+	return host + "/" + strings.Join(segments, "/")
+}
+
+// candidateIdentities lists the normalized values that identify a
+// candidate: their contact details and the hashes of their clean resumes.
+func candidateIdentities(tx *gorm.DB, candidate *models.Candidate) ([]models.CandidateIdentity, error) {
+	values := map[string][]string{
+		IdentityEmail:    {normalizeEmail(candidate.Email)},
+		IdentityPhone:    {normalizePhone(candidate.Phone)},
+		IdentityLinkedIn: {normalizeProfileURL(candidate.LinkedIn, "linkedin.com")},
+		IdentityGitHub:   {normalizeProfileURL(candidate.GitHub, "github.com")},
+	}
+	var hashes []string
+	if err := tx.Model(&models.DocumentVersion{}).
+		Joins("JOIN job_applications ON job_applications.id = document_versions.application_id").
+		Where("job_applications.candidate_id = ? AND document_versions.kind = ? AND document_versions.scan_status = ?",
+			candidate.ID, documentResume, ScanStatusClean).
+		Distinct().Pluck("document_versions.sha256", &hashes).Error; err != nil {
+		return nil, err
+	}
+	values[IdentityResume] = hashes
+
+	var identities []models.CandidateIdentity
+	for kind, list := range values {
+		for _, value := range list {
+			if value != "" {
+				identities = append(identities, models.CandidateIdentity{
+					OrganizationID: candidate.OrganizationID,
+					Kind:           kind,
+					Value:          value,
+					CandidateID:    candidate.ID,
+				})
+			}
+		}
+	}
+	return identities, nil
+}
+
+// orderedPair returns two candidate ids in the order CandidateDuplicate
+// stores them.
+func orderedPair(a, b string) (string, string) {
+	if a < b {
+		return a, b
+	}
+	return b, a
+}
+
+// DetectDuplicates refreshes a candidate's identities and records every
+// other candidate in the organization sharing one of them as a suspected
+// duplicate. Open suspicions that no longer hold are dropped; dismissed ones
+// stay dismissed.
+func (s *CandidateService) DetectDuplicates(ctx context.Context, candidateID string) error {
+	var candidate models.Candidate
+	if err := db.DB.Where("id = ?", candidateID).First(&candidate).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Merged into another candidate since the task was queued.
+			return nil
+		}
+		return err
+	}
+
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		// Two candidates detected at the same time wouldn't see each
+		// other's uncommitted identities, so detection is serialized per
+		// organization.
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "candidate-dedupe:"+candidate.OrganizationID).Error; err != nil {
+			return err
+		}
+
+		identities, err := candidateIdentities(tx, &candidate)
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("candidate_id = ?", candidate.ID).Delete(&models.CandidateIdentity{}).Error; err != nil {
+			return err
+		}
+
+		reasons := make(map[string][]string)
+		if len(identities) > 0 {
+			if err := tx.Create(&identities).Error; err != nil {
+				return err
+			}
+
+			keys := make([][]interface{}, len(identities))
+			for i, identity := range identities {
+				keys[i] = []interface{}{identity.Kind, identity.Value}
+			}
+			var matches []models.CandidateIdentity
+			if err := tx.Scopes(ForOrganization(candidate.OrganizationID)).
+				Where("candidate_id <> ? AND (kind, value) IN ?", candidate.ID, keys).
+				Find(&matches).Error; err != nil {
+				return err
+			}
+			seen := make(map[[2]string]bool)
+			for _, m := range matches {
+				if key := [2]string{m.CandidateID, m.Kind}; !seen[key] {
+					seen[key] = true
+					reasons[m.CandidateID] = append(reasons[m.CandidateID], m.Kind)
+				}
+			}
+		}
+
+		others := make([]string, 0, len(reasons))
+		now := time.Now()
+		for other, kinds := range reasons {
+			others = append(others, other)
+			sort.Strings(kinds)
+			first, second := orderedPair(candidate.ID, other)
+			pair := models.CandidateDuplicate{
+				OrganizationID: candidate.OrganizationID,
+				CandidateID:    first,
+				DuplicateID:    second,
+				Reasons:        pq.StringArray(kinds),
+				Status:         DuplicateOpen,
+				CreatedAt:      now,
+				UpdatedAt:      now,
+			}
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "candidate_id"}, {Name: "duplicate_id"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{"reasons": pair.Reasons, "updated_at": now}),
+			}).Create(&pair).Error; err != nil {
+				return err
+			}
+		}
+
+		stale := tx.Where("status = ? AND (candidate_id = ? OR duplicate_id = ?)", DuplicateOpen, candidate.ID, candidate.ID)
+		if len(others) > 0 {
+			stale = stale.Where("candidate_id NOT IN ? AND duplicate_id NOT IN ?", others, others)
+		}
+		return stale.Delete(&models.CandidateDuplicate{}).Error
+	})
+}
+
+type ListDuplicatesRequest struct {
+	Status string `form:"status" binding:"omitempty,oneof=open dismissed merged"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=200"`
+	Offset int    `form:"offset" binding:"omitempty,min=0"`
+}
+
+// DuplicateCandidate describes one side of a suspected duplicate. It is nil
+// in merged pairs for the candidate that was merged away.
+type DuplicateCandidate struct {
+	ID             string    `json:"id"`
+	FullName       string    `json:"full_name"`
+	Email          string    `json:"email"`
+	Phone          string    `json:"phone"`
+	LinkedIn       string    `json:"linkedin"`
+	GitHub         string    `json:"github"`
+	Location       string    `json:"location"`
+	ApplicationIDs []string  `json:"application_ids"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type DuplicateView struct {
+	ID         string              `json:"id"`
+	Reasons    []string            `json:"reasons"`
+	Status     string              `json:"status"`
+	Candidate  *DuplicateCandidate `json:"candidate"`
+	Duplicate  *DuplicateCandidate `json:"duplicate"`
+	ResolvedBy *string             `json:"resolved_by"`
+	ResolvedAt *time.Time          `json:"resolved_at"`
+	CreatedAt  time.Time           `json:"created_at"`
+	UpdatedAt  time.Time           `json:"updated_at"`
+}
+
+// ListDuplicates returns the organization's suspected duplicates, open ones
+// unless another status is asked for, most recently detected first.
+func (s *CandidateService) ListDuplicates(caller Caller, req ListDuplicatesRequest) (gin.H, int) {
+	status := req.Status
+	if status == "" {
+		status = DuplicateOpen
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultDuplicatePageSize
+	}
+
+	query := db.DB.Model(&models.CandidateDuplicate{}).Scopes(ForOrganization(caller.OrganizationID)).Where("status = ?", status)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return gin.H{"error": "Failed to load duplicates"}, http.StatusInternalServerError
+	}
+	var pairs []models.CandidateDuplicate
+	if err := query.Order("updated_at DESC").Limit(limit).Offset(req.Offset).Find(&pairs).Error; err != nil {
+		return gin.H{"error": "Failed to load duplicates"}, http.StatusInternalServerError
+	}
+
+	ids := make([]string, 0, 2*len(pairs))
+	for _, p := range pairs {
+		ids = append(ids, p.CandidateID, p.DuplicateID)
+	}
+	candidates, err := duplicateCandidates(caller.OrganizationID, ids)
+	if err != nil {
+		return gin.H{"error": "Failed to load duplicates"}, http.StatusInternalServerError
+	}
+
+	views := make([]DuplicateView, 0, len(pairs))
+	for _, p := range pairs {
+		views = append(views, DuplicateView{
+			ID:         p.ID,
+			Reasons:    p.Reasons,
+			Status:     p.Status,
+			Candidate:  candidates[p.CandidateID],
+			Duplicate:  candidates[p.DuplicateID],
+			ResolvedBy: p.ResolvedBy,
+			ResolvedAt: p.ResolvedAt,
+			CreatedAt:  p.CreatedAt,
+			UpdatedAt:  p.UpdatedAt,
+		})
+	}
+	return gin.H{"duplicates": views, "total": total}, http.StatusOK
+}
+
+// duplicateCandidates loads the candidates with the given ids along with
+// their applications.
+func duplicateCandidates(orgID string, ids []string) (map[string]*DuplicateCandidate, error) {
+	result := make(map[string]*DuplicateCandidate)
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	var candidates []models.Candidate
+	if err := db.DB.Scopes(ForOrganization(orgID)).Where("id IN ?", ids).Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+	for _, c := range candidates {
+		result[c.ID] = &DuplicateCandidate{
+			ID:             c.ID,
+			FullName:       c.FullName,
+			Email:          c.Email,
+			Phone:          c.Phone,
+			LinkedIn:       c.LinkedIn,
+			GitHub:         c.GitHub,
+			Location:       c.Location,
+			ApplicationIDs: []string{},
+			CreatedAt:      c.CreatedAt,
+		}
+	}
+
+	var applications []models.JobApplication
+	if err := db.DB.Scopes(ApplicationsForOrganization(orgID)).
+		Select("job_applications.id, job_applications.candidate_id").
+		Where("job_applications.candidate_id IN ?", ids).
+		Order("job_applications.created_at").
+		Find(&applications).Error; err != nil {
+		return nil, err
+	}
+	for _, a := range applications {
+		if c := result[a.CandidateID]; c != nil {
+			c.ApplicationIDs = append(c.ApplicationIDs, a.ID)
+		}
+	}
+	return result, nil
+}
+
+// DismissDuplicate records that a suspected pair are different people.
+// The pair isn't suggested again unless it is merged by hand.
+func (s *CandidateService) DismissDuplicate(caller Caller, duplicateID string) (gin.H, int) {
+	var pair models.CandidateDuplicate
+	if err := db.DB.Scopes(ForOrganization(caller.OrganizationID)).Where("id = ?", duplicateID).First(&pair).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return gin.H{"error": "Duplicate not found"}, http.StatusNotFound
+		}
+		return gin.H{"error": "Failed to load duplicate"}, http.StatusInternalServerError
+	}
+
+	now := time.Now()
+	result := db.DB.Model(&pair).Where("status = ?", DuplicateOpen).Updates(map[string]interface{}{
+		"status":      DuplicateDismissed,
+		"resolved_by": caller.UserID,
+		"resolved_at": now,
+		"updated_at":  now,
+	})
+	if result.Error != nil {
+		return gin.H{"error": "Failed to dismiss duplicate"}, http.StatusInternalServerError
+	}
+	if result.RowsAffected == 0 {
+		return gin.H{"error": "Only open duplicates can be dismissed"}, http.StatusConflict
+	}
+	return gin.H{"message": "Duplicate dismissed"}, http.StatusOK
+}
+
+// RescanDuplicates queues duplicate detection for every candidate in the
+// organization, for candidates added before detection existed.
+func (s *CandidateService) RescanDuplicates(caller Caller) (gin.H, int) {
+	var ids []string
+	if err := db.DB.Model(&models.Candidate{}).Scopes(ForOrganization(caller.OrganizationID)).Pluck("id", &ids).Error; err != nil {
+		return gin.H{"error": "Failed to load candidates"}, http.StatusInternalServerError
+	}
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		for _, id := range ids {
+			if err := s.queue.Enqueue(tx, caller.OrganizationID, TaskDetectDuplicates, candidateTask{CandidateID: id}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return gin.H{"error": "Failed to queue duplicate detection"}, http.StatusInternalServerError
+	}
+	return gin.H{"message": "Duplicate detection queued", "candidates": len(ids)}, http.StatusAccepted
+}
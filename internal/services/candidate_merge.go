@@ -0,0 +1,304 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/resumelens/authservice/internal/db"
+	"github.com/resumelens/authservice/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrSameCandidate = errors.New("a candidate can't be merged into itself")
+
+type MergeCandidatesRequest struct {
+	DuplicateID string `json:"duplicate_id" binding:"required,uuid"`
+}
+
+// mergeResult lists what a merge did to the duplicate's applications and
+// which follow-up work it needs.
+type mergeResult struct {
+	moved     []string // applications re-pointed at the kept candidate
+	merged    []string // applications folded into the kept candidate's and deleted
+	targets   []string // the kept candidate's applications that received them
+	reprocess []string // applications whose resume changed hands
+}
+
+// MergeCandidates merges the duplicate into candidateID, which is kept.
+// Empty contact fields are filled from the duplicate, and its tags, notes
+// and applications are moved over. Where both applied to the same job, the
+// duplicate's application is folded into the kept one: its document
+// versions are appended after the kept application's, and its history,
+// access log, quarantine records and ratings move with them. The duplicate
+// is then deleted.
+func (s *CandidateService) MergeCandidates(caller Caller, candidateID string, req MergeCandidatesRequest) (gin.H, int) {
+	var kept models.Candidate
+	result := mergeResult{moved: []string{}, merged: []string{}}
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if candidateID == req.DuplicateID {
+			return ErrSameCandidate
+		}
+
+		// Locked in id order so concurrent merges of the same pair can't
+		// deadlock.
+		var candidates []models.Candidate
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Scopes(ForOrganization(caller.OrganizationID)).
+			Where("id IN ?", []string{candidateID, req.DuplicateID}).
+			Order("id").Find(&candidates).Error; err != nil {
+			return err
+		}
+		if len(candidates) != 2 {
+			return ErrNotFound
+		}
+		duplicate := candidates[0]
+		kept = candidates[1]
+		if kept.ID != candidateID {
+			kept, duplicate = duplicate, kept
+		}
+
+		if err := fillCandidate(tx, &kept, &duplicate); err != nil {
+			return err
+		}
+
+		var applications []models.JobApplication
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("candidate_id = ?", duplicate.ID).Find(&applications).Error; err != nil {
+			return err
+		}
+		for i := range applications {
+			source := &applications[i]
+			var target models.JobApplication
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("candidate_id = ? AND job_id = ?", kept.ID, source.JobID).First(&target).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				if err := tx.Model(source).Update("candidate_id", kept.ID).Error; err != nil {
+					return err
+				}
+				result.moved = append(result.moved, source.ID)
+				result.reprocess = append(result.reprocess, source.ID)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			adoptedResume, err := foldApplication(tx, caller, &target, source)
+			if err != nil {
+				return err
+			}
+			result.merged = append(result.merged, source.ID)
+			result.targets = append(result.targets, target.ID)
+			if adoptedResume {
+				result.reprocess = append(result.reprocess, target.ID)
+			}
+		}
+
+		if err := tx.Model(&models.CandidateNote{}).Where("candidate_id = ?", duplicate.ID).
+			Update("candidate_id", kept.ID).Error; err != nil {
+			return err
+		}
+		if err := resolveMergedPair(tx, caller, kept.ID, duplicate.ID); err != nil {
+			return err
+		}
+		if err := tx.Where("candidate_id = ?", duplicate.ID).Delete(&models.CandidateIdentity{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&duplicate).Error; err != nil {
+			return err
+		}
+		return s.enqueueMergeFollowUp(tx, caller.OrganizationID, kept.ID, &result)
+	})
+	switch {
+	case errors.Is(err, ErrSameCandidate):
+		return gin.H{"error": err.Error()}, http.StatusBadRequest
+	case errors.Is(err, ErrNotFound):
+		return gin.H{"error": "Candidate not found"}, http.StatusNotFound
+	case err != nil:
+		return gin.H{"error": "Failed to merge candidates"}, http.StatusInternalServerError
+	}
+
+	return gin.H{
+		"message":             "Candidates merged",
+		"candidate":           candidateView(&kept),
+		"moved_applications":  result.moved,
+		"merged_applications": result.merged,
+	}, http.StatusOK
+}
+
+// fillCandidate copies the duplicate's details into the kept candidate's
+// empty fields and adds its tags. The kept candidate's email always stays.
+func fillCandidate(tx *gorm.DB, kept, duplicate *models.Candidate) error {
+	updates := map[string]interface{}{}
+	fill := func(column string, field *string, value string) {
+		if *field == "" && value != "" {
+			*field = value
+			updates[column] = value
+		}
+	}
+	fill("full_name", &kept.FullName, duplicate.FullName)
+	fill("phone", &kept.Phone, duplicate.Phone)
+	fill("linked_in", &kept.LinkedIn, duplicate.LinkedIn)
+	fill("git_hub", &kept.GitHub, duplicate.GitHub)
+	fill("location", &kept.Location, duplicate.Location)
+	fill("experience", &kept.Experience, duplicate.Experience)
+	fill("education", &kept.Education, duplicate.Education)
+	fill("skills", &kept.Skills, duplicate.Skills)
+	if len(duplicate.Tags) > 0 {
+		tags := append(append([]string{}, kept.Tags...), duplicate.Tags...)
+		merged, err := normalizeTags(tags)
+		if err != nil {
+			// Too many for one candidate; keep the first maxTags.
+			merged, _ = normalizeTags(tags[:maxTags])
+		}
+		if len(merged) != len(kept.Tags) {
+			kept.Tags = merged
+			updates["tags"] = merged
+		}
+	}
+	if kept.UserID == nil && duplicate.UserID != nil {
+		kept.UserID = duplicate.UserID
+		updates["user_id"] = *duplicate.UserID
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	kept.UpdatedAt = time.Now()
+	updates["updated_at"] = kept.UpdatedAt
+	return tx.Model(kept).Updates(updates).Error
+}
+
+// foldApplication moves everything recorded against source into target,
+// both applications to the same job, and deletes source. Target keeps its
+// current documents; a document it has none of is taken from source, and
+// the bool reports whether that happened for the resume.
+func foldApplication(tx *gorm.DB, caller Caller, target, source *models.JobApplication) (bool, error) {
+	for _, kind := range []string{documentResume, documentCoverLetter} {
+		var offset int
+		if err := tx.Model(&models.DocumentVersion{}).
+			Where("application_id = ? AND kind = ?", target.ID, kind).
+			Select("COALESCE(MAX(version), 0)").Scan(&offset).Error; err != nil {
+			return false, err
+		}
+		if err := tx.Model(&models.DocumentVersion{}).
+			Where("application_id = ? AND kind = ?", source.ID, kind).
+			Updates(map[string]interface{}{
+				"application_id": target.ID,
+				"version":        gorm.Expr("version + ?", offset),
+			}).Error; err != nil {
+			return false, err
+		}
+	}
+
+	updates := map[string]interface{}{}
+	adoptedResume := target.ResumeGCSPath == "" && source.ResumeGCSPath != ""
+	if adoptedResume {
+		updates["resume_gcs_path"] = source.ResumeGCSPath
+		updates["current_resume_id"] = source.CurrentResumeID
+		updates["resume_scan_status"] = source.ResumeScanStatus
+	}
+	if target.CoverLetterGCSPath == "" && source.CoverLetterGCSPath != "" {
+		updates["cover_letter_gcs_path"] = source.CoverLetterGCSPath
+		updates["current_cover_letter_id"] = source.CurrentCoverLetterID
+		updates["cover_letter_scan_status"] = source.CoverLetterScanStatus
+	}
+	if len(updates) > 0 {
+		if err := tx.Model(target).Updates(updates).Error; err != nil {
+			return false, err
+		}
+	}
+
+	// An interviewer who rated both keeps their rating of target.
+	if err := tx.Exec(`UPDATE application_ratings SET application_id = ? WHERE application_id = ?
+		AND user_id NOT IN (SELECT user_id FROM application_ratings WHERE application_id = ?)`,
+		target.ID, source.ID, target.ID).Error; err != nil {
+		return false, err
+	}
+	if err := tx.Where("application_id = ?", source.ID).Delete(&models.ApplicationRating{}).Error; err != nil {
+		return false, err
+	}
+
+	for _, model := range []interface{}{&models.ApplicationStageHistory{}, &models.DocumentAccessLog{}, &models.QuarantinedFile{}, &models.CandidateNote{}} {
+		if err := tx.Model(model).Where("application_id = ?", source.ID).Update("application_id", target.ID).Error; err != nil {
+			return false, err
+		}
+	}
+	history := models.ApplicationStageHistory{
+		ApplicationID: target.ID,
+		FromStage:     target.Status,
+		ToStage:       target.Status,
+		ActorID:       &caller.UserID,
+		Note:          "Merged application " + source.ID + " of a duplicate candidate",
+		CreatedAt:     time.Now(),
+	}
+	if err := tx.Create(&history).Error; err != nil {
+		return false, err
+	}
+
+	if err := tx.Delete(source).Error; err != nil {
+		return false, err
+	}
+	if err := tx.Model(&models.Job{}).Where("id = ?", source.JobID).
+		UpdateColumn("application_count", gorm.Expr("application_count - 1")).Error; err != nil {
+		return false, err
+	}
+	return adoptedResume, refreshJobAnalytics(tx, source.JobID)
+}
+
+// resolveMergedPair marks the pair as merged, recording it even if it was
+// never suspected, and drops every other suspicion about the duplicate;
+// detection re-runs for the kept candidate.
+func resolveMergedPair(tx *gorm.DB, caller Caller, keptID, duplicateID string) error {
+	now := time.Now()
+	first, second := orderedPair(keptID, duplicateID)
+	pair := models.CandidateDuplicate{
+		OrganizationID: caller.OrganizationID,
+		CandidateID:    first,
+		DuplicateID:    second,
+		Status:         DuplicateMerged,
+		ResolvedBy:     &caller.UserID,
+		ResolvedAt:     &now,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "candidate_id"}, {Name: "duplicate_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"status":      DuplicateMerged,
+			"resolved_by": caller.UserID,
+			"resolved_at": now,
+			"updated_at":  now,
+		}),
+	}).Create(&pair).Error; err != nil {
+		return err
+	}
+
+	return tx.Where("status <> ? AND (candidate_id = ? OR duplicate_id = ?)", DuplicateMerged, duplicateID, duplicateID).
+		Delete(&models.CandidateDuplicate{}).Error
+}
+
+// enqueueMergeFollowUp queues the work a merge leaves behind. Moved
+// resumes are processed again so their embeddings carry the kept
+// candidate's id, folded applications lose theirs, metadata.json is
+// rewritten for every application that changed, and the kept candidate is
+// checked for further duplicates.
+func (s *CandidateService) enqueueMergeFollowUp(tx *gorm.DB, orgID, keptID string, result *mergeResult) error {
+	for _, id := range result.reprocess {
+		if err := s.queue.Enqueue(tx, orgID, TaskProcessResume, applicationTask{ApplicationID: id}); err != nil {
+			return err
+		}
+	}
+	for _, id := range result.merged {
+		if err := s.queue.Enqueue(tx, orgID, TaskUnindexResume, applicationTask{ApplicationID: id}); err != nil {
+			return err
+		}
+	}
+	for _, id := range append(append([]string{}, result.moved...), result.targets...) {
+		if err := s.queue.Enqueue(tx, orgID, TaskExportMetadata, applicationTask{ApplicationID: id}); err != nil {
+			return err
+		}
+	}
+	return s.queue.Enqueue(tx, orgID, TaskDetectDuplicates, candidateTask{CandidateID: keptID})
+}
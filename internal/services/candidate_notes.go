@@ -0,0 +1,287 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"github.com/resumelens/authservice/internal/db"
+	"github.com/resumelens/authservice/internal/models"
+	"gorm.io/gorm"
+)
+
+var ErrNotNoteAuthor = errors.New("only the author can change a note")
+
+// mentionPattern matches "@" followed by an email address, how notes
+// mention users; the first "@" must not be part of a word.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w.+-])@([\w.%+-]+@[\w-]+(?:\.[\w-]+)*\.[A-Za-z]{2,})`)
+
+type mentionTask struct {
+	NoteID string `json:"note_id"`
+	UserID string `json:"user_id"`
+}
+
+type CreateNoteRequest struct {
+	Body          string `json:"body" binding:"required,max=10000"`
+	ApplicationID string `json:"application_id" binding:"omitempty,uuid"`
+}
+
+type UpdateNoteRequest struct {
+	Body string `json:"body" binding:"required,max=10000"`
+}
+
+type ListNotesRequest struct {
+	ApplicationID string `form:"application_id" binding:"omitempty,uuid"`
+}
+
+type MentionView struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+type NoteView struct {
+	ID            string        `json:"id"`
+	CandidateID   string        `json:"candidate_id"`
+	ApplicationID *string       `json:"application_id"`
+	AuthorID      string        `json:"author_id"`
+	AuthorEmail   string        `json:"author_email"`
+	Body          string        `json:"body"`
+	Mentions      []MentionView `json:"mentions"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+}
+
+// resolveMentions returns the ids of the organization's users mentioned in
+// body. Addresses that aren't users of the organization are left as text.
+func resolveMentions(orgID, body string) (pq.StringArray, error) {
+	var emails []string
+	for _, m := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		emails = append(emails, strings.ToLower(m[1]))
+	}
+	ids := pq.StringArray{}
+	if len(emails) == 0 {
+		return ids, nil
+	}
+	if err := db.DB.Model(&models.User{}).Scopes(ForOrganization(orgID)).
+		Where("lower(email) IN ?", emails).Order("email").Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// noteViews attaches author and mentioned users' emails to notes.
+func noteViews(notes []models.CandidateNote) ([]NoteView, error) {
+	userIDs := []string{}
+	for _, n := range notes {
+		userIDs = append(userIDs, n.AuthorID)
+		userIDs = append(userIDs, n.Mentions...)
+	}
+	emails := make(map[string]string)
+	if len(userIDs) > 0 {
+		var users []models.User
+		if err := db.DB.Select("id, email").Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+			return nil, err
+		}
+		for _, u := range users {
+			emails[u.ID] = u.Email
+		}
+	}
+
+	views := make([]NoteView, len(notes))
+	for i, n := range notes {
+		mentions := make([]MentionView, len(n.Mentions))
+		for j, id := range n.Mentions {
+			mentions[j] = MentionView{UserID: id, Email: emails[id]}
+		}
+		views[i] = NoteView{
+			ID:            n.ID,
+			CandidateID:   n.CandidateID,
+			ApplicationID: n.ApplicationID,
+			AuthorID:      n.AuthorID,
+			AuthorEmail:   emails[n.AuthorID],
+			Body:          n.Body,
+			Mentions:      mentions,
+			CreatedAt:     n.CreatedAt,
+			UpdatedAt:     n.UpdatedAt,
+		}
+	}
+	return views, nil
+}
+
+// enqueueMentions queues an email to every user in mentions who isn't in
+// already and isn't the author.
+func (s *CandidateService) enqueueMentions(tx *gorm.DB, note *models.CandidateNote, already []string) error {
+	skip := map[string]bool{note.AuthorID: true}
+	for _, id := range already {
+		skip[id] = true
+	}
+	for _, id := range note.Mentions {
+		if skip[id] {
+			continue
+		}
+		if err := s.queue.Enqueue(tx, note.OrganizationID, TaskNotifyMention, mentionTask{NoteID: note.ID, UserID: id}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateNote adds a note to a candidate, optionally about one of their
+// applications, and emails the users it mentions.
+func (s *CandidateService) CreateNote(caller Caller, candidateID string, req CreateNoteRequest) (gin.H, int) {
+	candidate, err := loadCandidate(caller.OrganizationID, candidateID)
+	if errors.Is(err, ErrNotFound) {
+		return gin.H{"error": "Candidate not found"}, http.StatusNotFound
+	}
+	if err != nil {
+		return gin.H{"error": "Failed to load candidate"}, http.StatusInternalServerError
+	}
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		return gin.H{"error": "body can't be empty"}, http.StatusBadRequest
+	}
+
+	note := models.CandidateNote{
+		OrganizationID: caller.OrganizationID,
+		CandidateID:    candidate.ID,
+		AuthorID:       caller.UserID,
+		Body:           body,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+	if req.ApplicationID != "" {
+		application, err := loadApplication(caller.OrganizationID, req.ApplicationID)
+		if errors.Is(err, ErrNotFound) || (err == nil && application.CandidateID != candidate.ID) {
+			return gin.H{"error": "Application not found"}, http.StatusNotFound
+		}
+		if err != nil {
+			return gin.H{"error": "Failed to load application"}, http.StatusInternalServerError
+		}
+		note.ApplicationID = &application.ID
+	}
+	if note.Mentions, err = resolveMentions(caller.OrganizationID, body); err != nil {
+		return gin.H{"error": "Failed to resolve mentions"}, http.StatusInternalServerError
+	}
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&note).Error; err != nil {
+			return err
+		}
+		return s.enqueueMentions(tx, &note, nil)
+	})
+	if err != nil {
+		return gin.H{"error": "Failed to save note"}, http.StatusInternalServerError
+	}
+	views, err := noteViews([]models.CandidateNote{note})
+	if err != nil {
+		return gin.H{"error": "Failed to load note"}, http.StatusInternalServerError
+	}
+	return gin.H{"message": "Note added", "note": views[0]}, http.StatusCreated
+}
+
+// ListNotes returns a candidate's notes, newest first, optionally only
+// those about one application.
+func (s *CandidateService) ListNotes(caller Caller, candidateID string, req ListNotesRequest) (gin.H, int) {
+	candidate, err := loadCandidate(caller.OrganizationID, candidateID)
+	if errors.Is(err, ErrNotFound) {
+		return gin.H{"error": "Candidate not found"}, http.StatusNotFound
+	}
+	if err != nil {
+		return gin.H{"error": "Failed to load candidate"}, http.StatusInternalServerError
+	}
+
+	query := db.DB.Scopes(ForOrganization(caller.OrganizationID)).Where("candidate_id = ?", candidate.ID)
+	if req.ApplicationID != "" {
+		query = query.Where("application_id = ?", req.ApplicationID)
+	}
+	var notes []models.CandidateNote
+	if err := query.Order("created_at DESC").Find(&notes).Error; err != nil {
+		return gin.H{"error": "Failed to load notes"}, http.StatusInternalServerError
+	}
+	views, err := noteViews(notes)
+	if err != nil {
+		return gin.H{"error": "Failed to load notes"}, http.StatusInternalServerError
+	}
+	return gin.H{"notes": views}, http.StatusOK
+}
+
+// loadNote returns a note on one of orgID's candidates, checking that the
+// caller wrote it.
+func loadNote(caller Caller, candidateID, noteID string) (*models.CandidateNote, error) {
+	var note models.CandidateNote
+	if err := db.DB.Scopes(ForOrganization(caller.OrganizationID)).
+		Where("id = ? AND candidate_id = ?", noteID, candidateID).First(&note).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if note.AuthorID != caller.UserID {
+		return nil, ErrNotNoteAuthor
+	}
+	return &note, nil
+}
+
+func noteError(err error) (gin.H, int) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return gin.H{"error": "Note not found"}, http.StatusNotFound
+	case errors.Is(err, ErrNotNoteAuthor):
+		return gin.H{"error": err.Error()}, http.StatusForbidden
+	}
+	return gin.H{"error": "Failed to load note"}, http.StatusInternalServerError
+}
+
+// UpdateNote replaces the text of the caller's own note. Only users
+// mentioned for the first time are emailed.
+func (s *CandidateService) UpdateNote(caller Caller, candidateID, noteID string, req UpdateNoteRequest) (gin.H, int) {
+	note, err := loadNote(caller, candidateID, noteID)
+	if err != nil {
+		return noteError(err)
+	}
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		return gin.H{"error": "body can't be empty"}, http.StatusBadRequest
+	}
+	mentions, err := resolveMentions(caller.OrganizationID, body)
+	if err != nil {
+		return gin.H{"error": "Failed to resolve mentions"}, http.StatusInternalServerError
+	}
+
+	previous := note.Mentions
+	note.Body, note.Mentions, note.UpdatedAt = body, mentions, time.Now()
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(note).Updates(map[string]interface{}{
+			"body":       note.Body,
+			"mentions":   note.Mentions,
+			"updated_at": note.UpdatedAt,
+		}).Error; err != nil {
+			return err
+		}
+		return s.enqueueMentions(tx, note, previous)
+	})
+	if err != nil {
+		return gin.H{"error": "Failed to save note"}, http.StatusInternalServerError
+	}
+	views, err := noteViews([]models.CandidateNote{*note})
+	if err != nil {
+		return gin.H{"error": "Failed to load note"}, http.StatusInternalServerError
+	}
+	return gin.H{"message": "Note updated", "note": views[0]}, http.StatusOK
+}
+
+// DeleteNote deletes the caller's own note.
+func (s *CandidateService) DeleteNote(caller Caller, candidateID, noteID string) (gin.H, int) {
+	note, err := loadNote(caller, candidateID, noteID)
+	if err != nil {
+		return noteError(err)
+	}
+	if err := db.DB.Delete(note).Error; err != nil {
+		return gin.H{"error": "Failed to delete note"}, http.StatusInternalServerError
+	}
+	return gin.H{"message": "Note deleted"}, http.StatusOK
+}
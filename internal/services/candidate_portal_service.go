@@ -0,0 +1,231 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/resumelens/authservice/internal/db"
+	"github.com/resumelens/authservice/internal/models"
+	"gorm.io/gorm"
+)
+
+// CandidatePortalService backs the magic-link pages where a candidate manages
+// their own application. Possession of the token is the only credential, so
+// the same link works from any device until it expires.
+type CandidatePortalService struct {
+	applications *JobApplicationService
+}
+
+func NewCandidatePortalService(applications *JobApplicationService) *CandidatePortalService {
+	return &CandidatePortalService{applications: applications}
+}
+
+var errMagicLinkExpired = errors.New("magic link expired")
+
+type portalContext struct {
+	application models.JobApplication
+	candidate   models.Candidate
+	job         models.Job
+}
+
+func (s *CandidatePortalService) resolve(token string) (*portalContext, error) {
+	if token == "" {
+		return nil, ErrNotFound
+	}
+
+	var pc portalContext
+	if err := db.DB.Where("magic_link_token = ?", token).First(&pc.application).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if time.Now().After(pc.application.MagicLinkExpiresAt) {
+		return nil, errMagicLinkExpired
+	}
+	if err := db.DB.Where("id = ?", pc.application.CandidateID).First(&pc.candidate).Error; err != nil {
+		return nil, err
+	}
+	if err := db.DB.Unscoped().Where("id = ?", pc.application.JobID).First(&pc.job).Error; err != nil {
+		return nil, err
+	}
+	return &pc, nil
+}
+
+func portalError(err error) (gin.H, int) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return gin.H{"error": "Application not found"}, http.StatusNotFound
+	case errors.Is(err, errMagicLinkExpired):
+		return gin.H{"error": "This link has expired"}, http.StatusGone
+	default:
+		return gin.H{"error": "Failed to load application"}, http.StatusInternalServerError
+	}
+}
+
+// GetApplication returns what a candidate may see about their application.
+// Recruiter-only data such as scores and parsed resumes is left out.
+func (s *CandidatePortalService) GetApplication(token string) (gin.H, int) {
+	pc, err := s.resolve(token)
+	if err != nil {
+		return portalError(err)
+	}
+
+	stageName := pc.application.Status
+	if pipeline, err := pipelineFor(db.DB, pc.job.OrganizationID, pc.job.ID); err == nil {
+		if stage := findStage(pipeline, pc.application.Status); stage != nil {
+			stageName = stage.Name
+		}
+	}
+
+	return gin.H{
+		"application": gin.H{
+			"id":                pc.application.ID,
+			"status":            pc.application.Status,
+			"status_name":       stageName,
+			"has_resume":        pc.application.ResumeGCSPath != "",
+			"has_cover_letter":  pc.application.CoverLetterGCSPath != "",
+			"resume_scan":       pc.application.ResumeScanStatus,
+			"cover_letter_scan": pc.application.CoverLetterScanStatus,
+			"submitted_at":      pc.application.CreatedAt,
+			"link_expires_at":   pc.application.MagicLinkExpiresAt,
+		},
+		"job": gin.H{
+			"id":    pc.job.ID,
+			"title": pc.job.Title,
+		},
+		"candidate": gin.H{
+			"full_name": pc.candidate.FullName,
+			"email":     pc.candidate.Email,
+			"phone":     pc.candidate.Phone,
+			"linkedin":  pc.candidate.LinkedIn,
+			"github":    pc.candidate.GitHub,
+			"location":  pc.candidate.Location,
+		},
+	}, http.StatusOK
+}
+
+// UpdateContactRequest is a partial update of the candidate's own contact
+// details. Email is the candidate's identity within the organization and
+// cannot be changed here.
+type UpdateContactRequest struct {
+	FullName *string `json:"full_name" binding:"omitempty,min=1,singleline"`
+	Phone    *string `json:"phone"`
+	LinkedIn *string `json:"linkedin"`
+	GitHub   *string `json:"github"`
+	Location *string `json:"location"`
+}
+
+func (s *CandidatePortalService) UpdateContact(token string, req UpdateContactRequest) (gin.H, int) {
+	pc, err := s.resolve(token)
+	if err != nil {
+		return portalError(err)
+	}
+
+	updates := make(map[string]interface{})
+	if req.FullName != nil {
+		updates["full_name"] = strings.TrimSpace(*req.FullName)
+	}
+	if req.Phone != nil {
+		updates["phone"] = *req.Phone
+	}
+	if req.LinkedIn != nil {
+		updates["linked_in"] = *req.LinkedIn
+	}
+	if req.GitHub != nil {
+		updates["git_hub"] = *req.GitHub
+	}
+	if req.Location != nil {
+		updates["location"] = *req.Location
+	}
+	if len(updates) == 0 {
+		return gin.H{"message": "Nothing to update"}, http.StatusOK
+	}
+	updates["updated_at"] = time.Now()
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&pc.candidate).Updates(updates).Error; err != nil {
+			return err
+		}
+		return s.applications.queue.Enqueue(tx, pc.job.OrganizationID, TaskDetectDuplicates, candidateTask{CandidateID: pc.candidate.ID})
+	})
+	if err != nil {
+		return gin.H{"error": "Failed to update contact details"}, http.StatusInternalServerError
+	}
+
+	return gin.H{"message": "Contact details updated"}, http.StatusOK
+}
+
+// replaceDocument swaps the candidate's resume or cover letter while the
+// application is still active.
+func (s *CandidatePortalService) replaceDocument(ctx context.Context, token, kind string, upload UploadedFile) (gin.H, int) {
+	pc, err := s.resolve(token)
+	if err != nil {
+		return portalError(err)
+	}
+
+	if s.isClosed(pc) {
+		return gin.H{"error": "This application is closed and can no longer be changed"}, http.StatusConflict
+	}
+	if _, err := s.applications.checkDocument(upload.File, upload.Header); err != nil {
+		return rejectedUpload(err, "file")
+	}
+
+	req := UploadDocumentRequest{JobID: pc.job.ID, CandidateID: pc.candidate.ID}
+	if _, err := s.applications.saveDocument(ctx, &pc.job, req, nil, kind, upload.File, upload.Header); err != nil {
+		log.Printf("Candidate portal upload for application %s failed: %v", pc.application.ID, err)
+		return gin.H{"error": "Failed to store file"}, http.StatusInternalServerError
+	}
+
+	return gin.H{"message": "File uploaded successfully"}, http.StatusOK
+}
+
+func (s *CandidatePortalService) ReplaceResume(ctx context.Context, token string, upload UploadedFile) (gin.H, int) {
+	return s.replaceDocument(ctx, token, documentResume, upload)
+}
+
+func (s *CandidatePortalService) ReplaceCoverLetter(ctx context.Context, token string, upload UploadedFile) (gin.H, int) {
+	return s.replaceDocument(ctx, token, documentCoverLetter, upload)
+}
+
+type WithdrawRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (s *CandidatePortalService) Withdraw(token string, req WithdrawRequest) (gin.H, int) {
+	pc, err := s.resolve(token)
+	if err != nil {
+		return portalError(err)
+	}
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		_, err := moveApplicationStage(tx, pc.job.OrganizationID, pc.application.ID, StageWithdrawn, nil, req.Reason, true)
+		return err
+	})
+	if errors.Is(err, ErrStageTransitionBlocked) {
+		return gin.H{"error": "This application can no longer be withdrawn"}, http.StatusConflict
+	}
+	if err != nil {
+		return gin.H{"error": "Failed to withdraw application"}, http.StatusInternalServerError
+	}
+
+	return gin.H{"message": "Your application has been withdrawn"}, http.StatusOK
+}
+
+// isClosed reports whether the application has reached a terminal stage.
+func (s *CandidatePortalService) isClosed(pc *portalContext) bool {
+	if pc.application.Status == StageWithdrawn {
+		return true
+	}
+	pipeline, err := pipelineFor(db.DB, pc.job.OrganizationID, pc.job.ID)
+	if err != nil {
+		return false
+	}
+	stage := findStage(pipeline, pc.application.Status)
+	return stage != nil && stage.IsTerminal
+}
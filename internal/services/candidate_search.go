@@ -0,0 +1,366 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"html"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/resumelens/authservice/internal/db"
+	"github.com/resumelens/authservice/internal/models"
+	"github.com/resumelens/authservice/internal/vector"
+)
+
+const (
+	defaultCandidateSearchLimit = 20
+	// searchPoolSize caps how many embeddings and keyword matches are
+	// considered before ranking.
+	searchPoolSize = 500
+	// snippetRadius is how many characters of context surround a match.
+	snippetRadius   = 80
+	maxSnippets     = 3
+	maxSnippetInput = 1 << 20
+
+	similarityWeight = 0.6
+	keywordWeight    = 0.4
+)
+
+type SearchCandidatesRequest struct {
+	Query      string   `form:"q"`
+	Skills     []string `form:"skills"`
+	Location   string   `form:"location"`
+	Experience string   `form:"experience"`
+	Limit      int      `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+type CandidateHighlight struct {
+	Field   string `json:"field"`
+	Snippet string `json:"snippet"` // HTML-escaped, with matched terms wrapped in <em></em>
+}
+
+type CandidateSearchResult struct {
+	CandidateID  string               `json:"candidate_id"`
+	FullName     string               `json:"full_name"`
+	Email        string               `json:"email"`
+	Location     string               `json:"location"`
+	Skills       string               `json:"skills"`
+	Score        float64              `json:"score"`
+	Similarity   float32              `json:"similarity"`
+	KeywordScore float64              `json:"keyword_score"`
+	Applications []string             `json:"application_ids"`
+	Highlights   []CandidateHighlight `json:"highlights"`
+}
+
+// SearchCandidates finds candidates across all of the caller's
+// organization's jobs. Skills, location and experience are hard filters on
+// the candidate record; the free-text query ranks what is left by resume
+// similarity combined with how many query terms the candidate's details
+// contain.
+func (s *SearchService) SearchCandidates(ctx context.Context, caller Caller, req SearchCandidatesRequest) (gin.H, int) {
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultCandidateSearchLimit
+	}
+	terms := vector.Tokenize(req.Query)
+
+	query := db.DB.Model(&models.Candidate{}).Where("candidates.organization_id = ?", caller.OrganizationID)
+	for _, skill := range req.Skills {
+		if skill = strings.TrimSpace(skill); skill != "" {
+			query = query.Where("candidates.skills ILIKE ?", likePattern(skill))
+		}
+	}
+	if req.Location != "" {
+		query = query.Where("candidates.location ILIKE ?", likePattern(req.Location))
+	}
+	if req.Experience != "" {
+		query = query.Where("candidates.experience ILIKE ?", likePattern(req.Experience))
+	}
+
+	// Best resume similarity per candidate; a candidate who applied to
+	// several jobs has several embeddings.
+	similarity := make(map[string]float32)
+	if len(terms) > 0 {
+		vectors, err := s.embedder.Embed(ctx, []string{req.Query})
+		if err != nil {
+			return gin.H{"error": "Failed to embed query"}, http.StatusInternalServerError
+		}
+		matches, err := s.index.Query(ctx, vectors[0], searchPoolSize, vector.Filter{
+			OrganizationID: caller.OrganizationID,
+			Model:          s.embedder.Model(),
+		})
+		if err != nil {
+			return gin.H{"error": "Failed to search resumes"}, http.StatusInternalServerError
+		}
+		for _, m := range matches {
+			if m.Score > similarity[m.CandidateID] {
+				similarity[m.CandidateID] = m.Score
+			}
+		}
+
+		// Only candidates that match the query somehow are ranked.
+		candidateIDs := make([]string, 0, len(similarity))
+		for id := range similarity {
+			candidateIDs = append(candidateIDs, id)
+		}
+		clauses := []string{"candidates.id IN ?"}
+		args := []interface{}{candidateIDs}
+		for _, term := range terms {
+			clauses = append(clauses, candidateSearchText+" ILIKE ?")
+			args = append(args, likePattern(term))
+		}
+		if len(candidateIDs) == 0 {
+			clauses, args = clauses[1:], args[1:]
+		}
+		query = query.Where("("+strings.Join(clauses, " OR ")+")", args...)
+	}
+
+	var candidates []models.Candidate
+	if err := query.Order("candidates.updated_at DESC").Limit(searchPoolSize).Find(&candidates).Error; err != nil {
+		return gin.H{"error": "Failed to search candidates"}, http.StatusInternalServerError
+	}
+
+	var maxSimilarity float32
+	for _, v := range similarity {
+		if v > maxSimilarity {
+			maxSimilarity = v
+		}
+	}
+
+	results := make([]CandidateSearchResult, 0, len(candidates))
+	for _, c := range candidates {
+		result := CandidateSearchResult{
+			CandidateID:  c.ID,
+			FullName:     c.FullName,
+			Email:        c.Email,
+			Location:     c.Location,
+			Skills:       c.Skills,
+			Similarity:   similarity[c.ID],
+			KeywordScore: keywordCoverage(terms, candidateText(&c)),
+			Applications: []string{},
+			Highlights:   []CandidateHighlight{},
+		}
+		if len(terms) > 0 {
+			// Hashed embeddings give small absolute similarities, so they
+			// are scaled relative to the best match.
+			var relative float64
+			if maxSimilarity > 0 {
+				relative = float64(result.Similarity / maxSimilarity)
+			}
+			result.Score = round2(similarityWeight*relative + keywordWeight*result.KeywordScore)
+		}
+		results = append(results, result)
+	}
+	if len(terms) > 0 {
+		sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	}
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	if err := s.attachHighlights(ctx, results, candidates, terms); err != nil {
+		return gin.H{"error": "Failed to load resumes"}, http.StatusInternalServerError
+	}
+
+	return gin.H{"candidates": results, "count": len(results)}, http.StatusOK
+}
+
+// candidateSearchText is the SQL expression keyword terms are matched
+// against; candidateText is its Go counterpart.
+const candidateSearchText = "concat_ws(' ', candidates.full_name, candidates.skills, candidates.location, candidates.experience, candidates.education)"
+
+func candidateText(c *models.Candidate) string {
+	return strings.Join([]string{c.FullName, c.Skills, c.Location, c.Experience, c.Education}, " ")
+}
+
+// keywordCoverage is the share of terms that appear in text.
+func keywordCoverage(terms []string, text string) float64 {
+	if len(terms) == 0 {
+		return 0
+	}
+	text = strings.ToLower(text)
+	found := 0
+	for _, term := range terms {
+		if strings.Contains(text, term) {
+			found++
+		}
+	}
+	return round2(float64(found) / float64(len(terms)))
+}
+
+// attachHighlights fills in each result's applications and the snippets of
+// its candidate fields and latest resume text that contain query terms.
+func (s *SearchService) attachHighlights(ctx context.Context, results []CandidateSearchResult, candidates []models.Candidate, terms []string) error {
+	if len(results) == 0 {
+		return nil
+	}
+	ids := make([]string, len(results))
+	for i, r := range results {
+		ids[i] = r.CandidateID
+	}
+
+	var applications []models.JobApplication
+	if err := db.DB.Where("candidate_id IN ?", ids).Order("created_at DESC").Find(&applications).Error; err != nil {
+		return err
+	}
+	textPaths := make(map[string]string)
+	appIDs := make(map[string][]string)
+	for _, a := range applications {
+		appIDs[a.CandidateID] = append(appIDs[a.CandidateID], a.ID)
+		if _, ok := textPaths[a.CandidateID]; ok || a.ParsedResume == nil {
+			continue
+		}
+		var parsed ParsedResume
+		if json.Unmarshal([]byte(*a.ParsedResume), &parsed) == nil && parsed.TextPath != "" {
+			textPaths[a.CandidateID] = parsed.TextPath
+		}
+	}
+
+	byID := make(map[string]*models.Candidate, len(candidates))
+	for i := range candidates {
+		byID[candidates[i].ID] = &candidates[i]
+	}
+	highlighter := newHighlighter(terms)
+
+	for i := range results {
+		r := &results[i]
+		if apps := appIDs[r.CandidateID]; apps != nil {
+			r.Applications = apps
+		}
+		if highlighter == nil {
+			continue
+		}
+
+		c := byID[r.CandidateID]
+		for _, field := range []struct{ name, value string }{
+			{"skills", c.Skills},
+			{"location", c.Location},
+			{"experience", c.Experience},
+		} {
+			if snippets := highlighter.snippets(field.value, 1); len(snippets) > 0 {
+				r.Highlights = append(r.Highlights, CandidateHighlight{Field: field.name, Snippet: snippets[0]})
+			}
+		}
+
+		textPath, ok := textPaths[r.CandidateID]
+		if !ok {
+			continue
+		}
+		text, err := s.readText(ctx, textPath)
+		if err != nil {
+			// The text is derived data; a missing object only costs the
+			// snippets.
+			continue
+		}
+		for _, snippet := range highlighter.snippets(text, maxSnippets) {
+			r.Highlights = append(r.Highlights, CandidateHighlight{Field: "resume", Snippet: snippet})
+		}
+	}
+	return nil
+}
+
+func (s *SearchService) readText(ctx context.Context, name string) (string, error) {
+	reader, err := s.store.Get(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(io.LimitReader(reader, maxSnippetInput))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+type highlighter struct {
+	pattern *regexp.Regexp
+}
+
+func newHighlighter(terms []string) *highlighter {
+	if len(terms) == 0 {
+		return nil
+	}
+	quoted := make([]string, len(terms))
+	for i, t := range terms {
+		quoted[i] = regexp.QuoteMeta(t)
+	}
+	return &highlighter{pattern: regexp.MustCompile(`(?i)` + strings.Join(quoted, "|"))}
+}
+
+// snippets returns up to n non-overlapping excerpts of text around term
+// matches as HTML: the text is escaped and every match inside an excerpt is
+// wrapped in <em></em>.
+func (h *highlighter) snippets(text string, n int) []string {
+	var out []string
+	end := 0
+	for _, loc := range h.pattern.FindAllStringIndex(text, -1) {
+		if len(out) == n {
+			break
+		}
+		if loc[0] < end {
+			continue
+		}
+		from, to := snippetBounds(text, loc[0]-snippetRadius, loc[1]+snippetRadius)
+		excerpt := h.mark(text[from:to])
+		excerpt = strings.Join(strings.Fields(excerpt), " ")
+		if from > 0 {
+			excerpt = "…" + excerpt
+		}
+		if to < len(text) {
+			excerpt += "…"
+		}
+		out = append(out, excerpt)
+		end = to
+	}
+	return out
+}
+
+// mark escapes s for HTML and wraps every match in <em></em>. Matching runs
+// on the raw text so terms never match inside an entity.
+func (h *highlighter) mark(s string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range h.pattern.FindAllStringIndex(s, -1) {
+		b.WriteString(html.EscapeString(s[last:loc[0]]))
+		b.WriteString("<em>")
+		b.WriteString(html.EscapeString(s[loc[0]:loc[1]]))
+		b.WriteString("</em>")
+		last = loc[1]
+	}
+	b.WriteString(html.EscapeString(s[last:]))
+	return b.String()
+}
+
+// snippetBounds clamps [from, to) to text and moves both ends to the
+// nearest word boundary so snippets don't start or end mid-word.
+func snippetBounds(text string, from, to int) (int, int) {
+	if from < 0 {
+		from = 0
+	}
+	if to > len(text) {
+		to = len(text)
+	}
+	for from > 0 && text[from-1] != ' ' && text[from-1] != '\n' {
+		from--
+	}
+	for to < len(text) && text[to] != ' ' && text[to] != '\n' {
+		to++
+	}
+	return from, to
+}
+
+// likePattern turns user input into an ILIKE pattern that matches it
+// anywhere, escaping LIKE wildcards.
+func likePattern(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(strings.TrimSpace(s))
+	return "%" + s + "%"
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
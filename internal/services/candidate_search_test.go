@@ -0,0 +1,48 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHighlighterSnippets(t *testing.T) {
+	tests := []struct {
+		name  string
+		terms []string
+		text  string
+		want  []string
+	}{
+		{
+			name:  "matches are marked",
+			terms: []string{"go", "postgres"},
+			text:  "Senior Go engineer with Postgres",
+			want:  []string{"Senior <em>Go</em> engineer with <em>Postgres</em>"},
+		},
+		{
+			name:  "markup in the text is escaped",
+			terms: []string{"go"},
+			text:  `<img src=x onerror="alert(1)"> Go & <script>`,
+			want:  []string{`&lt;img src=x onerror=&#34;alert(1)&#34;&gt; <em>Go</em> &amp; &lt;script&gt;`},
+		},
+		{
+			name:  "markup in a match is escaped",
+			terms: []string{"<b>"},
+			text:  "bold <b> tag",
+			want:  []string{"bold <em>&lt;b&gt;</em> tag"},
+		},
+		{
+			name:  "terms don't match inside entities",
+			terms: []string{"amp"},
+			text:  "R&D champion",
+			want:  []string{"R&amp;D ch<em>amp</em>ion"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newHighlighter(tt.terms).snippets(tt.text, 3)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("snippets = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
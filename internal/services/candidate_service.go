@@ -0,0 +1,412 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+	"github.com/resumelens/authservice/internal/db"
+	"github.com/resumelens/authservice/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultCandidatePageSize = 20
+	maxCandidatePageSize     = 100
+
+	maxTags      = 20
+	maxTagLength = 50
+)
+
+var (
+	ErrInvalidTags = errors.New("tags must be at most 50 characters, and a candidate can have at most 20")
+	// ErrCandidateExists is returned when saving a candidate would give the
+	// organization two candidates with the same email address.
+	ErrCandidateExists = errors.New("a candidate with this email already exists")
+)
+
+// CandidateView is how candidates are returned by the candidate API.
+type CandidateView struct {
+	ID         string    `json:"id"`
+	FullName   string    `json:"full_name"`
+	Email      string    `json:"email"`
+	Phone      string    `json:"phone"`
+	LinkedIn   string    `json:"linkedin"`
+	GitHub     string    `json:"github"`
+	Location   string    `json:"location"`
+	Experience string    `json:"experience"`
+	Education  string    `json:"education"`
+	Skills     string    `json:"skills"`
+	Tags       []string  `json:"tags"`
+	AddedBy    *string   `json:"added_by"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func candidateView(c *models.Candidate) CandidateView {
+	tags := []string(c.Tags)
+	if tags == nil {
+		tags = []string{}
+	}
+	return CandidateView{
+		ID:         c.ID,
+		FullName:   c.FullName,
+		Email:      c.Email,
+		Phone:      c.Phone,
+		LinkedIn:   c.LinkedIn,
+		GitHub:     c.GitHub,
+		Location:   c.Location,
+		Experience: c.Experience,
+		Education:  c.Education,
+		Skills:     c.Skills,
+		Tags:       tags,
+		AddedBy:    c.UserID,
+		CreatedAt:  c.CreatedAt,
+		UpdatedAt:  c.UpdatedAt,
+	}
+}
+
+// normalizeTags trims and lowercases tags, dropping empty and repeated ones.
+func normalizeTags(tags []string) (pq.StringArray, error) {
+	result := pq.StringArray{}
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.Join(strings.Fields(tag), " "))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len([]rune(tag)) > maxTagLength {
+			return nil, ErrInvalidTags
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	if len(result) > maxTags {
+		return nil, ErrInvalidTags
+	}
+	return result, nil
+}
+
+// loadCandidate returns one of orgID's candidates, or ErrNotFound.
+func loadCandidate(orgID, candidateID string) (*models.Candidate, error) {
+	var candidate models.Candidate
+	if err := db.DB.Scopes(ForOrganization(orgID)).Where("id = ?", candidateID).First(&candidate).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &candidate, nil
+}
+
+// candidateWithEmail returns the id of another of orgID's candidates with
+// the same email address, ignoring case, or "".
+func candidateWithEmail(orgID, email, exceptID string) (string, error) {
+	var ids []string
+	query := db.DB.Model(&models.Candidate{}).Scopes(ForOrganization(orgID)).Where("lower(email) = ?", strings.ToLower(email))
+	if exceptID != "" {
+		query = query.Where("id <> ?", exceptID)
+	}
+	if err := query.Limit(1).Pluck("id", &ids).Error; err != nil || len(ids) == 0 {
+		return "", err
+	}
+	return ids[0], nil
+}
+
+// candidateSaveError turns the unique violation raised when a concurrent
+// request saved a candidate with the same email first into
+// ErrCandidateExists. candidateWithEmail only catches the common case; the
+// idx_candidates_org_email index catches the rest.
+func candidateSaveError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrCandidateExists
+	}
+	return err
+}
+
+type CreateCandidateRequest struct {
+	FullName   string   `json:"full_name" binding:"required,singleline"`
+	Email      string   `json:"email" binding:"required,email"`
+	Phone      string   `json:"phone"`
+	LinkedIn   string   `json:"linkedin"`
+	GitHub     string   `json:"github"`
+	Location   string   `json:"location"`
+	Experience string   `json:"experience"`
+	Education  string   `json:"education"`
+	Skills     string   `json:"skills"`
+	Tags       []string `json:"tags"`
+}
+
+// CreateCandidate adds a candidate to the caller's organization without an
+// application. Emails are unique per organization.
+func (s *CandidateService) CreateCandidate(caller Caller, req CreateCandidateRequest) (gin.H, int) {
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		return gin.H{"error": err.Error()}, http.StatusBadRequest
+	}
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	fullName := strings.TrimSpace(req.FullName)
+	if fullName == "" {
+		return gin.H{"error": "full_name is required"}, http.StatusBadRequest
+	}
+
+	existing, err := candidateWithEmail(caller.OrganizationID, email, "")
+	if err != nil {
+		return gin.H{"error": "Failed to create candidate"}, http.StatusInternalServerError
+	}
+	if existing != "" {
+		return gin.H{"error": "A candidate with this email already exists", "candidate_id": existing}, http.StatusConflict
+	}
+
+	now := time.Now()
+	candidate := models.Candidate{
+		ID:             uuid.NewString(),
+		OrganizationID: caller.OrganizationID,
+		UserID:         &caller.UserID,
+		FullName:       fullName,
+		Email:          email,
+		Phone:          req.Phone,
+		LinkedIn:       req.LinkedIn,
+		GitHub:         req.GitHub,
+		Location:       req.Location,
+		Experience:     req.Experience,
+		Education:      req.Education,
+		Skills:         req.Skills,
+		Tags:           tags,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&candidate).Error; err != nil {
+			return candidateSaveError(err)
+		}
+		return s.queue.Enqueue(tx, caller.OrganizationID, TaskDetectDuplicates, candidateTask{CandidateID: candidate.ID})
+	})
+	if errors.Is(err, ErrCandidateExists) {
+		return gin.H{"error": "A candidate with this email already exists"}, http.StatusConflict
+	}
+	if err != nil {
+		return gin.H{"error": "Failed to create candidate"}, http.StatusInternalServerError
+	}
+	return gin.H{"message": "Candidate created", "candidate": candidateView(&candidate)}, http.StatusCreated
+}
+
+// CandidateApplication summarizes one of a candidate's applications.
+type CandidateApplication struct {
+	ID            string    `json:"id"`
+	JobID         string    `json:"job_id"`
+	JobTitle      string    `json:"job_title"`
+	Status        string    `json:"status"`
+	AIScore       float64   `json:"ai_score"`
+	AverageRating *float64  `json:"average_rating"`
+	RatingCount   int       `json:"rating_count"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// GetCandidate returns a candidate with their applications to the
+// organization's jobs and the interviewers' average rating of each.
+func (s *CandidateService) GetCandidate(caller Caller, candidateID string) (gin.H, int) {
+	candidate, err := loadCandidate(caller.OrganizationID, candidateID)
+	if errors.Is(err, ErrNotFound) {
+		return gin.H{"error": "Candidate not found"}, http.StatusNotFound
+	}
+	if err != nil {
+		return gin.H{"error": "Failed to load candidate"}, http.StatusInternalServerError
+	}
+
+	applications := []CandidateApplication{}
+	if err := db.DB.Model(&models.JobApplication{}).Scopes(ApplicationsForOrganization(caller.OrganizationID)).
+		Select("job_applications.id, job_applications.job_id, jobs.title AS job_title, job_applications.status, job_applications.ai_score, job_applications.created_at").
+		Where("job_applications.candidate_id = ?", candidate.ID).
+		Order("job_applications.created_at DESC").
+		Scan(&applications).Error; err != nil {
+		return gin.H{"error": "Failed to load applications"}, http.StatusInternalServerError
+	}
+
+	ids := make([]string, len(applications))
+	for i, a := range applications {
+		ids[i] = a.ID
+	}
+	summaries, err := ratingSummaries(ids)
+	if err != nil {
+		return gin.H{"error": "Failed to load ratings"}, http.StatusInternalServerError
+	}
+	for i := range applications {
+		if summary, ok := summaries[applications[i].ID]; ok {
+			average := round2(summary.Average)
+			applications[i].AverageRating = &average
+			applications[i].RatingCount = summary.Count
+		}
+	}
+
+	return gin.H{"candidate": candidateView(candidate), "applications": applications}, http.StatusOK
+}
+
+type ListCandidatesRequest struct {
+	Query  string   `form:"q"`
+	Tags   []string `form:"tag"`
+	Limit  int      `form:"limit" binding:"omitempty,min=1"`
+	Cursor string   `form:"cursor"`
+}
+
+// ListCandidates pages through the organization's candidates, newest first.
+// q matches name or email; every tag given must be present.
+func (s *CandidateService) ListCandidates(caller Caller, req ListCandidatesRequest) (gin.H, int) {
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultCandidatePageSize
+	}
+	if limit > maxCandidatePageSize {
+		limit = maxCandidatePageSize
+	}
+
+	query := db.DB.Model(&models.Candidate{}).Scopes(ForOrganization(caller.OrganizationID))
+	if q := strings.TrimSpace(req.Query); q != "" {
+		query = query.Where("(candidates.full_name ILIKE ? OR candidates.email ILIKE ?)", likePattern(q), likePattern(q))
+	}
+	if len(req.Tags) > 0 {
+		tags, err := normalizeTags(req.Tags)
+		if err != nil {
+			return gin.H{"error": err.Error()}, http.StatusBadRequest
+		}
+		query = query.Where("candidates.tags @> ?", tags)
+	}
+	if req.Cursor != "" {
+		var after time.Time
+		afterID, err := decodeCursor(req.Cursor, "created_at", "desc", &after)
+		if err != nil {
+			return gin.H{"error": "Invalid cursor"}, http.StatusBadRequest
+		}
+		query = query.Where("(candidates.created_at, candidates.id) < (?, ?)", after, afterID)
+	}
+
+	var candidates []models.Candidate
+	if err := query.Order("candidates.created_at DESC, candidates.id DESC").Limit(limit + 1).Find(&candidates).Error; err != nil {
+		return gin.H{"error": "Failed to list candidates"}, http.StatusInternalServerError
+	}
+
+	var nextCursor string
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+		last := candidates[len(candidates)-1]
+		cursor, err := encodeCursor("created_at", "desc", last.CreatedAt, last.ID)
+		if err != nil {
+			return gin.H{"error": "Failed to build cursor"}, http.StatusInternalServerError
+		}
+		nextCursor = cursor
+	}
+
+	views := make([]CandidateView, len(candidates))
+	for i := range candidates {
+		views[i] = candidateView(&candidates[i])
+	}
+	return gin.H{"candidates": views, "next_cursor": nextCursor}, http.StatusOK
+}
+
+// UpdateCandidateRequest changes only the fields present. Tags replaces
+// the whole set.
+type UpdateCandidateRequest struct {
+	FullName   *string   `json:"full_name" binding:"omitempty,singleline"`
+	Email      *string   `json:"email" binding:"omitempty,email"`
+	Phone      *string   `json:"phone"`
+	LinkedIn   *string   `json:"linkedin"`
+	GitHub     *string   `json:"github"`
+	Location   *string   `json:"location"`
+	Experience *string   `json:"experience"`
+	Education  *string   `json:"education"`
+	Skills     *string   `json:"skills"`
+	Tags       *[]string `json:"tags"`
+}
+
+func (s *CandidateService) UpdateCandidate(caller Caller, candidateID string, req UpdateCandidateRequest) (gin.H, int) {
+	candidate, err := loadCandidate(caller.OrganizationID, candidateID)
+	if errors.Is(err, ErrNotFound) {
+		return gin.H{"error": "Candidate not found"}, http.StatusNotFound
+	}
+	if err != nil {
+		return gin.H{"error": "Failed to load candidate"}, http.StatusInternalServerError
+	}
+
+	updates := make(map[string]interface{})
+	if req.FullName != nil {
+		name := strings.TrimSpace(*req.FullName)
+		if name == "" {
+			return gin.H{"error": "full_name can't be empty"}, http.StatusBadRequest
+		}
+		updates["full_name"] = name
+	}
+	if req.Email != nil {
+		email := strings.ToLower(strings.TrimSpace(*req.Email))
+		existing, err := candidateWithEmail(caller.OrganizationID, email, candidate.ID)
+		if err != nil {
+			return gin.H{"error": "Failed to update candidate"}, http.StatusInternalServerError
+		}
+		if existing != "" {
+			return gin.H{"error": "A candidate with this email already exists", "candidate_id": existing}, http.StatusConflict
+		}
+		updates["email"] = email
+	}
+	set := func(column string, value *string) {
+		if value != nil {
+			updates[column] = *value
+		}
+	}
+	set("phone", req.Phone)
+	set("linked_in", req.LinkedIn)
+	set("git_hub", req.GitHub)
+	set("location", req.Location)
+	set("experience", req.Experience)
+	set("education", req.Education)
+	set("skills", req.Skills)
+	if req.Tags != nil {
+		tags, err := normalizeTags(*req.Tags)
+		if err != nil {
+			return gin.H{"error": err.Error()}, http.StatusBadRequest
+		}
+		updates["tags"] = tags
+	}
+	if len(updates) == 0 {
+		return gin.H{"message": "Nothing to update", "candidate": candidateView(candidate)}, http.StatusOK
+	}
+	updates["updated_at"] = time.Now()
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(candidate).Updates(updates).Error; err != nil {
+			return candidateSaveError(err)
+		}
+		return s.queue.Enqueue(tx, caller.OrganizationID, TaskDetectDuplicates, candidateTask{CandidateID: candidate.ID})
+	})
+	if errors.Is(err, ErrCandidateExists) {
+		return gin.H{"error": "A candidate with this email already exists"}, http.StatusConflict
+	}
+	if err != nil {
+		return gin.H{"error": "Failed to update candidate"}, http.StatusInternalServerError
+	}
+	if candidate, err = loadCandidate(caller.OrganizationID, candidate.ID); err != nil {
+		return gin.H{"error": "Failed to load candidate"}, http.StatusInternalServerError
+	}
+	return gin.H{"message": "Candidate updated", "candidate": candidateView(candidate)}, http.StatusOK
+}
+
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// ListTags returns every tag used on the organization's candidates with how
+// many candidates have it, most used first.
+func (s *CandidateService) ListTags(caller Caller) (gin.H, int) {
+	tags := []TagCount{}
+	if err := db.DB.Raw(`SELECT tag, COUNT(*) AS count FROM candidates, unnest(candidates.tags) AS tag
+		WHERE candidates.organization_id = ? GROUP BY tag ORDER BY count DESC, tag`, caller.OrganizationID).
+		Scan(&tags).Error; err != nil {
+		return gin.H{"error": "Failed to load tags"}, http.StatusInternalServerError
+	}
+	return gin.H{"tags": tags}, http.StatusOK
+}
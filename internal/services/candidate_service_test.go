@@ -0,0 +1,35 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/resumelens/authservice/internal/db"
+	"github.com/resumelens/authservice/internal/models"
+	"github.com/resumelens/authservice/internal/queue"
+	"github.com/resumelens/authservice/internal/storage"
+)
+
+// TestCandidateEmailUnique checks that the database, not just the
+// candidateWithEmail pre-check, keeps candidate emails unique within an
+// organization.
+func TestCandidateEmailUnique(t *testing.T) {
+	testDB(t)
+	store := storage.NewMemoryStore()
+	// Both organizations have a candidate with the same email.
+	a, _ := createTenant(t, store), createTenant(t, store)
+
+	// A racing insert that got past the pre-check.
+	racer := models.Candidate{ID: uuid.NewString(), OrganizationID: a.caller.OrganizationID, FullName: "Racer", Email: "SAME@example.com"}
+	if err := candidateSaveError(db.DB.Create(&racer).Error); !errors.Is(err, ErrCandidateExists) {
+		t.Fatalf("insert err = %v; want ErrCandidateExists", err)
+	}
+
+	candidates := NewCandidateService(queue.New(db.DB, queue.Options{}))
+	response, status := candidates.CreateCandidate(a.caller, CreateCandidateRequest{FullName: "Again", Email: "Same@Example.com"})
+	if status != http.StatusConflict || response["candidate_id"] != a.candidate.ID {
+		t.Errorf("CreateCandidate = %d %v; want 409 naming %s", status, response, a.candidate.ID)
+	}
+}
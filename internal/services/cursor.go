@@ -0,0 +1,52 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// pageCursor marks the last row of a page for keyset pagination. Clients
+// treat the encoded form as opaque.
+type pageCursor struct {
+	Sort  string          `json:"s"`
+	Order string          `json:"o"`
+	Value json.RawMessage `json:"v"`
+	ID    string          `json:"id"`
+}
+
+func encodeCursor(sort, order string, value interface{}, id string) (string, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(pageCursor{Sort: sort, Order: order, Value: raw, ID: id})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor parses an opaque cursor into value, checking that it was
+// issued for the same sort column and direction and holds a row id.
+func decodeCursor(cursor, sort, order string, value interface{}) (string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", errInvalidCursor
+	}
+	var c pageCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.Sort != sort || c.Order != order {
+		return "", errInvalidCursor
+	}
+	if _, err := uuid.Parse(c.ID); err != nil {
+		return "", errInvalidCursor
+	}
+	if err := json.Unmarshal(c.Value, value); err != nil {
+		return "", errInvalidCursor
+	}
+	return c.ID, nil
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"encoding/base64"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestDecodeCursor(t *testing.T) {
+	const id = "3f2a9c1b-0d4e-4f6a-8b7c-9d0e1f2a3b4c"
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	cursor, err := encodeCursor("created_at", "desc", created, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var after time.Time
+	afterID, err := decodeCursor(cursor, "created_at", "desc", &after)
+	if err != nil || afterID != id || !after.Equal(created) {
+		t.Fatalf("decodeCursor = %s, %v, %v; want %s, %v", afterID, after, err, id, created)
+	}
+
+	badID, _ := encodeCursor("created_at", "desc", created, "1' OR '1'='1")
+	tests := []struct {
+		name   string
+		cursor string
+		sort   string
+		order  string
+	}{
+		{"other sort", cursor, "application_count", "desc"},
+		{"other order", cursor, "created_at", "asc"},
+		{"malformed id", badID, "created_at", "desc"},
+		{"not base64", "%%%", "created_at", "desc"},
+		{"not json", base64.RawURLEncoding.EncodeToString([]byte("nope")), "created_at", "desc"},
+	}
+	for _, tt := range tests {
+		if _, err := decodeCursor(tt.cursor, tt.sort, tt.order, &after); err != errInvalidCursor {
+			t.Errorf("%s: err = %v; want errInvalidCursor", tt.name, err)
+		}
+	}
+}
+
+// TestListJobsRejectsCursor checks that a cursor from another ordering or
+// with a malformed id is a client error rather than a failed query.
+func TestListJobsRejectsCursor(t *testing.T) {
+	statements := dryRunDB(t)
+	jobs := NewJobHostingService(nil, nil)
+	caller := Caller{UserID: "user-a", OrganizationID: "org-a"}
+
+	desc, _ := encodeCursor("created_at", "desc", time.Now(), "3f2a9c1b-0d4e-4f6a-8b7c-9d0e1f2a3b4c")
+	badID, _ := encodeCursor("created_at", "desc", time.Now(), "not-a-uuid")
+	tests := []struct {
+		name string
+		req  ListJobsRequest
+	}{
+		{"ascending with a descending cursor", ListJobsRequest{Order: "asc", Cursor: desc}},
+		{"sorted by applications with a created_at cursor", ListJobsRequest{Sort: "application_count", Cursor: desc}},
+		{"malformed id", ListJobsRequest{Cursor: badID}},
+	}
+	for _, tt := range tests {
+		if response, status := jobs.ListJobs(caller, tt.req); status != http.StatusBadRequest {
+			t.Errorf("%s: ListJobs = %d %v; want 400", tt.name, status, response)
+		}
+	}
+	if len(*statements) != 0 {
+		t.Errorf("ran %v; want no query", *statements)
+	}
+
+	if _, status := jobs.ListJobs(caller, ListJobsRequest{Cursor: desc}); status != http.StatusOK {
+		t.Errorf("ListJobs with a matching cursor = %d; want 200", status)
+	}
+}
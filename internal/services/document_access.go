@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/resumelens/authservice/internal/db"
+	"github.com/resumelens/authservice/internal/models"
+	"github.com/resumelens/authservice/internal/storage"
+	"gorm.io/gorm"
+)
+
+// Values of DocumentAccessLog.Method.
+const (
+	AccessMethodSignedURL = "signed_url"
+	AccessMethodStream    = "stream"
+)
+
+const defaultAccessLogPageSize = 50
+
+// AccessClient identifies where a document request came from, for the
+// access log.
+type AccessClient struct {
+	IPAddress string
+	UserAgent string
+}
+
+// DocumentAccessRequest selects between the stored document and its
+// preview, a rendition browsers can show inline.
+type DocumentAccessRequest struct {
+	Preview bool `form:"preview"`
+}
+
+// DocumentAccess is a granted download. URL is set when the storage backend
+// can sign one; otherwise Reader streams the document and must be closed.
+// For previews Version describes the rendition.
+type DocumentAccess struct {
+	Version   *models.DocumentVersion
+	Preview   bool
+	URL       string
+	ExpiresAt time.Time
+	Reader    io.ReadCloser
+}
+
+// AccessDocumentVersion grants access to one version of an application's
+// documents.
+func (s *JobApplicationService) AccessDocumentVersion(ctx context.Context, caller Caller, applicationID, versionID string, req DocumentAccessRequest, client AccessClient) (*DocumentAccess, error) {
+	application, err := loadApplication(caller.OrganizationID, applicationID)
+	if err != nil {
+		return nil, err
+	}
+
+	var version models.DocumentVersion
+	if err := db.DB.Where("id = ? AND application_id = ?", versionID, application.ID).First(&version).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return s.accessDocument(ctx, caller, application, &version, req, client)
+}
+
+// AccessCurrentDocument grants access to the resume or cover letter the
+// application currently uses.
+func (s *JobApplicationService) AccessCurrentDocument(ctx context.Context, caller Caller, applicationID, kind string, req DocumentAccessRequest, client AccessClient) (*DocumentAccess, error) {
+	application, err := loadApplication(caller.OrganizationID, applicationID)
+	if err != nil {
+		return nil, err
+	}
+
+	currentID, objectPath, scanStatus := application.CurrentResumeID, application.ResumeGCSPath, application.ResumeScanStatus
+	if kind == documentCoverLetter {
+		currentID, objectPath, scanStatus = application.CurrentCoverLetterID, application.CoverLetterGCSPath, application.CoverLetterScanStatus
+	}
+	if objectPath == "" {
+		return nil, ErrNotFound
+	}
+
+	var version models.DocumentVersion
+	if currentID != nil {
+		if err := db.DB.Where("id = ?", *currentID).First(&version).Error; err != nil {
+			return nil, err
+		}
+	} else {
+		// Uploaded before versioning: there is no record, so describe the
+		// object from storage. Such files predate scanning too.
+		info, err := s.store.Stat(ctx, objectPath)
+		if errors.Is(err, storage.ErrObjectNotExist) {
+			return nil, ErrNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		if scanStatus == "" {
+			scanStatus = ScanStatusClean
+		}
+		version = models.DocumentVersion{
+			ApplicationID: application.ID,
+			Kind:          kind,
+			ObjectPath:    objectPath,
+			Size:          info.Size,
+			ContentType:   info.ContentType,
+			Filename:      path.Base(objectPath),
+			ScanStatus:    scanStatus,
+		}
+	}
+	return s.accessDocument(ctx, caller, application, &version, req, client)
+}
+
+// accessDocument signs a URL for version, or for its preview, or opens it
+// when the backend can't sign, and records the access. Nothing is handed
+// out unless the access log row was written.
+func (s *JobApplicationService) accessDocument(ctx context.Context, caller Caller, application *models.JobApplication, version *models.DocumentVersion, req DocumentAccessRequest, client AccessClient) (*DocumentAccess, error) {
+	if version.ScanStatus != ScanStatusClean {
+		return nil, ErrDocumentUnavailable
+	}
+	if req.Preview {
+		rendition, err := s.previewRendition(ctx, version)
+		if err != nil {
+			return nil, err
+		}
+		version = rendition
+	}
+
+	access := &DocumentAccess{Version: version, Preview: req.Preview}
+	method := AccessMethodSignedURL
+	expiry := time.Duration(s.config.DownloadURLExpiryMinutes) * time.Minute
+	url, err := s.store.SignedURL(ctx, version.ObjectPath, expiry)
+	switch {
+	case err == nil:
+		access.URL = url
+		access.ExpiresAt = time.Now().Add(expiry).UTC()
+	case errors.Is(err, storage.ErrSignedURLNotSupported):
+		method = AccessMethodStream
+		reader, err := s.store.Get(ctx, version.ObjectPath)
+		if errors.Is(err, storage.ErrObjectNotExist) {
+			return nil, ErrNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		access.Reader = reader
+	default:
+		return nil, fmt.Errorf("failed to sign URL for %s: %w", version.ObjectPath, err)
+	}
+
+	entry := models.DocumentAccessLog{
+		OrganizationID: caller.OrganizationID,
+		ApplicationID:  application.ID,
+		Kind:           version.Kind,
+		ObjectPath:     version.ObjectPath,
+		UserID:         caller.UserID,
+		Method:         method,
+		Preview:        req.Preview,
+		IPAddress:      client.IPAddress,
+		UserAgent:      client.UserAgent,
+		CreatedAt:      time.Now(),
+	}
+	if version.ID != "" {
+		entry.VersionID = &version.ID
+	}
+	if err := db.DB.Create(&entry).Error; err != nil {
+		if access.Reader != nil {
+			access.Reader.Close()
+		}
+		return nil, fmt.Errorf("failed to log document access: %w", err)
+	}
+	return access, nil
+}
+
+type ListDocumentAccessRequest struct {
+	Limit  int `form:"limit" binding:"omitempty,min=1,max=200"`
+	Offset int `form:"offset" binding:"omitempty,min=0"`
+}
+
+// ListDocumentAccess returns who downloaded an application's documents,
+// newest first.
+func (s *JobApplicationService) ListDocumentAccess(caller Caller, applicationID string, req ListDocumentAccessRequest) (gin.H, int) {
+	application, err := loadApplication(caller.OrganizationID, applicationID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return gin.H{"error": "Application not found"}, http.StatusNotFound
+		}
+		return gin.H{"error": "Failed to load application"}, http.StatusInternalServerError
+	}
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultAccessLogPageSize
+	}
+	query := db.DB.Model(&models.DocumentAccessLog{}).
+		Where("organization_id = ? AND application_id = ?", caller.OrganizationID, application.ID)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return gin.H{"error": "Failed to load access log"}, http.StatusInternalServerError
+	}
+	entries := []models.DocumentAccessLog{}
+	if err := query.Order("created_at DESC").Limit(limit).Offset(req.Offset).Find(&entries).Error; err != nil {
+		return gin.H{"error": "Failed to load access log"}, http.StatusInternalServerError
+	}
+	return gin.H{"access_log": entries, "total": total}, http.StatusOK
+}
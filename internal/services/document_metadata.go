@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/resumelens/authservice/internal/db"
+	"github.com/resumelens/authservice/internal/models"
+	"github.com/resumelens/authservice/internal/storage"
+)
+
+// Metadata is the metadata.json kept next to a candidate's documents. It
+// is an export of the application's current document versions for tools
+// that read the bucket; Postgres is the source of truth.
+type Metadata struct {
+	ApplicationID        string    `json:"application_id,omitempty"`
+	ResumeFilename       string    `json:"resume_filename,omitempty"`
+	ResumeVersionID      string    `json:"resume_version_id,omitempty"`
+	CoverLetterFilename  string    `json:"cover_letter_filename,omitempty"`
+	CoverLetterVersionID string    `json:"cover_letter_version_id,omitempty"`
+	LastUpdated          time.Time `json:"last_updated"`
+}
+
+func metadataPath(orgID, jobID, candidateID string) string {
+	return fmt.Sprintf("org-%s/job-%s/candidate-%s/metadata.json", orgID, jobID, candidateID)
+}
+
+// ExportMetadata rewrites an application's metadata.json from the database.
+// The write is conditional on the generation that was read, so concurrent
+// exports retry on the fresh file instead of dropping each other's fields.
+// The database is read inside every attempt, after the file, so whichever
+// export wins last has seen the latest state.
+func (s *JobApplicationService) ExportMetadata(ctx context.Context, applicationID string) error {
+	var application models.JobApplication
+	if err := db.DB.Where("id = ?", applicationID).First(&application).Error; err != nil {
+		return err
+	}
+	var job models.Job
+	if err := db.DB.Unscoped().Where("id = ?", application.JobID).First(&job).Error; err != nil {
+		return err
+	}
+
+	name := metadataPath(job.OrganizationID, job.ID, application.CandidateID)
+	return storage.Update(ctx, s.store, name, "application/json", func(current []byte) ([]byte, error) {
+		// Files written before versioning are the only record of their
+		// file names, so start from what is there.
+		var metadata Metadata
+		if len(current) > 0 {
+			if err := json.Unmarshal(current, &metadata); err != nil {
+				metadata = Metadata{}
+			}
+		}
+
+		var latest models.JobApplication
+		if err := db.DB.Where("id = ?", applicationID).First(&latest).Error; err != nil {
+			return nil, err
+		}
+		metadata.ApplicationID = latest.ID
+		if err := exportDocument(latest.CurrentResumeID, latest.ResumeGCSPath, &metadata.ResumeFilename, &metadata.ResumeVersionID); err != nil {
+			return nil, err
+		}
+		if err := exportDocument(latest.CurrentCoverLetterID, latest.CoverLetterGCSPath, &metadata.CoverLetterFilename, &metadata.CoverLetterVersionID); err != nil {
+			return nil, err
+		}
+		metadata.LastUpdated = time.Now().UTC()
+
+		return json.MarshalIndent(metadata, "", "  ")
+	})
+}
+
+// exportDocument fills in the file name and version of one document kind.
+// A legacy document without a version keeps the exported file name.
+func exportDocument(currentID *string, objectPath string, filename, versionID *string) error {
+	switch {
+	case currentID != nil:
+		var version models.DocumentVersion
+		if err := db.DB.Where("id = ?", *currentID).First(&version).Error; err != nil {
+			return err
+		}
+		*filename, *versionID = version.Filename, version.ID
+	case objectPath == "":
+		*filename, *versionID = "", ""
+	}
+	return nil
+}
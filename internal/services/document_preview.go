@@ -0,0 +1,82 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/resumelens/authservice/internal/models"
+	"github.com/resumelens/authservice/internal/preview"
+	"github.com/resumelens/authservice/internal/storage"
+	"github.com/resumelens/authservice/internal/upload"
+)
+
+// ErrPreviewUnavailable is returned for documents that can't be rendered,
+// such as legacy .doc files or damaged DOCX.
+var ErrPreviewUnavailable = errors.New("no preview is available for this document")
+
+// renditionPath names the cached preview of a stored document. It sits
+// next to the original and carries the renderer version, so a renderer
+// change produces fresh renditions instead of serving stale ones.
+func renditionPath(objectPath string) string {
+	return fmt.Sprintf("%s.preview-v%d.html", strings.TrimSuffix(objectPath, path.Ext(objectPath)), preview.Version)
+}
+
+// previewRendition returns a description of the document browsers can show
+// inline. PDFs are their own preview; DOCX, RTF and text are rendered to
+// HTML on first use and the rendition is cached in the store. Versions are
+// immutable, so a cached rendition never goes stale.
+func (s *JobApplicationService) previewRendition(ctx context.Context, version *models.DocumentVersion) (*models.DocumentVersion, error) {
+	fileType := upload.Lookup(version.ContentType, version.ObjectPath)
+	switch fileType {
+	case upload.TypePDF:
+		return version, nil
+	case upload.TypeDOCX, upload.TypeRTF, upload.TypeTXT:
+	default:
+		return nil, ErrPreviewUnavailable
+	}
+
+	rendition := *version
+	rendition.ObjectPath = renditionPath(version.ObjectPath)
+	rendition.ContentType = preview.ContentType
+	rendition.Filename = strings.TrimSuffix(version.Filename, path.Ext(version.Filename)) + ".html"
+
+	info, err := s.store.Stat(ctx, rendition.ObjectPath)
+	if err == nil {
+		rendition.Size = info.Size
+		return &rendition, nil
+	}
+	if !errors.Is(err, storage.ErrObjectNotExist) {
+		return nil, err
+	}
+
+	reader, err := s.store.Get(ctx, version.ObjectPath)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(io.LimitReader(reader, maxResumeSize+1))
+	reader.Close()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxResumeSize {
+		return nil, ErrPreviewUnavailable
+	}
+
+	rendered, err := preview.Render(fileType.Name, data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPreviewUnavailable, err)
+	}
+	if err := s.store.Put(ctx, rendition.ObjectPath, bytes.NewReader(rendered), preview.ContentType); err != nil {
+		return nil, fmt.Errorf("failed to cache preview: %w", err)
+	}
+	rendition.Size = int64(len(rendered))
+	return &rendition, nil
+}
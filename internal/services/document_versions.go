@@ -0,0 +1,90 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/resumelens/authservice/internal/db"
+	"github.com/resumelens/authservice/internal/models"
+	"gorm.io/gorm"
+)
+
+// ErrDocumentUnavailable is returned for versions that haven't passed the
+// malware scan.
+var ErrDocumentUnavailable = errors.New("document has not passed the virus scan")
+
+// DocumentVersionView is a DocumentVersion as shown to recruiters.
+type DocumentVersionView struct {
+	ID          string    `json:"id"`
+	Kind        string    `json:"kind"`
+	Version     int       `json:"version"`
+	Current     bool      `json:"current"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256"`
+	ScanStatus  string    `json:"scan_status"`
+	UploadedBy  *string   `json:"uploaded_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type ListDocumentVersionsRequest struct {
+	Kind string `form:"kind" binding:"omitempty,oneof=resume cover_letter"`
+}
+
+// ListDocumentVersions returns every version of an application's documents,
+// newest first within each kind.
+func (s *JobApplicationService) ListDocumentVersions(caller Caller, applicationID string, req ListDocumentVersionsRequest) (gin.H, int) {
+	application, err := loadApplication(caller.OrganizationID, applicationID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return gin.H{"error": "Application not found"}, http.StatusNotFound
+		}
+		return gin.H{"error": "Failed to load application"}, http.StatusInternalServerError
+	}
+
+	query := db.DB.Where("application_id = ?", application.ID)
+	if req.Kind != "" {
+		query = query.Where("kind = ?", req.Kind)
+	}
+	var versions []models.DocumentVersion
+	if err := query.Order("kind, version DESC").Find(&versions).Error; err != nil {
+		return gin.H{"error": "Failed to load documents"}, http.StatusInternalServerError
+	}
+
+	views := make([]DocumentVersionView, len(versions))
+	for i, v := range versions {
+		current := application.CurrentResumeID
+		if v.Kind == documentCoverLetter {
+			current = application.CurrentCoverLetterID
+		}
+		views[i] = DocumentVersionView{
+			ID:          v.ID,
+			Kind:        v.Kind,
+			Version:     v.Version,
+			Current:     current != nil && *current == v.ID,
+			Filename:    v.Filename,
+			ContentType: v.ContentType,
+			Size:        v.Size,
+			SHA256:      v.SHA256,
+			ScanStatus:  v.ScanStatus,
+			UploadedBy:  v.UploadedBy,
+			CreatedAt:   v.CreatedAt,
+		}
+	}
+	return gin.H{"documents": views}, http.StatusOK
+}
+
+func loadApplication(orgID, applicationID string) (*models.JobApplication, error) {
+	var application models.JobApplication
+	if err := db.DB.Scopes(ApplicationsForOrganization(orgID)).
+		Where("job_applications.id = ?", applicationID).First(&application).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &application, nil
+}
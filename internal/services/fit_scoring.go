@@ -0,0 +1,123 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/resumelens/authservice/internal/db"
+	"github.com/resumelens/authservice/internal/models"
+	"github.com/resumelens/authservice/internal/scoring"
+	"gorm.io/gorm"
+)
+
+// scoredFields are the job columns the fit score depends on. Changing any of
+// them makes every stored score for the job stale.
+var scoredFields = []string{"skills_required", "experience_level", "location", "employment_type"}
+
+// ScoreBreakdown is the document stored in JobApplication.ScoreBreakdown.
+type ScoreBreakdown struct {
+	scoring.Result
+	ScoredAt time.Time `json:"scored_at"`
+}
+
+// scoreUpdates returns the column values that record result.
+func scoreUpdates(result scoring.Result) (map[string]interface{}, error) {
+	data, err := json.Marshal(ScoreBreakdown{Result: result, ScoredAt: time.Now().UTC()})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"ai_score":        result.Score,
+		"score_breakdown": string(data),
+	}, nil
+}
+
+// RescoreJob recomputes the fit score of every application to a job whose
+// resume has been parsed, then refreshes the job's analytics.
+func RescoreJob(jobID string) error {
+	var job models.Job
+	if err := db.DB.Where("id = ?", jobID).First(&job).Error; err != nil {
+		return err
+	}
+
+	var applications []models.JobApplication
+	if err := db.DB.Where("job_id = ? AND parsed_resume IS NOT NULL", jobID).Find(&applications).Error; err != nil {
+		return err
+	}
+
+	for _, application := range applications {
+		var parsed ParsedResume
+		if err := json.Unmarshal([]byte(*application.ParsedResume), &parsed); err != nil || parsed.Profile == nil {
+			continue
+		}
+		updates, err := scoreUpdates(scoring.Score(parsed.Profile, &job))
+		if err != nil {
+			return err
+		}
+		// Skip the write if the resume changed since it was parsed; the
+		// new upload's processing will score it.
+		if err := db.DB.Model(&models.JobApplication{}).
+			Where("id = ? AND resume_gcs_path = ?", application.ID, parsed.SourcePath).
+			Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+
+	return refreshJobAnalytics(db.DB, jobID)
+}
+
+// refreshJobAnalytics recomputes the application totals and average fit
+// score of a job. Only scored applications count towards the average.
+func refreshJobAnalytics(tx *gorm.DB, jobID string) error {
+	var totals struct {
+		Total       int
+		Hires       int
+		AvgFitScore float64
+	}
+	err := tx.Model(&models.JobApplication{}).
+		Select("COUNT(*) AS total, "+
+			"COUNT(*) FILTER (WHERE status = ?) AS hires, "+
+			"COALESCE(AVG(ai_score) FILTER (WHERE score_breakdown IS NOT NULL), 0) AS avg_fit_score", StageHired).
+		Where("job_id = ?", jobID).
+		Scan(&totals).Error
+	if err != nil {
+		return err
+	}
+
+	var analytics models.JobAnalytics
+	err = tx.Where("job_id = ?", jobID).FirstOrInit(&analytics, models.JobAnalytics{JobID: jobID}).Error
+	if err != nil {
+		return err
+	}
+	analytics.TotalApplications = totals.Total
+	analytics.TotalHires = totals.Hires
+	analytics.AvgFitScore = totals.AvgFitScore
+	return tx.Save(&analytics).Error
+}
+
+// GetApplicationScore returns an application's fit score and how it was
+// arrived at. Applications whose resume hasn't been parsed yet have no
+// breakdown.
+func (s *JobApplicationService) GetApplicationScore(caller Caller, applicationID string) (gin.H, int) {
+	var application models.JobApplication
+	if err := db.DB.Scopes(ApplicationsForOrganization(caller.OrganizationID)).
+		Where("job_applications.id = ?", applicationID).First(&application).Error; err != nil {
+		return gin.H{"error": "Application not found"}, http.StatusNotFound
+	}
+
+	if application.ScoreBreakdown == nil {
+		return gin.H{"score": nil, "breakdown": nil, "message": "Resume has not been scored yet"}, http.StatusOK
+	}
+	var breakdown ScoreBreakdown
+	if err := json.Unmarshal([]byte(*application.ScoreBreakdown), &breakdown); err != nil {
+		return gin.H{"error": "Failed to load score"}, http.StatusInternalServerError
+	}
+	return gin.H{
+		"score":     application.AI_Score,
+		"version":   breakdown.Version,
+		"breakdown": breakdown.Breakdown,
+		"scored_at": breakdown.ScoredAt,
+	}, http.StatusOK
+}
@@ -1,145 +1,343 @@
 package services
 
 import (
-	"archive/zip"
-	"bytes"
 	"context"
-	"encoding/json"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"mime/multipart"
 	"path/filepath"
+	"strings"
 	"time"
 
-	"cloud.google.com/go/storage"
+	"github.com/google/uuid"
+	"github.com/resumelens/authservice/internal/config"
+	"github.com/resumelens/authservice/internal/db"
+	"github.com/resumelens/authservice/internal/models"
+	"github.com/resumelens/authservice/internal/queue"
+	"github.com/resumelens/authservice/internal/storage"
+	"github.com/resumelens/authservice/internal/upload"
+	"github.com/resumelens/authservice/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidCandidate  = errors.New("candidate_id or full_name and email are required")
+	ErrArchiveNotAllowed = errors.New("zip archives must be uploaded through the bulk import endpoint")
+
+	errApplicationExists = errors.New("candidate has already applied to this job")
+)
+
+const (
+	documentResume      = "resume"
+	documentCoverLetter = "cover_letter"
 )
 
 type JobApplicationService struct {
-	gcsClient  *storage.Client
-	bucketName string
+	config *config.Config
+	store  storage.BlobStore
+	queue  *queue.Queue
+	limits upload.Limits
 }
 
-type Metadata struct {
-	ResumeFilename      string    `json:"resume_filename,omitempty"`
-	CoverLetterFilename string    `json:"cover_letter_filename,omitempty"`
-	LastUpdated         time.Time `json:"last_updated"`
+func NewJobApplicationService(cfg *config.Config, store storage.BlobStore, q *queue.Queue) *JobApplicationService {
+	return &JobApplicationService{
+		config: cfg,
+		store:  store,
+		queue:  q,
+		limits: upload.DefaultLimits(cfg.UploadMaxFileMB << 20),
+	}
 }
 
-func NewJobApplicationService(gcsClient *storage.Client, bucketName string) *JobApplicationService {
-	return &JobApplicationService{
-		gcsClient:  gcsClient,
-		bucketName: bucketName,
+// UploadDocumentRequest names the job and the candidate a document belongs
+// to. An existing candidate is referenced by CandidateID; otherwise FullName
+// and Email are used to find or create one.
+type UploadDocumentRequest struct {
+	JobID       string `form:"job_id" binding:"required"`
+	CandidateID string `form:"candidate_id"`
+	FullName    string `form:"full_name" binding:"omitempty,singleline"`
+	Email       string `form:"email" binding:"omitempty,email"`
+	Phone       string `form:"phone"`
+	LinkedIn    string `form:"linkedin"`
+	GitHub      string `form:"github"`
+	Location    string `form:"location"`
+}
+
+func (s *JobApplicationService) UploadResume(ctx context.Context, caller Caller, file multipart.File, handler *multipart.FileHeader, req UploadDocumentRequest) (*models.JobApplication, error) {
+	job, err := loadJob(caller.OrganizationID, req.JobID)
+	if err != nil {
+		return nil, err
 	}
+	return s.saveDocument(ctx, job, req, &caller.UserID, documentResume, file, handler)
 }
 
-func (s *JobApplicationService) UploadResume(ctx context.Context, file multipart.File, handler *multipart.FileHeader, orgID, jobID, candidateID string) error {
-	if filepath.Ext(handler.Filename) == ".zip" {
-		return s.processZip(ctx, file, handler, orgID, jobID, candidateID)
+func (s *JobApplicationService) UploadCoverLetter(ctx context.Context, caller Caller, file multipart.File, handler *multipart.FileHeader, req UploadDocumentRequest) (*models.JobApplication, error) {
+	job, err := loadJob(caller.OrganizationID, req.JobID)
+	if err != nil {
+		return nil, err
 	}
+	return s.saveDocument(ctx, job, req, &caller.UserID, documentCoverLetter, file, handler)
+}
 
-	ext := filepath.Ext(handler.Filename)
-	objectName := s.buildObjectPath(orgID, jobID, candidateID, "resume", ext)
+// saveDocument stores a resume or cover letter and records it on the
+// candidate's application for job, creating the Candidate and JobApplication
+// rows as needed.
+func (s *JobApplicationService) saveDocument(ctx context.Context, job *models.Job, req UploadDocumentRequest, addedBy *string, kind string, file multipart.File, handler *multipart.FileHeader) (*models.JobApplication, error) {
+	fileType, err := s.checkDocument(file, handler)
+	if err != nil {
+		return nil, err
+	}
 
-	if err := s.uploadToGCS(ctx, objectName, file); err != nil {
-		return fmt.Errorf("failed to upload resume: %w", err)
+	candidate, isNew, err := resolveCandidate(job.OrganizationID, req, addedBy)
+	if err != nil {
+		return nil, err
 	}
 
-	metadataUpdate := map[string]interface{}{"resume_filename": handler.Filename}
-	return s.updateMetadata(ctx, orgID, jobID, candidateID, metadataUpdate)
+	application, _, err := s.storeDocument(ctx, job, candidate, isNew, kind, fileType, file, handler.Filename, addedBy, false)
+	return application, err
 }
 
-func (s *JobApplicationService) UploadCoverLetter(ctx context.Context, file multipart.File, handler *multipart.FileHeader, orgID, jobID, candidateID string) error {
-	if filepath.Ext(handler.Filename) == ".zip" {
-		return fmt.Errorf("zip files are not supported for cover letters")
+// checkDocument identifies an uploaded document from its content and
+// rejects anything outside the allow-list or over the size limit.
+func (s *JobApplicationService) checkDocument(file multipart.File, handler *multipart.FileHeader) (*upload.Type, error) {
+	if strings.EqualFold(filepath.Ext(handler.Filename), ".zip") {
+		return nil, ErrArchiveNotAllowed
 	}
+	return s.limits.Document(file, handler.Size, handler.Filename)
+}
 
-	ext := filepath.Ext(handler.Filename)
-	objectName := s.buildObjectPath(orgID, jobID, candidateID, "cover_letter", ext)
+// storeDocument uploads a validated document for candidate as a new
+// version, saving the candidate first if isNewCandidate, and makes it the
+// current version on the candidate's application for job. Every version is
+// its own object, named after the detected type rather than the uploader's
+// file name. It stays pending until the malware scan queued here marks it
+// clean; uploadedBy is told if it doesn't.
+// The blob is written first; if the database write then fails it is
+// deleted again so the bucket doesn't collect orphans. The bool reports
+// whether the application was created. With newOnly, an existing
+// application is left alone and errApplicationExists returned instead.
+func (s *JobApplicationService) storeDocument(ctx context.Context, job *models.Job, candidate *models.Candidate, isNewCandidate bool, kind string, fileType *upload.Type, r io.Reader, filename string, uploadedBy *string, newOnly bool) (*models.JobApplication, bool, error) {
+	version := models.DocumentVersion{
+		ID:             uuid.NewString(),
+		OrganizationID: job.OrganizationID,
+		Kind:           kind,
+		ContentType:    fileType.ContentType,
+		Filename:       filename,
+		UploadedBy:     uploadedBy,
+		ScanStatus:     ScanStatusPending,
+		CreatedAt:      time.Now(),
+	}
+	version.ObjectPath = s.buildObjectPath(job.OrganizationID, job.ID, candidate.ID, kind+"-"+version.ID, fileType.Extension)
 
-	if err := s.uploadToGCS(ctx, objectName, file); err != nil {
-		return fmt.Errorf("failed to upload cover letter: %w", err)
+	hash := sha256.New()
+	counter := &byteCounter{}
+	if err := s.uploadObject(ctx, version.ObjectPath, io.TeeReader(r, io.MultiWriter(hash, counter)), fileType.ContentType); err != nil {
+		return nil, false, fmt.Errorf("failed to upload %s: %w", kind, err)
 	}
+	version.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	version.Size = counter.n
 
-	metadataUpdate := map[string]interface{}{"cover_letter_filename": handler.Filename}
-	return s.updateMetadata(ctx, orgID, jobID, candidateID, metadataUpdate)
-}
+	pathColumn, currentColumn, statusColumn := documentColumns(kind)
 
-func (s *JobApplicationService) buildObjectPath(orgID, jobID, candidateID, fileType, ext string) string {
-	return fmt.Sprintf("org-%s/job-%s/candidate-%s/%s%s", orgID, jobID, candidateID, fileType, ext)
-}
+	var application models.JobApplication
+	var isNewApplication bool
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if isNewCandidate {
+			if err := tx.Create(candidate).Error; err != nil {
+				return candidateSaveError(err)
+			}
+		}
+
+		app, created, err := s.findOrCreateApplication(tx, job, candidate.ID)
+		if err != nil {
+			return err
+		}
+		if newOnly && !created {
+			return errApplicationExists
+		}
 
-func (s *JobApplicationService) updateMetadata(ctx context.Context, orgID, jobID, candidateID string, updates map[string]interface{}) error {
-	metadataPath := fmt.Sprintf("org-%s/job-%s/candidate-%s/metadata.json", orgID, jobID, candidateID)
-	obj := s.gcsClient.Bucket(s.bucketName).Object(metadataPath)
+		// findOrCreateApplication locks the application row, so version
+		// numbers are handed out one at a time.
+		version.ApplicationID = app.ID
+		if err := tx.Model(&models.DocumentVersion{}).
+			Where("application_id = ? AND kind = ?", app.ID, kind).
+			Select("COALESCE(MAX(version), 0) + 1").Scan(&version.Version).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&version).Error; err != nil {
+			return err
+		}
 
-	reader, err := obj.NewReader(ctx)
-	currentMetadata := make(map[string]interface{})
+		updates := map[string]interface{}{pathColumn: version.ObjectPath, currentColumn: version.ID, statusColumn: ScanStatusPending}
+		if err := tx.Model(&models.JobApplication{}).Where("id = ?", app.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+		if kind == documentCoverLetter {
+			app.CoverLetterGCSPath, app.CurrentCoverLetterID, app.CoverLetterScanStatus = version.ObjectPath, &version.ID, ScanStatusPending
+		} else {
+			app.ResumeGCSPath, app.CurrentResumeID, app.ResumeScanStatus = version.ObjectPath, &version.ID, ScanStatusPending
+		}
+		application = *app
+		isNewApplication = created
 
-	if err == nil {
-		defer reader.Close()
-		data, readErr := io.ReadAll(reader)
-		if readErr != nil {
-			return fmt.Errorf("failed to read existing metadata: %w", readErr)
+		if created {
+			if err := s.queue.Enqueue(tx, job.OrganizationID, TaskSendMagicLink, applicationTask{ApplicationID: app.ID}); err != nil {
+				return err
+			}
 		}
-		if unmarshalErr := json.Unmarshal(data, &currentMetadata); unmarshalErr != nil {
-			return fmt.Errorf("failed to parse existing metadata: %w", unmarshalErr)
+		if isNewCandidate {
+			if err := s.queue.Enqueue(tx, job.OrganizationID, TaskDetectDuplicates, candidateTask{CandidateID: candidate.ID}); err != nil {
+				return err
+			}
 		}
-	} else if err != storage.ErrObjectNotExist {
-		return fmt.Errorf("failed to download metadata: %w", err)
+		if err := s.queue.Enqueue(tx, job.OrganizationID, TaskExportMetadata, applicationTask{ApplicationID: app.ID}); err != nil {
+			return err
+		}
+		return s.queue.Enqueue(tx, job.OrganizationID, TaskScanDocument, documentTask{VersionID: version.ID})
+	})
+	if err != nil {
+		s.deleteOrphans(ctx, []string{version.ObjectPath})
+		return nil, false, fmt.Errorf("failed to record %s: %w", kind, err)
+	}
+
+	return &application, isNewApplication, nil
+}
+
+// findOrCreateApplication returns the candidate's application for job,
+// creating it and bumping the job's application count the first time. The
+// bool reports whether the application was created.
+func (s *JobApplicationService) findOrCreateApplication(tx *gorm.DB, job *models.Job, candidateID string) (*models.JobApplication, bool, error) {
+	pipeline, err := pipelineFor(tx, job.OrganizationID, job.ID)
+	if err != nil {
+		return nil, false, err
 	}
 
-	for key, value := range updates {
-		currentMetadata[key] = value
+	application := models.JobApplication{
+		CandidateID:        candidateID,
+		JobID:              job.ID,
+		Status:             pipeline[0].Key,
+		MagicLinkToken:     utils.GenerateRandomToken(32),
+		MagicLinkExpiresAt: time.Now().AddDate(0, 0, s.config.MagicLinkExpiryDays),
+		CreatedAt:          time.Now(),
+	}
+	if application.MagicLinkToken == "" {
+		return nil, false, errors.New("failed to generate magic link token")
 	}
-	currentMetadata["last_updated"] = time.Now().UTC().Format(time.RFC3339)
 
-	updatedData, marshalErr := json.MarshalIndent(currentMetadata, "", "  ")
-	if marshalErr != nil {
-		return fmt.Errorf("failed to marshal updated metadata: %w", marshalErr)
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&application)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		if err := tx.Model(&models.Job{}).Where("id = ?", job.ID).
+			UpdateColumn("application_count", gorm.Expr("application_count + 1")).Error; err != nil {
+			return nil, false, err
+		}
+		history := models.ApplicationStageHistory{
+			ApplicationID: application.ID,
+			ToStage:       application.Status,
+			CreatedAt:     application.CreatedAt,
+		}
+		if err := tx.Create(&history).Error; err != nil {
+			return nil, false, err
+		}
+		return &application, true, nil
 	}
 
-	return s.uploadToGCS(ctx, metadataPath, bytes.NewReader(updatedData))
+	var existing models.JobApplication
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("candidate_id = ? AND job_id = ?", candidateID, job.ID).
+		First(&existing).Error; err != nil {
+		return nil, false, err
+	}
+	return &existing, false, nil
 }
 
-func (s *JobApplicationService) uploadToGCS(ctx context.Context, objectName string, reader io.Reader) error {
-	obj := s.gcsClient.Bucket(s.bucketName).Object(objectName)
-	wc := obj.NewWriter(ctx)
+// resolveCandidate finds the candidate an upload refers to. New candidates
+// are returned unsaved, with their ID already assigned so object paths can
+// be built before the database write.
+func resolveCandidate(orgID string, req UploadDocumentRequest, addedBy *string) (*models.Candidate, bool, error) {
+	var candidate models.Candidate
 
-	if _, err := io.Copy(wc, reader); err != nil {
-		return fmt.Errorf("io.Copy: %w", err)
+	if req.CandidateID != "" {
+		err := db.DB.Scopes(ForOrganization(orgID)).Where("id = ?", req.CandidateID).First(&candidate).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, ErrNotFound
+		}
+		return &candidate, false, err
 	}
-	if err := wc.Close(); err != nil {
-		return fmt.Errorf("Writer.Close: %w", err)
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	if email == "" || strings.TrimSpace(req.FullName) == "" {
+		return nil, false, ErrInvalidCandidate
 	}
 
-	log.Printf("Successfully uploaded %s", objectName)
-	return nil
-}
+	err := db.DB.Scopes(ForOrganization(orgID)).Where("lower(email) = ?", email).First(&candidate).Error
+	if err == nil {
+		return &candidate, false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, err
+	}
 
-func (s *JobApplicationService) processZip(ctx context.Context, zipFile multipart.File, zipHandler *multipart.FileHeader, orgID, jobID, candidateID string) error {
-	reader, err := zip.NewReader(zipFile, zipHandler.Size)
-	if err != nil {
-		return fmt.Errorf("could not read zip file: %w", err)
+	candidate = models.Candidate{
+		ID:             uuid.NewString(),
+		OrganizationID: orgID,
+		UserID:         addedBy,
+		FullName:       strings.TrimSpace(req.FullName),
+		Email:          email,
+		Phone:          req.Phone,
+		LinkedIn:       req.LinkedIn,
+		GitHub:         req.GitHub,
+		Location:       req.Location,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
 	}
+	return &candidate, true, nil
+}
 
-	for _, file := range reader.File {
-		if ext := filepath.Ext(file.Name); ext != ".pdf" && ext != ".docx" {
-			continue
+func loadJob(orgID, jobID string) (*models.Job, error) {
+	var job models.Job
+	if err := db.DB.Scopes(ForOrganization(orgID)).Where("id = ?", jobID).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
 		}
+		return nil, err
+	}
+	return &job, nil
+}
 
-		zippedFile, err := file.Open()
-		if err != nil {
-			log.Printf("Error opening %s in zip: %v", file.Name, err)
-			continue
-		}
-		defer zippedFile.Close()
+func (s *JobApplicationService) buildObjectPath(orgID, jobID, candidateID, fileType, ext string) string {
+	return fmt.Sprintf("org-%s/job-%s/candidate-%s/%s%s", orgID, jobID, candidateID, fileType, ext)
+}
 
-		objectName := s.buildObjectPath(orgID, jobID, candidateID, "resume", filepath.Ext(file.Name))
-		if err := s.uploadToGCS(ctx, objectName, zippedFile); err != nil {
-			log.Printf("Failed to upload %s from zip: %v", file.Name, err)
-		}
+func (s *JobApplicationService) uploadObject(ctx context.Context, objectName string, reader io.Reader, contentType string) error {
+	if err := s.store.Put(ctx, objectName, reader, contentType); err != nil {
+		return err
 	}
 
+	log.Printf("Successfully uploaded %s", objectName)
 	return nil
 }
+
+// byteCounter is an io.Writer that counts what is written to it.
+type byteCounter struct {
+	n int64
+}
+
+func (c *byteCounter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
+
+func (s *JobApplicationService) deleteOrphans(ctx context.Context, objectNames []string) {
+	for _, name := range objectNames {
+		if err := s.store.Delete(ctx, name); err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+			log.Printf("Failed to delete orphaned object %s: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/resumelens/authservice/internal/db"
+	"github.com/resumelens/authservice/internal/models"
+	"github.com/resumelens/authservice/internal/upload"
+	"gorm.io/gorm"
+)
+
+// JobBoardService serves the unauthenticated, candidate-facing side of job
+// postings. It only ever exposes open jobs.
+type JobBoardService struct {
+	applications *JobApplicationService
+}
+
+func NewJobBoardService(applications *JobApplicationService) *JobBoardService {
+	return &JobBoardService{applications: applications}
+}
+
+// PublicJob is the subset of models.Job that is safe to show to anyone.
+type PublicJob struct {
+	ID               string    `json:"id"`
+	OrganizationID   string    `json:"organization_id"`
+	OrganizationName string    `json:"organization_name"`
+	Title            string    `json:"title"`
+	Description      string    `json:"description"`
+	Location         []string  `json:"location"`
+	ExperienceLevel  string    `json:"experience_level"`
+	SkillsRequired   []string  `json:"skills_required"`
+	EmploymentType   []string  `json:"employment_type"`
+	SalaryRange      []string  `json:"salary_range"`
+	Slug             string    `json:"slug"`
+	PublicLink       string    `json:"public_link"`
+	ShortLink        string    `json:"short_link"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+func newPublicJob(job models.Job, org models.Organization) PublicJob {
+	return PublicJob{
+		ID:               job.ID,
+		OrganizationID:   job.OrganizationID,
+		OrganizationName: org.Name,
+		Title:            job.Title,
+		Description:      job.Description,
+		Location:         job.Location,
+		ExperienceLevel:  job.ExperienceLevel,
+		SkillsRequired:   job.SkillsRequired,
+		EmploymentType:   job.EmploymentType,
+		SalaryRange:      job.SalaryRange,
+		Slug:             job.Slug,
+		PublicLink:       job.PublicLink,
+		ShortLink:        job.ShortLink,
+		CreatedAt:        job.CreatedAt,
+	}
+}
+
+func openJobs() *gorm.DB {
+	return db.DB.Where("status = ?", JobStatusOpen)
+}
+
+// openJob finds an open job by its id or its slug.
+func openJob(ref string) *gorm.DB {
+	if uuid.Validate(ref) == nil {
+		return openJobs().Where("id = ?", ref)
+	}
+	return openJobs().Where("slug = ?", ref)
+}
+
+// GetPublicJob returns an open job by id or slug. orgID is optional and,
+// when given, must match the job, which is how the long public link is
+// resolved.
+func (s *JobBoardService) GetPublicJob(orgID, jobRef string) (gin.H, int) {
+	query := openJob(jobRef)
+	if orgID != "" {
+		query = query.Scopes(ForOrganization(orgID))
+	}
+
+	var job models.Job
+	if err := query.First(&job).Error; err != nil {
+		return gin.H{"error": "Job not found"}, http.StatusNotFound
+	}
+
+	var org models.Organization
+	if err := db.DB.Where("id = ?", job.OrganizationID).First(&org).Error; err != nil {
+		return gin.H{"error": "Job not found"}, http.StatusNotFound
+	}
+
+	return gin.H{"job": newPublicJob(job, org)}, http.StatusOK
+}
+
+func (s *JobBoardService) ListOrganizationJobs(orgID string) (gin.H, int) {
+	var org models.Organization
+	if err := db.DB.Where("id = ?", orgID).First(&org).Error; err != nil {
+		return gin.H{"error": "Organization not found"}, http.StatusNotFound
+	}
+
+	var jobs []models.Job
+	if err := openJobs().Scopes(ForOrganization(orgID)).Order("created_at desc").Find(&jobs).Error; err != nil {
+		return gin.H{"error": "Failed to list jobs"}, http.StatusInternalServerError
+	}
+
+	publicJobs := make([]PublicJob, 0, len(jobs))
+	for _, job := range jobs {
+		publicJobs = append(publicJobs, newPublicJob(job, org))
+	}
+
+	return gin.H{"organization": gin.H{"id": org.ID, "name": org.Name}, "jobs": publicJobs}, http.StatusOK
+}
+
+type ApplyRequest struct {
+	FullName string `form:"full_name" binding:"required,singleline"`
+	Email    string `form:"email" binding:"required,email"`
+	Phone    string `form:"phone"`
+	LinkedIn string `form:"linkedin"`
+	GitHub   string `form:"github"`
+	Location string `form:"location"`
+}
+
+// UploadedFile pairs a multipart file with its header.
+type UploadedFile struct {
+	File   multipart.File
+	Header *multipart.FileHeader
+}
+
+// Apply records an application from an anonymous candidate to the open job
+// with the given id or slug. Candidates are matched by email within the
+// job's organization so repeat applicants keep a single profile. Since the
+// email proves nothing, Apply only ever creates applications: it never adds
+// documents to an existing one or to a candidate a recruiter added, and
+// answers 409 instead.
+func (s *JobBoardService) Apply(ctx context.Context, jobRef string, req ApplyRequest, resume UploadedFile, coverLetter *UploadedFile) (gin.H, int) {
+	var job models.Job
+	if err := openJob(jobRef).First(&job).Error; err != nil {
+		return gin.H{"error": "Job not found"}, http.StatusNotFound
+	}
+
+	// Check both files up front so a bad cover letter can't leave a
+	// half-submitted application behind.
+	resumeType, err := s.applications.checkDocument(resume.File, resume.Header)
+	if err != nil {
+		return rejectedUpload(err, "resume")
+	}
+	if coverLetter != nil {
+		if _, err := s.applications.checkDocument(coverLetter.File, coverLetter.Header); err != nil {
+			return rejectedUpload(err, "cover letter")
+		}
+	}
+
+	uploadReq := UploadDocumentRequest{
+		JobID:    job.ID,
+		FullName: req.FullName,
+		Email:    req.Email,
+		Phone:    req.Phone,
+		LinkedIn: req.LinkedIn,
+		GitHub:   req.GitHub,
+		Location: req.Location,
+	}
+	alreadyApplied := gin.H{"error": "An application with this email already exists"}
+
+	candidate, isNew, err := resolveCandidate(job.OrganizationID, uploadReq, nil)
+	if err != nil {
+		log.Printf("Public application for job %s failed to resolve candidate: %v", job.ID, err)
+		return gin.H{"error": "Failed to process resume file"}, http.StatusInternalServerError
+	}
+	if !isNew && candidate.UserID != nil {
+		return alreadyApplied, http.StatusConflict
+	}
+
+	application, _, err := s.applications.storeDocument(ctx, &job, candidate, isNew, documentResume, resumeType, resume.File, resume.Header.Filename, nil, true)
+	if errors.Is(err, errApplicationExists) || errors.Is(err, ErrCandidateExists) {
+		return alreadyApplied, http.StatusConflict
+	}
+	if err != nil {
+		log.Printf("Public application for job %s failed to store resume: %v", job.ID, err)
+		return gin.H{"error": "Failed to process resume file"}, http.StatusInternalServerError
+	}
+
+	if coverLetter != nil {
+		uploadReq.CandidateID = application.CandidateID
+		if _, err := s.applications.saveDocument(ctx, &job, uploadReq, nil, documentCoverLetter, coverLetter.File, coverLetter.Header); err != nil {
+			log.Printf("Public application for job %s failed to store cover letter: %v", job.ID, err)
+			return gin.H{"error": "Failed to process cover letter file"}, http.StatusInternalServerError
+		}
+	}
+
+	return gin.H{"message": "Application submitted successfully", "application_id": application.ID}, http.StatusCreated
+}
+
+// rejectedUpload answers a failed checkDocument. what names the file in the
+// message, e.g. "resume".
+func rejectedUpload(err error, what string) (gin.H, int) {
+	var rejected *upload.Error
+	switch {
+	case errors.As(err, &rejected):
+		return gin.H{"error": "Invalid " + what + ": " + rejected.Message, "code": rejected.Code}, rejected.Status
+	case errors.Is(err, ErrArchiveNotAllowed):
+		return gin.H{"error": "Please upload a single " + what + " file, not an archive"}, http.StatusBadRequest
+	}
+	return gin.H{"error": "Failed to read " + what}, http.StatusInternalServerError
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/resumelens/authservice/internal/config"
+	"github.com/resumelens/authservice/internal/db"
+	"github.com/resumelens/authservice/internal/models"
+	"github.com/resumelens/authservice/internal/queue"
+	"github.com/resumelens/authservice/internal/storage"
+)
+
+// uploadedFile builds the UploadedFile a multipart form with one file field
+// parses into.
+func uploadedFile(t *testing.T, filename, content string) UploadedFile {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreateFormFile("resume", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write([]byte(content))
+	w.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	file, header, err := req.FormFile("resume")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { file.Close() })
+	return UploadedFile{File: file, Header: header}
+}
+
+// TestApplyNeverReusesApplications checks that a public application, which
+// anyone knowing an email address can send, can't replace the documents of
+// an existing application or reach a candidate a recruiter added.
+func TestApplyNeverReusesApplications(t *testing.T) {
+	testDB(t)
+	store := storage.NewMemoryStore()
+	q := queue.New(db.DB, queue.Options{})
+	applications := NewJobApplicationService(&config.Config{UploadMaxFileMB: 1, MagicLinkExpiryDays: 7}, store, q)
+	board := NewJobBoardService(applications)
+	ctx := context.Background()
+	f := createTenant(t, store)
+	t.Cleanup(func() {
+		db.DB.Where("application_id IN (?)", db.DB.Model(&models.JobApplication{}).Select("id").Where("job_id = ?", f.job.ID)).
+			Delete(&models.ApplicationStageHistory{})
+	})
+
+	currentResume := func(email string) (string, string) {
+		t.Helper()
+		var app models.JobApplication
+		err := db.DB.Scopes(ApplicationsForOrganization(f.caller.OrganizationID)).
+			Joins("JOIN candidates ON candidates.id = job_applications.candidate_id").
+			Where("job_applications.job_id = ? AND lower(candidates.email) = ?", f.job.ID, email).
+			First(&app).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+		return app.ID, *app.CurrentResumeID
+	}
+
+	apply := func(email, resume string) (map[string]interface{}, int) {
+		req := ApplyRequest{FullName: "Applicant", Email: email}
+		return board.Apply(ctx, f.job.Slug, req, uploadedFile(t, "resume.txt", resume), nil)
+	}
+
+	response, status := apply("new@example.com", "Jane Doe, Go engineer")
+	if status != http.StatusCreated {
+		t.Fatalf("first Apply = %d %v; want 201", status, response)
+	}
+	appID, resumeID := currentResume("new@example.com")
+
+	response, status = apply("NEW@example.com", "Mallory's resume")
+	if status != http.StatusConflict || response["application_id"] != nil {
+		t.Errorf("second Apply = %d %v; want 409 without an application id", status, response)
+	}
+	if id, current := currentResume("new@example.com"); id != appID || current != resumeID {
+		t.Errorf("application %s now has resume %s; want %s unchanged", id, current, resumeID)
+	}
+	var versions int64
+	db.DB.Model(&models.DocumentVersion{}).Where("application_id = ?", appID).Count(&versions)
+	if versions != 1 {
+		t.Errorf("application has %d document versions; want 1", versions)
+	}
+
+	// The fixture's candidate already applied, so another application from
+	// their email is refused the same way.
+	if _, status := apply(f.candidate.Email, "Mallory's resume"); status != http.StatusConflict {
+		t.Errorf("Apply for the fixture candidate = %d; want 409", status)
+	}
+	if _, current := currentResume(f.candidate.Email); current != f.document.ID {
+		t.Errorf("fixture application now has resume %s; want %s", current, f.document.ID)
+	}
+
+	// A candidate a recruiter added gets no new application either.
+	sourced := models.Candidate{ID: uuid.NewString(), OrganizationID: f.caller.OrganizationID, UserID: &f.caller.UserID, FullName: "Sourced", Email: "sourced@example.com"}
+	if err := db.DB.Create(&sourced).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, status := apply("sourced@example.com", "Mallory's resume"); status != http.StatusConflict {
+		t.Errorf("Apply for a sourced candidate = %d; want 409", status)
+	}
+	var count int64
+	db.DB.Model(&models.JobApplication{}).Where("candidate_id = ?", sourced.ID).Count(&count)
+	if count != 0 {
+		t.Errorf("sourced candidate has %d applications; want 0", count)
+	}
+}
@@ -3,78 +3,219 @@ package services
 import (
 	"fmt"
 	"net/http"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/resumelens/authservice/internal/config"
 	"github.com/resumelens/authservice/internal/db"
 	"github.com/resumelens/authservice/internal/models"
+	"github.com/resumelens/authservice/internal/queue"
+	"gorm.io/gorm"
 )
 
 type JobHostingService struct {
 	config *config.Config
+	queue  *queue.Queue
 }
 
-func NewJobHostingService(cfg *config.Config) *JobHostingService {
-	return &JobHostingService{config: cfg}
+func NewJobHostingService(cfg *config.Config, q *queue.Queue) *JobHostingService {
+	return &JobHostingService{config: cfg, queue: q}
 }
 
 type CreateJobRequest struct {
 	Title           string   `json:"title" binding:"required"`
-	OrganizationID  string   `json:"organization_id" binding:"required"`
-	CreatedByID     string   `json:"created_by_id" binding:"required"`
 	Description     string   `json:"description" binding:"required"`
 	Location        []string `json:"location" binding:"required"`
 	ExperienceLevel string   `json:"experience_level" binding:"required"`
 	SkillsRequired  []string `json:"skills_required" binding:"required"`
 	EmploymentType  []string `json:"employment_type" binding:"required"`
 	SalaryRange     []string `json:"salary_range" binding:"required"`
-	IsActive        bool     `json:"is_active" binding:"required"`
+	Status          string   `json:"status" binding:"omitempty,oneof=draft open"`
 }
 
-func (s *JobHostingService) CreateJob(req CreateJobRequest) (gin.H, int) {
+func (s *JobHostingService) CreateJob(caller Caller, req CreateJobRequest) (gin.H, int) {
 	var org models.Organization
 	var job models.Job
 
-	if err := db.DB.Where("id = ?", req.OrganizationID).First(&org).Error; err != nil {
-		return gin.H{"error": "User not found"}, http.StatusNotFound
+	if err := db.DB.Where("id = ?", caller.OrganizationID).First(&org).Error; err != nil {
+		return gin.H{"error": "Organization not found"}, http.StatusNotFound
 	}
 
 	job.Title = req.Title
-	job.OrganizationID = req.OrganizationID
-	job.CreatedByID = req.CreatedByID
+	job.OrganizationID = caller.OrganizationID
+	job.CreatedByID = caller.UserID
 	job.Description = req.Description
 	job.Location = req.Location
 	job.ExperienceLevel = req.ExperienceLevel
 	job.SkillsRequired = req.SkillsRequired
 	job.EmploymentType = req.EmploymentType
 	job.SalaryRange = req.SalaryRange
-	job.IsActive = req.IsActive
+	job.Status = req.Status
+	if job.Status == "" {
+		job.Status = JobStatusOpen
+	}
 	job.ApplicationCount = 0
 	job.CreatedAt = time.Now()
 
-	if err := db.DB.Create(&job).Error; err != nil {
-		return gin.H{"error": err.Error()}, http.StatusInternalServerError
-	}
-
+	job.ID = uuid.NewString()
+	job.Slug = jobSlug(job.Title, job.ID)
 	job.PublicLink = fmt.Sprintf("https://resumelens.com/job/%s/%s", job.OrganizationID, job.ID)
 	job.ShortLink = fmt.Sprintf("https://resumelens.com/job/%s", job.ID)
 
-	if err := db.DB.Model(&job).Updates(map[string]interface{}{
-		"public_link": job.PublicLink,
-		"short_link":  job.ShortLink,
-	}).Error; err != nil {
-		return gin.H{"error": err.Error()}, http.StatusInternalServerError
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&job).Error; err != nil {
+			return err
+		}
+		return recordJobAudit(tx, caller, &job, "create", nil)
+	})
+	if err != nil {
+		return gin.H{"error": "Failed to create job"}, http.StatusInternalServerError
 	}
 
-	return gin.H{"message": "Job created successfully"}, http.StatusOK
+	return gin.H{"message": "Job created successfully", "job": job}, http.StatusOK
 }
 
-func (s *JobHostingService) GetJob(id string) (gin.H, int) {
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// jobSlug makes the public slug of a job: its title in lowercase words
+// joined by hyphens, then the first block of its id so slugs are unique.
+// The backfill in db.migrateDatabase computes the same thing in SQL.
+func jobSlug(title, id string) string {
+	slug := strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(title), "-"), "-")
+	if len(slug) > 60 {
+		slug = strings.Trim(slug[:60], "-")
+	}
+	if slug == "" {
+		slug = "job"
+	}
+	return slug + "-" + id[:8]
+}
+
+func (s *JobHostingService) GetJob(caller Caller, id string) (gin.H, int) {
 	var job models.Job
-	if err := db.DB.Where("id = ?", id).First(&job).Error; err != nil {
+	if err := db.DB.Scopes(ForOrganization(caller.OrganizationID)).Where("id = ?", id).First(&job).Error; err != nil {
 		return gin.H{"error": "Job not found"}, http.StatusNotFound
 	}
 
 	return gin.H{"job": job}, http.StatusOK
 }
+
+const (
+	defaultJobPageSize = 20
+	maxJobPageSize     = 100
+)
+
+// jobSearchVector must match the expression of idx_jobs_search created in
+// db.migrateDatabase, otherwise Postgres won't use the index.
+const jobSearchVector = "to_tsvector('english', coalesce(jobs.title, '') || ' ' || coalesce(jobs.description, ''))"
+
+type ListJobsRequest struct {
+	Status          []string `form:"status"`
+	ExperienceLevel string   `form:"experience_level"`
+	EmploymentType  []string `form:"employment_type"`
+	Location        []string `form:"location"`
+	Skills          []string `form:"skills"`
+	Query           string   `form:"q"`
+	Sort            string   `form:"sort" binding:"omitempty,oneof=created_at application_count"`
+	Order           string   `form:"order" binding:"omitempty,oneof=asc desc"`
+	Limit           int      `form:"limit" binding:"omitempty,min=1"`
+	Cursor          string   `form:"cursor"`
+}
+
+// ListJobs returns a page of the caller's organization's jobs. Array filters
+// match when a job shares at least one value with the filter, except skills
+// where the job must require every listed skill.
+func (s *JobHostingService) ListJobs(caller Caller, req ListJobsRequest) (gin.H, int) {
+	sortBy := req.Sort
+	if sortBy == "" {
+		sortBy = "created_at"
+	}
+	order := req.Order
+	if order == "" {
+		order = "desc"
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultJobPageSize
+	}
+	if limit > maxJobPageSize {
+		limit = maxJobPageSize
+	}
+
+	query := db.DB.Model(&models.Job{}).Scopes(ForOrganization(caller.OrganizationID))
+
+	if len(req.Status) > 0 {
+		query = query.Where("jobs.status IN ?", req.Status)
+	}
+	if req.ExperienceLevel != "" {
+		query = query.Where("jobs.experience_level = ?", req.ExperienceLevel)
+	}
+	if len(req.EmploymentType) > 0 {
+		query = query.Where("jobs.employment_type && ?", pq.StringArray(req.EmploymentType))
+	}
+	if len(req.Location) > 0 {
+		query = query.Where("jobs.location && ?", pq.StringArray(req.Location))
+	}
+	if len(req.Skills) > 0 {
+		query = query.Where("jobs.skills_required @> ?", pq.StringArray(req.Skills))
+	}
+	if req.Query != "" {
+		query = query.Where(jobSearchVector+" @@ websearch_to_tsquery('english', ?)", req.Query)
+	}
+
+	comparator := "<"
+	if order == "asc" {
+		comparator = ">"
+	}
+	if req.Cursor != "" {
+		var err error
+		var afterID string
+		switch sortBy {
+		case "created_at":
+			var after time.Time
+			if afterID, err = decodeCursor(req.Cursor, sortBy, order, &after); err == nil {
+				query = query.Where("(jobs.created_at, jobs.id) "+comparator+" (?, ?)", after, afterID)
+			}
+		case "application_count":
+			var after int
+			if afterID, err = decodeCursor(req.Cursor, sortBy, order, &after); err == nil {
+				query = query.Where("(jobs.application_count, jobs.id) "+comparator+" (?, ?)", after, afterID)
+			}
+		}
+		if err != nil {
+			return gin.H{"error": "Invalid cursor"}, http.StatusBadRequest
+		}
+	}
+
+	var jobs []models.Job
+	if err := query.
+		Order(fmt.Sprintf("jobs.%s %s, jobs.id %s", sortBy, order, order)).
+		Limit(limit + 1).
+		Find(&jobs).Error; err != nil {
+		return gin.H{"error": "Failed to list jobs"}, http.StatusInternalServerError
+	}
+
+	var nextCursor string
+	if len(jobs) > limit {
+		jobs = jobs[:limit]
+		last := jobs[len(jobs)-1]
+		var sortValue interface{} = last.CreatedAt
+		if sortBy == "application_count" {
+			sortValue = last.ApplicationCount
+		}
+		cursor, err := encodeCursor(sortBy, order, sortValue, last.ID)
+		if err != nil {
+			return gin.H{"error": "Failed to build cursor"}, http.StatusInternalServerError
+		}
+		nextCursor = cursor
+	}
+
+	return gin.H{
+		"jobs":        jobs,
+		"next_cursor": nextCursor,
+	}, http.StatusOK
+}
@@ -0,0 +1,22 @@
+package services
+
+import "testing"
+
+func TestJobSlug(t *testing.T) {
+	const id = "3f2a9c1b-0d4e-4f6a-8b7c-9d0e1f2a3b4c"
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Senior Go Engineer", "senior-go-engineer-3f2a9c1b"},
+		{"  C++ / Rust developer (Remote!) ", "c-rust-developer-remote-3f2a9c1b"},
+		{"Ingénieur logiciel", "ing-nieur-logiciel-3f2a9c1b"},
+		{"¿?", "job-3f2a9c1b"},
+		{"An extremely long job title that goes on and on well past the sixty character limit", "an-extremely-long-job-title-that-goes-on-and-on-well-past-th-3f2a9c1b"},
+	}
+	for _, tt := range tests {
+		if got := jobSlug(tt.title, id); got != tt.want {
+			t.Errorf("jobSlug(%q) = %q; want %q", tt.title, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"github.com/resumelens/authservice/internal/db"
+	"github.com/resumelens/authservice/internal/models"
+	"gorm.io/gorm"
+)
+
+// Job states. Only open jobs accept applications.
+const (
+	JobStatusDraft    = "draft"
+	JobStatusOpen     = "open"
+	JobStatusPaused   = "paused"
+	JobStatusClosed   = "closed"
+	JobStatusArchived = "archived"
+)
+
+// jobTransitions lists the states each state may move to. Archived is
+// terminal; a closed job can be reopened.
+var jobTransitions = map[string][]string{
+	JobStatusDraft:  {JobStatusOpen, JobStatusArchived},
+	JobStatusOpen:   {JobStatusPaused, JobStatusClosed},
+	JobStatusPaused: {JobStatusOpen, JobStatusClosed},
+	JobStatusClosed: {JobStatusOpen, JobStatusArchived},
+}
+
+func canTransitionJob(from, to string) bool {
+	for _, allowed := range jobTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateJobRequest is a partial update; nil fields are left untouched.
+type UpdateJobRequest struct {
+	Title           *string   `json:"title" binding:"omitempty,min=1"`
+	Description     *string   `json:"description" binding:"omitempty,min=1"`
+	Location        *[]string `json:"location"`
+	ExperienceLevel *string   `json:"experience_level"`
+	SkillsRequired  *[]string `json:"skills_required"`
+	EmploymentType  *[]string `json:"employment_type"`
+	SalaryRange     *[]string `json:"salary_range"`
+}
+
+type fieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+func (s *JobHostingService) UpdateJob(caller Caller, id string, req UpdateJobRequest) (gin.H, int) {
+	var job models.Job
+	var changes map[string]fieldChange
+	var rescoring bool
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Scopes(ForOrganization(caller.OrganizationID)).Where("id = ?", id).First(&job).Error; err != nil {
+			return err
+		}
+		if job.Status == JobStatusArchived {
+			return errJobArchived
+		}
+
+		updates, diff := diffJobUpdate(&job, req)
+		changes = diff
+		if len(updates) == 0 {
+			return nil
+		}
+
+		if err := tx.Model(&job).Updates(updates).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&job, "id = ?", job.ID).Error; err != nil {
+			return err
+		}
+		if err := recordJobAudit(tx, caller, &job, "update", changes); err != nil {
+			return err
+		}
+
+		for _, field := range scoredFields {
+			if _, ok := changes[field]; ok {
+				rescoring = true
+			}
+		}
+		if rescoring {
+			return s.queue.Enqueue(tx, job.OrganizationID, TaskRescoreJob, jobTask{JobID: job.ID})
+		}
+		return nil
+	})
+
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return gin.H{"error": "Job not found"}, http.StatusNotFound
+	case errors.Is(err, errJobArchived):
+		return gin.H{"error": "Archived jobs cannot be edited"}, http.StatusConflict
+	case err != nil:
+		return gin.H{"error": "Failed to update job"}, http.StatusInternalServerError
+	}
+
+	return gin.H{"message": "Job updated successfully", "job": job, "changes": changes, "rescoring": rescoring}, http.StatusOK
+}
+
+var errJobArchived = errors.New("job is archived")
+
+type ChangeJobStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=draft open paused closed archived"`
+}
+
+// ChangeJobStatus moves a job to a new state if jobTransitions allows it.
+func (s *JobHostingService) ChangeJobStatus(caller Caller, id string, req ChangeJobStatusRequest) (gin.H, int) {
+	var job models.Job
+	var from string
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Scopes(ForOrganization(caller.OrganizationID)).Where("id = ?", id).First(&job).Error; err != nil {
+			return err
+		}
+		from = job.Status
+		if !canTransitionJob(from, req.Status) {
+			return errInvalidTransition
+		}
+
+		// Guard against a concurrent transition having moved the job first.
+		result := tx.Model(&models.Job{}).
+			Where("id = ? AND status = ?", job.ID, from).
+			Update("status", req.Status)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errInvalidTransition
+		}
+		job.Status = req.Status
+
+		return recordJobAudit(tx, caller, &job, "status", map[string]fieldChange{
+			"status": {From: from, To: req.Status},
+		})
+	})
+
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return gin.H{"error": "Job not found"}, http.StatusNotFound
+	case errors.Is(err, errInvalidTransition):
+		return gin.H{
+			"error":   "Invalid status transition",
+			"from":    from,
+			"to":      req.Status,
+			"allowed": jobTransitions[from],
+		}, http.StatusConflict
+	case err != nil:
+		return gin.H{"error": "Failed to change job status"}, http.StatusInternalServerError
+	}
+
+	return gin.H{"message": "Job status updated", "job": job}, http.StatusOK
+}
+
+var errInvalidTransition = errors.New("invalid status transition")
+
+// DeleteJob soft deletes a job. The row is kept for audit and analytics but
+// disappears from every query.
+func (s *JobHostingService) DeleteJob(caller Caller, id string) (gin.H, int) {
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var job models.Job
+		if err := tx.Scopes(ForOrganization(caller.OrganizationID)).Where("id = ?", id).First(&job).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&job).Error; err != nil {
+			return err
+		}
+		return recordJobAudit(tx, caller, &job, "delete", nil)
+	})
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return gin.H{"error": "Job not found"}, http.StatusNotFound
+	}
+	if err != nil {
+		return gin.H{"error": "Failed to delete job"}, http.StatusInternalServerError
+	}
+
+	return gin.H{"message": "Job deleted successfully"}, http.StatusOK
+}
+
+// GetJobAudit returns the change history of a job, newest first. It still
+// works for deleted jobs.
+func (s *JobHostingService) GetJobAudit(caller Caller, id string) (gin.H, int) {
+	var job models.Job
+	if err := db.DB.Unscoped().Scopes(ForOrganization(caller.OrganizationID)).Where("id = ?", id).First(&job).Error; err != nil {
+		return gin.H{"error": "Job not found"}, http.StatusNotFound
+	}
+
+	var entries []models.JobAuditLog
+	if err := db.DB.Where("job_id = ?", job.ID).Order("created_at desc").Find(&entries).Error; err != nil {
+		return gin.H{"error": "Failed to load job audit"}, http.StatusInternalServerError
+	}
+
+	return gin.H{"audit": entries}, http.StatusOK
+}
+
+// diffJobUpdate returns the column updates implied by req and the
+// corresponding before/after values for the audit log.
+func diffJobUpdate(job *models.Job, req UpdateJobRequest) (map[string]interface{}, map[string]fieldChange) {
+	updates := make(map[string]interface{})
+	changes := make(map[string]fieldChange)
+
+	setString := func(column string, current string, next *string) {
+		if next != nil && *next != current {
+			updates[column] = *next
+			changes[column] = fieldChange{From: current, To: *next}
+		}
+	}
+	setArray := func(column string, current pq.StringArray, next *[]string) {
+		if next != nil && !equalStrings(current, *next) {
+			updates[column] = pq.StringArray(*next)
+			changes[column] = fieldChange{From: []string(current), To: *next}
+		}
+	}
+
+	setString("title", job.Title, req.Title)
+	setString("description", job.Description, req.Description)
+	setString("experience_level", job.ExperienceLevel, req.ExperienceLevel)
+	setArray("location", job.Location, req.Location)
+	setArray("skills_required", job.SkillsRequired, req.SkillsRequired)
+	setArray("employment_type", job.EmploymentType, req.EmploymentType)
+	setArray("salary_range", job.SalaryRange, req.SalaryRange)
+
+	return updates, changes
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func recordJobAudit(tx *gorm.DB, caller Caller, job *models.Job, action string, changes map[string]fieldChange) error {
+	entry := models.JobAuditLog{
+		JobID:          job.ID,
+		OrganizationID: job.OrganizationID,
+		ActorID:        caller.UserID,
+		Action:         action,
+		CreatedAt:      time.Now(),
+	}
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+	entry.Changes = string(data)
+	return tx.Create(&entry).Error
+}
@@ -1,8 +1,13 @@
 package services
 
 import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
 	"github.com/resumelens/authservice/internal/db"
 	"github.com/resumelens/authservice/internal/models"
+	"gorm.io/gorm"
 )
 
 // Permission constants
@@ -13,10 +18,82 @@ const (
 	PermissionIAM       = "IamPermission"
 )
 
-type PermissionService struct{}
+// roleCacheTTL bounds how long a permission change can take to reach
+// requests authenticated with an existing token.
+const roleCacheTTL = 5 * time.Minute
+
+// maxCachedRoles caps the role cache. Roles are few per organization, so
+// the cap is only reached across many organizations.
+const maxCachedRoles = 1024
+
+type cachedRole struct {
+	role      models.Role
+	expiresAt time.Time
+}
+
+type PermissionService struct {
+	mu    sync.RWMutex
+	roles map[string]cachedRole
+}
 
 func NewPermissionService() *PermissionService {
-	return &PermissionService{}
+	return &PermissionService{roles: make(map[string]cachedRole)}
+}
+
+// GetRole loads a role by id, serving it from an in-process cache when
+// possible. An empty or malformed id is reported as gorm.ErrRecordNotFound
+// without a query.
+func (s *PermissionService) GetRole(roleID string) (*models.Role, error) {
+	if uuid.Validate(roleID) != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	s.mu.RLock()
+	cached, ok := s.roles[roleID]
+	s.mu.RUnlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		role := cached.role
+		return &role, nil
+	}
+
+	var role models.Role
+	if err := db.DB.First(&role, "id = ?", roleID).Error; err != nil {
+		return nil, err
+	}
+
+	s.cacheRole(roleID, role)
+	return &role, nil
+}
+
+// cacheRole stores role under roleID. A full cache first drops its expired
+// entries, then arbitrary ones until there is room.
+func (s *PermissionService) cacheRole(roleID string, role models.Role) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.roles[roleID]; !ok && len(s.roles) >= maxCachedRoles {
+		now := time.Now()
+		for id, cached := range s.roles {
+			if !now.Before(cached.expiresAt) {
+				delete(s.roles, id)
+			}
+		}
+		for id := range s.roles {
+			if len(s.roles) < maxCachedRoles {
+				break
+			}
+			delete(s.roles, id)
+		}
+	}
+	s.roles[roleID] = cachedRole{role: role, expiresAt: time.Now().Add(roleCacheTTL)}
+}
+
+// InvalidateRole drops a role from the cache so the next lookup hits the
+// database.
+func (s *PermissionService) InvalidateRole(roleID string) {
+	s.mu.Lock()
+	delete(s.roles, roleID)
+	s.mu.Unlock()
 }
 
 // CheckRolePermission checks if a role has a specific permission.
@@ -26,23 +103,12 @@ func (s *PermissionService) CheckRolePermission(roleID string, permission string
 	}
 
 	// Fetch the role
-	var role models.Role
-	if err := db.DB.First(&role, "id = ?", roleID).Error; err != nil {
+	role, err := s.GetRole(roleID)
+	if err != nil {
 		return false, err
 	}
 
-	switch permission {
-	case PermissionHome:
-		return role.HomePermission, nil
-	case PermissionCreateJob:
-		return role.CreateJobPermission, nil
-	case PermissionViewJob:
-		return role.ViewJobPermission, nil
-	case PermissionIAM:
-		return role.IamPermission, nil
-	default:
-		return false, nil
-	}
+	return HasPermission(role, permission), nil
 }
 
 // GetUserPermissions returns all permissions for a user's role
@@ -51,8 +117,8 @@ func (s *PermissionService) GetUserPermissions(roleID string) (map[string]bool,
 		return make(map[string]bool), nil
 	}
 
-	var role models.Role
-	if err := db.DB.First(&role, "id = ?", roleID).Error; err != nil {
+	role, err := s.GetRole(roleID)
+	if err != nil {
 		return nil, err
 	}
 
@@ -63,3 +129,19 @@ func (s *PermissionService) GetUserPermissions(roleID string) (map[string]bool,
 		PermissionIAM:       role.IamPermission,
 	}, nil
 }
+
+// HasPermission reports whether role grants the named permission.
+func HasPermission(role *models.Role, permission string) bool {
+	switch permission {
+	case PermissionHome:
+		return role.HomePermission
+	case PermissionCreateJob:
+		return role.CreateJobPermission
+	case PermissionViewJob:
+		return role.ViewJobPermission
+	case PermissionIAM:
+		return role.IamPermission
+	default:
+		return false
+	}
+}
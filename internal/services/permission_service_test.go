@@ -0,0 +1,48 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/resumelens/authservice/internal/models"
+	"gorm.io/gorm"
+)
+
+func TestGetRoleInvalidID(t *testing.T) {
+	s := NewPermissionService()
+	// No database is connected, so these must not reach it.
+	for _, roleID := range []string{"", "admin", "not-a-uuid-at-all-but-36-characters"} {
+		if _, err := s.GetRole(roleID); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("GetRole(%q) err = %v; want gorm.ErrRecordNotFound", roleID, err)
+		}
+	}
+}
+
+func TestRoleCacheBounded(t *testing.T) {
+	s := NewPermissionService()
+	for i := 0; i < 3*maxCachedRoles; i++ {
+		id := uuid.NewString()
+		s.cacheRole(id, models.Role{ID: id})
+	}
+	if len(s.roles) > maxCachedRoles {
+		t.Fatalf("cache holds %d roles; want at most %d", len(s.roles), maxCachedRoles)
+	}
+
+	// Expired entries go first.
+	var expired string
+	for id := range s.roles {
+		expired = id
+		s.roles[id] = cachedRole{role: models.Role{ID: id}, expiresAt: time.Now().Add(-time.Second)}
+		break
+	}
+	fresh := uuid.NewString()
+	s.cacheRole(fresh, models.Role{ID: fresh})
+	if _, ok := s.roles[expired]; ok {
+		t.Error("expired role was kept")
+	}
+	if _, ok := s.roles[fresh]; !ok || len(s.roles) != maxCachedRoles {
+		t.Errorf("fresh role cached = %v, size = %d; want true, %d", ok, len(s.roles), maxCachedRoles)
+	}
+}
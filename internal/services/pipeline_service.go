@@ -0,0 +1,354 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"github.com/resumelens/authservice/internal/db"
+	"github.com/resumelens/authservice/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Built-in stage keys. StageWithdrawn is special: candidates can always move
+// their own non-terminal application there, whatever the workflow says.
+const (
+	StageApplied   = "applied"
+	StageScreening = "screening"
+	StageInterview = "interview"
+	StageOffer     = "offer"
+	StageHired     = "hired"
+	StageRejected  = "rejected"
+	StageWithdrawn = "withdrawn"
+)
+
+const maxBulkStageMove = 500
+
+var (
+	ErrInvalidStage           = errors.New("invalid stage")
+	ErrStageTransitionBlocked = errors.New("stage transition not allowed")
+)
+
+// PipelineStageConfig describes a stage when configuring or reading a
+// workflow.
+type PipelineStageConfig struct {
+	Key         string   `json:"key" binding:"required"`
+	Name        string   `json:"name" binding:"required"`
+	AllowedNext []string `json:"allowed_next"`
+	IsTerminal  bool     `json:"is_terminal"`
+}
+
+// defaultPipeline is used by organizations that never configured their own.
+var defaultPipeline = []PipelineStageConfig{
+	{Key: StageApplied, Name: "Applied", AllowedNext: []string{StageScreening, StageRejected, StageWithdrawn}},
+	{Key: StageScreening, Name: "Screening", AllowedNext: []string{StageInterview, StageRejected, StageWithdrawn}},
+	{Key: StageInterview, Name: "Interview", AllowedNext: []string{StageOffer, StageRejected, StageWithdrawn}},
+	{Key: StageOffer, Name: "Offer", AllowedNext: []string{StageHired, StageRejected, StageWithdrawn}},
+	{Key: StageHired, Name: "Hired", IsTerminal: true},
+	{Key: StageRejected, Name: "Rejected", IsTerminal: true},
+	{Key: StageWithdrawn, Name: "Withdrawn", IsTerminal: true},
+}
+
+type PipelineService struct{}
+
+func NewPipelineService() *PipelineService {
+	return &PipelineService{}
+}
+
+// pipelineFor resolves the workflow for a job: its own stages if it has any,
+// else the organization's, else the default pipeline. The first stage is the
+// one new applications enter.
+func pipelineFor(tx *gorm.DB, orgID string, jobID string) ([]PipelineStageConfig, error) {
+	var stages []models.PipelineStage
+	if jobID != "" {
+		if err := tx.Where("organization_id = ? AND job_id = ?", orgID, jobID).Order("position").Find(&stages).Error; err != nil {
+			return nil, err
+		}
+	}
+	if len(stages) == 0 {
+		if err := tx.Where("organization_id = ? AND job_id IS NULL", orgID).Order("position").Find(&stages).Error; err != nil {
+			return nil, err
+		}
+	}
+	if len(stages) == 0 {
+		return defaultPipeline, nil
+	}
+
+	configs := make([]PipelineStageConfig, 0, len(stages))
+	for _, stage := range stages {
+		configs = append(configs, PipelineStageConfig{
+			Key:         stage.Key,
+			Name:        stage.Name,
+			AllowedNext: stage.AllowedNext,
+			IsTerminal:  stage.IsTerminal,
+		})
+	}
+	return configs, nil
+}
+
+func findStage(pipeline []PipelineStageConfig, key string) *PipelineStageConfig {
+	for i := range pipeline {
+		if pipeline[i].Key == key {
+			return &pipeline[i]
+		}
+	}
+	return nil
+}
+
+func validatePipeline(stages []PipelineStageConfig) error {
+	if len(stages) == 0 {
+		return fmt.Errorf("at least one stage is required")
+	}
+	keys := make(map[string]bool, len(stages))
+	for _, stage := range stages {
+		if keys[stage.Key] {
+			return fmt.Errorf("duplicate stage key %q", stage.Key)
+		}
+		keys[stage.Key] = true
+	}
+	// Candidates can withdraw from any stage through the portal, so every
+	// workflow needs somewhere for them to go that recruiters can't move
+	// them out of.
+	if withdrawn := findStage(stages, StageWithdrawn); withdrawn == nil || !withdrawn.IsTerminal {
+		return fmt.Errorf("a terminal %q stage is required", StageWithdrawn)
+	}
+	for _, stage := range stages {
+		if stage.IsTerminal && len(stage.AllowedNext) > 0 {
+			return fmt.Errorf("terminal stage %q cannot have next stages", stage.Key)
+		}
+		for _, next := range stage.AllowedNext {
+			if !keys[next] {
+				return fmt.Errorf("stage %q allows unknown next stage %q", stage.Key, next)
+			}
+			if next == stage.Key {
+				return fmt.Errorf("stage %q cannot transition to itself", stage.Key)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *PipelineService) GetPipeline(caller Caller, jobID string) (gin.H, int) {
+	if jobID != "" {
+		if _, err := loadJob(caller.OrganizationID, jobID); err != nil {
+			return gin.H{"error": "Job not found"}, http.StatusNotFound
+		}
+	}
+
+	pipeline, err := pipelineFor(db.DB, caller.OrganizationID, jobID)
+	if err != nil {
+		return gin.H{"error": "Failed to load pipeline"}, http.StatusInternalServerError
+	}
+	return gin.H{"stages": pipeline}, http.StatusOK
+}
+
+type ConfigurePipelineRequest struct {
+	Stages []PipelineStageConfig `json:"stages" binding:"required,dive"`
+}
+
+// ConfigurePipeline replaces the organization's workflow, or a single job's
+// when jobID is set. Existing applications keep their current stage key.
+func (s *PipelineService) ConfigurePipeline(caller Caller, jobID string, req ConfigurePipelineRequest) (gin.H, int) {
+	if err := validatePipeline(req.Stages); err != nil {
+		return gin.H{"error": err.Error()}, http.StatusBadRequest
+	}
+
+	var jobRef *string
+	if jobID != "" {
+		if _, err := loadJob(caller.OrganizationID, jobID); err != nil {
+			return gin.H{"error": "Job not found"}, http.StatusNotFound
+		}
+		jobRef = &jobID
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		del := tx.Where("organization_id = ?", caller.OrganizationID)
+		if jobRef != nil {
+			del = del.Where("job_id = ?", *jobRef)
+		} else {
+			del = del.Where("job_id IS NULL")
+		}
+		if err := del.Delete(&models.PipelineStage{}).Error; err != nil {
+			return err
+		}
+
+		for i, stage := range req.Stages {
+			record := models.PipelineStage{
+				OrganizationID: caller.OrganizationID,
+				JobID:          jobRef,
+				Key:            stage.Key,
+				Name:           stage.Name,
+				Position:       i,
+				AllowedNext:    pq.StringArray(stage.AllowedNext),
+				IsTerminal:     stage.IsTerminal,
+				CreatedAt:      time.Now(),
+			}
+			if err := tx.Create(&record).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return gin.H{"error": "Failed to save pipeline"}, http.StatusInternalServerError
+	}
+
+	return gin.H{"message": "Pipeline updated", "stages": req.Stages}, http.StatusOK
+}
+
+type MoveStageRequest struct {
+	Stage string `json:"stage" binding:"required"`
+	Note  string `json:"note"`
+}
+
+func (s *PipelineService) MoveApplication(caller Caller, applicationID string, req MoveStageRequest) (gin.H, int) {
+	var application models.JobApplication
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		app, err := moveApplicationStage(tx, caller.OrganizationID, applicationID, req.Stage, &caller.UserID, req.Note, false)
+		if err == nil {
+			application = *app
+		}
+		return err
+	})
+
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return gin.H{"error": "Application not found"}, http.StatusNotFound
+	case errors.Is(err, ErrInvalidStage), errors.Is(err, ErrStageTransitionBlocked):
+		return gin.H{"error": err.Error()}, http.StatusConflict
+	case err != nil:
+		return gin.H{"error": "Failed to move application"}, http.StatusInternalServerError
+	}
+
+	return gin.H{"message": "Application moved", "application": application}, http.StatusOK
+}
+
+type BulkMoveStageRequest struct {
+	ApplicationIDs []string `json:"application_ids" binding:"required,min=1"`
+	Stage          string   `json:"stage" binding:"required"`
+	Note           string   `json:"note"`
+}
+
+type BulkMoveResult struct {
+	ApplicationID string `json:"application_id"`
+	Success       bool   `json:"success"`
+	Error         string `json:"error,omitempty"`
+}
+
+// BulkMoveApplications moves each application independently; one invalid
+// transition doesn't stop the rest.
+func (s *PipelineService) BulkMoveApplications(caller Caller, req BulkMoveStageRequest) (gin.H, int) {
+	if len(req.ApplicationIDs) > maxBulkStageMove {
+		return gin.H{"error": fmt.Sprintf("at most %d applications can be moved at once", maxBulkStageMove)}, http.StatusBadRequest
+	}
+
+	results := make([]BulkMoveResult, 0, len(req.ApplicationIDs))
+	moved := 0
+	for _, id := range req.ApplicationIDs {
+		err := db.DB.Transaction(func(tx *gorm.DB) error {
+			_, err := moveApplicationStage(tx, caller.OrganizationID, id, req.Stage, &caller.UserID, req.Note, false)
+			return err
+		})
+
+		result := BulkMoveResult{ApplicationID: id, Success: err == nil}
+		switch {
+		case err == nil:
+			moved++
+		case errors.Is(err, ErrNotFound):
+			result.Error = "Application not found"
+		case errors.Is(err, ErrInvalidStage), errors.Is(err, ErrStageTransitionBlocked):
+			result.Error = err.Error()
+		default:
+			result.Error = "Failed to move application"
+		}
+		results = append(results, result)
+	}
+
+	return gin.H{"moved": moved, "failed": len(results) - moved, "results": results}, http.StatusOK
+}
+
+func (s *PipelineService) GetStageHistory(caller Caller, applicationID string) (gin.H, int) {
+	var application models.JobApplication
+	if err := db.DB.Scopes(ApplicationsForOrganization(caller.OrganizationID)).
+		Where("job_applications.id = ?", applicationID).First(&application).Error; err != nil {
+		return gin.H{"error": "Application not found"}, http.StatusNotFound
+	}
+
+	var history []models.ApplicationStageHistory
+	if err := db.DB.Where("application_id = ?", application.ID).Order("created_at").Find(&history).Error; err != nil {
+		return gin.H{"error": "Failed to load stage history"}, http.StatusInternalServerError
+	}
+
+	return gin.H{"current_stage": application.Status, "history": history}, http.StatusOK
+}
+
+// moveApplicationStage validates and applies a stage change inside tx and
+// records it in the stage history. byCandidate relaxes the workflow to the
+// one move candidates may always make: withdrawing.
+func moveApplicationStage(tx *gorm.DB, orgID, applicationID, toStage string, actorID *string, note string, byCandidate bool) (*models.JobApplication, error) {
+	var application models.JobApplication
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "job_applications"}}).
+		Scopes(ApplicationsForOrganization(orgID)).
+		Where("job_applications.id = ?", applicationID).
+		First(&application).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	pipeline, err := pipelineFor(tx, orgID, application.JobID)
+	if err != nil {
+		return nil, err
+	}
+
+	from := application.Status
+	current := findStage(pipeline, from)
+	if byCandidate {
+		if toStage != StageWithdrawn || (current != nil && current.IsTerminal) || from == StageWithdrawn {
+			return nil, fmt.Errorf("%w: %s to %s", ErrStageTransitionBlocked, from, toStage)
+		}
+	} else {
+		if findStage(pipeline, toStage) == nil {
+			return nil, fmt.Errorf("%w: %q is not part of this job's pipeline", ErrInvalidStage, toStage)
+		}
+		// Applications left in a stage that was later removed from the
+		// workflow may move anywhere so they can be recovered. Withdrawals
+		// are final even in a workflow saved without a withdrawn stage.
+		if from == StageWithdrawn || current != nil && !contains(current.AllowedNext, toStage) {
+			return nil, fmt.Errorf("%w: %s to %s", ErrStageTransitionBlocked, from, toStage)
+		}
+	}
+
+	if err := tx.Model(&application).Update("status", toStage).Error; err != nil {
+		return nil, err
+	}
+
+	history := models.ApplicationStageHistory{
+		ApplicationID: application.ID,
+		FromStage:     from,
+		ToStage:       toStage,
+		ActorID:       actorID,
+		Note:          note,
+		CreatedAt:     time.Now(),
+	}
+	if err := tx.Create(&history).Error; err != nil {
+		return nil, err
+	}
+
+	application.Status = toStage
+	return &application, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
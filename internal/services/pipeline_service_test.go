@@ -0,0 +1,93 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/resumelens/authservice/internal/db"
+	"github.com/resumelens/authservice/internal/models"
+	"github.com/resumelens/authservice/internal/storage"
+)
+
+func TestValidatePipeline(t *testing.T) {
+	withdrawn := PipelineStageConfig{Key: StageWithdrawn, Name: "Withdrawn", IsTerminal: true}
+	tests := []struct {
+		name    string
+		stages  []PipelineStageConfig
+		wantErr bool
+	}{
+		{name: "default", stages: defaultPipeline},
+		{name: "minimal", stages: []PipelineStageConfig{
+			{Key: "new", Name: "New", AllowedNext: []string{"done", StageWithdrawn}},
+			{Key: "done", Name: "Done", IsTerminal: true},
+			withdrawn,
+		}},
+		{name: "empty", wantErr: true},
+		{name: "without withdrawn", wantErr: true, stages: []PipelineStageConfig{
+			{Key: "new", Name: "New", AllowedNext: []string{StageHired}},
+			{Key: StageHired, Name: "Hired", IsTerminal: true},
+		}},
+		{name: "withdrawn not terminal", wantErr: true, stages: []PipelineStageConfig{
+			{Key: "new", Name: "New", AllowedNext: []string{StageWithdrawn}},
+			{Key: StageWithdrawn, Name: "Withdrawn", AllowedNext: []string{"new"}},
+		}},
+		{name: "duplicate key", wantErr: true, stages: []PipelineStageConfig{
+			{Key: "new", Name: "New"}, {Key: "new", Name: "Again"}, withdrawn,
+		}},
+		{name: "terminal with next", wantErr: true, stages: []PipelineStageConfig{
+			{Key: "done", Name: "Done", IsTerminal: true, AllowedNext: []string{StageWithdrawn}}, withdrawn,
+		}},
+		{name: "unknown next", wantErr: true, stages: []PipelineStageConfig{
+			{Key: "new", Name: "New", AllowedNext: []string{"missing"}}, withdrawn,
+		}},
+		{name: "self transition", wantErr: true, stages: []PipelineStageConfig{
+			{Key: "new", Name: "New", AllowedNext: []string{"new"}}, withdrawn,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePipeline(tt.stages)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validatePipeline err = %v; want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestWithdrawnIsFinal covers a workflow saved before a withdrawn stage was
+// required: a withdrawn application is no longer in it, but still can't be
+// moved on.
+func TestWithdrawnIsFinal(t *testing.T) {
+	testDB(t)
+	f := createTenant(t, storage.NewMemoryStore())
+	for i, stage := range []models.PipelineStage{
+		{Key: "new", Name: "New", AllowedNext: pq.StringArray{StageHired}},
+		{Key: StageHired, Name: "Hired", IsTerminal: true},
+	} {
+		stage.OrganizationID, stage.JobID, stage.Position = f.caller.OrganizationID, &f.job.ID, i
+		if err := db.DB.Create(&stage).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() {
+		db.DB.Where("job_id = ?", f.job.ID).Delete(&models.PipelineStage{})
+		db.DB.Where("application_id = ?", f.application.ID).Delete(&models.ApplicationStageHistory{})
+	})
+
+	if err := db.DB.Model(&f.application).Update("status", StageWithdrawn).Error; err != nil {
+		t.Fatal(err)
+	}
+	_, err := moveApplicationStage(db.DB, f.caller.OrganizationID, f.application.ID, StageHired, &f.caller.UserID, "", false)
+	if !errors.Is(err, ErrStageTransitionBlocked) {
+		t.Errorf("moving a withdrawn application: err = %v; want ErrStageTransitionBlocked", err)
+	}
+
+	// Other stages that left the workflow can still be recovered.
+	if err := db.DB.Model(&f.application).Update("status", "legacy").Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := moveApplicationStage(db.DB, f.caller.OrganizationID, f.application.ID, "new", &f.caller.UserID, "", false); err != nil {
+		t.Errorf("moving from a removed stage: %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/resumelens/authservice/internal/queue"
+)
+
+const defaultDeadLetterPageSize = 50
+
+// QueueAdminService lets an organization's admins see its background work
+// and re-drive jobs that failed on every attempt.
+type QueueAdminService struct {
+	queue *queue.Queue
+}
+
+func NewQueueAdminService(q *queue.Queue) *QueueAdminService {
+	return &QueueAdminService{queue: q}
+}
+
+func (s *QueueAdminService) GetStats(caller Caller) (gin.H, int) {
+	stats, err := s.queue.Stats(caller.OrganizationID)
+	if err != nil {
+		return gin.H{"error": "Failed to load queue stats"}, http.StatusInternalServerError
+	}
+	return gin.H{"queues": stats}, http.StatusOK
+}
+
+type ListDeadLettersRequest struct {
+	Kind   string `form:"kind"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=200"`
+	Offset int    `form:"offset" binding:"omitempty,min=0"`
+}
+
+func (s *QueueAdminService) ListDeadLetters(caller Caller, req ListDeadLettersRequest) (gin.H, int) {
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultDeadLetterPageSize
+	}
+	jobs, total, err := s.queue.DeadLetters(caller.OrganizationID, req.Kind, limit, req.Offset)
+	if err != nil {
+		return gin.H{"error": "Failed to load dead letters"}, http.StatusInternalServerError
+	}
+	return gin.H{"dead_letters": jobs, "total": total}, http.StatusOK
+}
+
+func (s *QueueAdminService) Redrive(caller Caller, id string) (gin.H, int) {
+	job, err := s.queue.Redrive(caller.OrganizationID, id)
+	if errors.Is(err, queue.ErrNotFound) {
+		return gin.H{"error": "Dead letter not found"}, http.StatusNotFound
+	}
+	if err != nil {
+		return gin.H{"error": "Failed to re-drive job"}, http.StatusInternalServerError
+	}
+	return gin.H{"message": "Job re-queued", "job": job}, http.StatusOK
+}
+
+func (s *QueueAdminService) Discard(caller Caller, id string) (gin.H, int) {
+	err := s.queue.Discard(caller.OrganizationID, id)
+	if errors.Is(err, queue.ErrNotFound) {
+		return gin.H{"error": "Dead letter not found"}, http.StatusNotFound
+	}
+	if err != nil {
+		return gin.H{"error": "Failed to discard job"}, http.StatusInternalServerError
+	}
+	return gin.H{"message": "Job discarded"}, http.StatusOK
+}
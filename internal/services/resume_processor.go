@@ -0,0 +1,280 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/resumelens/authservice/internal/db"
+	"github.com/resumelens/authservice/internal/extract"
+	"github.com/resumelens/authservice/internal/models"
+	"github.com/resumelens/authservice/internal/parser"
+	"github.com/resumelens/authservice/internal/scoring"
+	"github.com/resumelens/authservice/internal/storage"
+	"github.com/resumelens/authservice/internal/vector"
+)
+
+// Values of ParsedResume.Status.
+const (
+	ParseStatusProcessing = "processing"
+	ParseStatusCompleted  = "completed"
+	ParseStatusFailed     = "failed"
+)
+
+// maxResumeSize caps how much of a stored resume is read for extraction.
+const maxResumeSize = 20 << 20
+
+// resumeTextFile is the name of the extracted text object, stored in the
+// same folder as the resume it came from.
+const resumeTextFile = "resume_text.txt"
+
+// minPrefillConfidence is the lowest parser confidence at which a value is
+// copied onto the candidate without a recruiter looking at it.
+const minPrefillConfidence = 0.5
+
+// ParsedResume is the document stored in JobApplication.ParsedResume.
+type ParsedResume struct {
+	Status      string    `json:"status"`
+	SourcePath  string    `json:"source_path"`
+	Format      string    `json:"format,omitempty"`
+	Pages       int       `json:"pages,omitempty"`
+	TextPath    string    `json:"text_path,omitempty"`
+	CharCount   int       `json:"char_count,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	ProcessedAt time.Time `json:"processed_at"`
+
+	// Profile holds the structured fields with their confidences and
+	// Prefilled lists the candidate columns that were filled from it.
+	Profile   *parser.Resume `json:"profile,omitempty"`
+	Prefilled []string       `json:"prefilled,omitempty"`
+}
+
+// ResumeProcessor extracts, parses, scores and indexes uploaded resumes. It
+// runs as the TaskProcessResume queue handler so uploads return as soon as
+// the file is stored.
+type ResumeProcessor struct {
+	store    storage.BlobStore
+	embedder vector.Embedder
+	index    vector.Index
+}
+
+func NewResumeProcessor(store storage.BlobStore, embedder vector.Embedder, index vector.Index) *ResumeProcessor {
+	return &ResumeProcessor{
+		store:    store,
+		embedder: embedder,
+		index:    index,
+	}
+}
+
+// Process extracts the text of an application's current resume, stores it
+// next to the resume, parses it into structured fields and records the
+// outcome in ParsedResume. Empty candidate columns are pre-filled from
+// fields the parser is confident about. Resumes that haven't passed the
+// malware scan are left alone.
+func (p *ResumeProcessor) Process(ctx context.Context, applicationID string) error {
+	var application models.JobApplication
+	if err := db.DB.Where("id = ?", applicationID).First(&application).Error; err != nil {
+		return err
+	}
+	sourcePath := application.ResumeGCSPath
+	if sourcePath == "" || application.ResumeScanStatus == ScanStatusPending || application.ResumeScanStatus == ScanStatusInfected {
+		return nil
+	}
+	var job models.Job
+	if err := db.DB.Unscoped().Where("id = ?", application.JobID).First(&job).Error; err != nil {
+		return err
+	}
+
+	// Marked as processing first so a retried or slow run is visible.
+	if err := saveParsedResume(application.ID, sourcePath, ParsedResume{
+		Status:      ParseStatusProcessing,
+		SourcePath:  sourcePath,
+		ProcessedAt: time.Now().UTC(),
+	}, nil); err != nil {
+		return err
+	}
+
+	updates := map[string]interface{}{}
+	result := ParsedResume{Status: ParseStatusCompleted, SourcePath: sourcePath}
+	extracted, err := p.extractText(ctx, sourcePath)
+	if err != nil {
+		result.Status = ParseStatusFailed
+		result.Error = err.Error()
+	} else {
+		result.Format = extracted.Format
+		result.Pages = extracted.Pages
+		result.CharCount = len(extracted.Text)
+		result.TextPath = path.Join(path.Dir(sourcePath), resumeTextFile)
+		if err := p.store.Put(ctx, result.TextPath, strings.NewReader(extracted.Text), "text/plain; charset=utf-8"); err != nil {
+			return fmt.Errorf("failed to store extracted text: %w", err)
+		}
+
+		result.Profile = parser.Parse(extracted.Text)
+		prefilled, err := prefillCandidate(application.CandidateID, result.Profile)
+		if err != nil {
+			return fmt.Errorf("failed to prefill candidate: %w", err)
+		}
+		result.Prefilled = prefilled
+
+		if updates, err = scoreUpdates(scoring.Score(result.Profile, &job)); err != nil {
+			return err
+		}
+
+		// A missing embedding only leaves the application out of semantic
+		// ranking, so it doesn't fail the whole job.
+		if err := p.indexResume(ctx, &application, &job, extracted.Text); err != nil {
+			log.Printf("Failed to index resume for application %s: %v", application.ID, err)
+		} else {
+			updates["pinecode_id"] = application.ID
+		}
+	}
+	result.ProcessedAt = time.Now().UTC()
+
+	if err := saveParsedResume(application.ID, sourcePath, result, updates); err != nil {
+		return err
+	}
+	return refreshJobAnalytics(db.DB, application.JobID)
+}
+
+// indexResume stores the embedding of a resume's text under the
+// application's id, replacing the one for any earlier resume.
+func (p *ResumeProcessor) indexResume(ctx context.Context, application *models.JobApplication, job *models.Job, text string) error {
+	vectors, err := p.embedder.Embed(ctx, []string{text})
+	if err != nil {
+		return err
+	}
+	return p.index.Upsert(ctx, vector.Record{
+		ID:             application.ID,
+		OrganizationID: job.OrganizationID,
+		JobID:          job.ID,
+		CandidateID:    application.CandidateID,
+		Model:          p.embedder.Model(),
+		Vector:         vectors[0],
+		Metadata:       map[string]string{"source_path": application.ResumeGCSPath},
+	})
+}
+
+// Unindex removes the embedding of an application that no longer exists,
+// such as one merged into another.
+func (p *ResumeProcessor) Unindex(ctx context.Context, applicationID string) error {
+	return p.index.Delete(ctx, applicationID)
+}
+
+func (p *ResumeProcessor) extractText(ctx context.Context, objectName string) (*extract.Result, error) {
+	reader, err := p.store.Get(ctx, objectName)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(io.LimitReader(reader, maxResumeSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxResumeSize {
+		return nil, errors.New("resume is too large to process")
+	}
+	return extract.Text(objectName, data)
+}
+
+// saveParsedResume writes result, along with any extra column updates such
+// as the fit score, unless the resume was replaced while it was being
+// processed, in which case the newer upload's job owns the columns.
+func saveParsedResume(applicationID, sourcePath string, result ParsedResume, updates map[string]interface{}) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	if updates == nil {
+		updates = map[string]interface{}{}
+	}
+	updates["parsed_resume"] = string(data)
+	return db.DB.Model(&models.JobApplication{}).
+		Where("id = ? AND resume_gcs_path = ?", applicationID, sourcePath).
+		Updates(updates).Error
+}
+
+// prefillCandidate copies confident parser output into the candidate's empty
+// columns. Values someone already entered are never overwritten. It returns
+// the names of the columns it set.
+func prefillCandidate(candidateID string, profile *parser.Resume) ([]string, error) {
+	var candidate models.Candidate
+	if err := db.DB.Where("id = ?", candidateID).First(&candidate).Error; err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{}
+	setField := func(column, current string, field parser.Field) {
+		if strings.TrimSpace(current) == "" && field.Value != "" && field.Confidence >= minPrefillConfidence {
+			updates[column] = field.Value
+		}
+	}
+	setField("full_name", candidate.FullName, profile.Name)
+	setField("phone", candidate.Phone, profile.Phone)
+	setField("linked_in", candidate.LinkedIn, profile.LinkedIn)
+	setField("git_hub", candidate.GitHub, profile.GitHub)
+	setField("location", candidate.Location, profile.Location)
+
+	if strings.TrimSpace(candidate.Experience) == "" {
+		if entries := confidentExperience(profile.Experience); len(entries) > 0 {
+			data, err := json.Marshal(entries)
+			if err != nil {
+				return nil, err
+			}
+			updates["experience"] = string(data)
+		}
+	}
+	if strings.TrimSpace(candidate.Education) == "" {
+		if entries := confidentEducation(profile.Education); len(entries) > 0 {
+			data, err := json.Marshal(entries)
+			if err != nil {
+				return nil, err
+			}
+			updates["education"] = string(data)
+		}
+	}
+	if strings.TrimSpace(candidate.Skills) == "" && len(profile.Skills) > 0 && profile.SkillsConfidence >= minPrefillConfidence {
+		updates["skills"] = strings.Join(profile.Skills, ", ")
+	}
+
+	if len(updates) == 0 {
+		return nil, nil
+	}
+	if err := db.DB.Model(&models.Candidate{}).Where("id = ?", candidateID).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+
+	prefilled := make([]string, 0, len(updates))
+	for column := range updates {
+		prefilled = append(prefilled, column)
+	}
+	sort.Strings(prefilled)
+	return prefilled, nil
+}
+
+func confidentExperience(entries []parser.Experience) []parser.Experience {
+	var out []parser.Experience
+	for _, e := range entries {
+		if e.Confidence >= minPrefillConfidence {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func confidentEducation(entries []parser.Education) []parser.Education {
+	var out []parser.Education
+	for _, e := range entries {
+		if e.Confidence >= minPrefillConfidence {
+			out = append(out, e)
+		}
+	}
+	return out
+}
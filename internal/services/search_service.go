@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/resumelens/authservice/internal/db"
+	"github.com/resumelens/authservice/internal/models"
+	"github.com/resumelens/authservice/internal/storage"
+	"github.com/resumelens/authservice/internal/vector"
+)
+
+const defaultMatchLimit = 20
+
+// SearchService ranks resumes by semantic similarity using the embeddings
+// written by the ResumeProcessor, and searches the organization's talent
+// pool.
+type SearchService struct {
+	store    storage.BlobStore
+	embedder vector.Embedder
+	index    vector.Index
+}
+
+func NewSearchService(store storage.BlobStore, embedder vector.Embedder, index vector.Index) *SearchService {
+	return &SearchService{store: store, embedder: embedder, index: index}
+}
+
+type RankApplicationsRequest struct {
+	Limit int `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+type ApplicationMatch struct {
+	ApplicationID string  `json:"application_id"`
+	CandidateID   string  `json:"candidate_id"`
+	FullName      string  `json:"full_name"`
+	Email         string  `json:"email"`
+	Status        string  `json:"status"`
+	Similarity    float32 `json:"similarity"`
+	FitScore      float64 `json:"fit_score"`
+}
+
+// RankApplications orders a job's applications by how similar their resume
+// is to the job posting. Applications whose resume hasn't been indexed yet
+// are not included.
+func (s *SearchService) RankApplications(ctx context.Context, caller Caller, jobID string, req RankApplicationsRequest) (gin.H, int) {
+	var job models.Job
+	if err := db.DB.Scopes(ForOrganization(caller.OrganizationID)).Where("id = ?", jobID).First(&job).Error; err != nil {
+		return gin.H{"error": "Job not found"}, http.StatusNotFound
+	}
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultMatchLimit
+	}
+
+	vectors, err := s.embedder.Embed(ctx, []string{jobText(&job)})
+	if err != nil {
+		return gin.H{"error": "Failed to embed job"}, http.StatusInternalServerError
+	}
+	matches, err := s.index.Query(ctx, vectors[0], limit, vector.Filter{
+		OrganizationID: caller.OrganizationID,
+		JobID:          job.ID,
+		Model:          s.embedder.Model(),
+	})
+	if err != nil {
+		return gin.H{"error": "Failed to search applications"}, http.StatusInternalServerError
+	}
+
+	ids := make([]string, len(matches))
+	for i, m := range matches {
+		ids[i] = m.ID
+	}
+	var rows []struct {
+		models.JobApplication
+		FullName string
+		Email    string
+	}
+	if len(ids) > 0 {
+		err = db.DB.Model(&models.JobApplication{}).
+			Select("job_applications.*, candidates.full_name, candidates.email").
+			Joins("JOIN candidates ON candidates.id = job_applications.candidate_id").
+			Where("job_applications.id IN ? AND job_applications.job_id = ?", ids, job.ID).
+			Scan(&rows).Error
+		if err != nil {
+			return gin.H{"error": "Failed to load applications"}, http.StatusInternalServerError
+		}
+	}
+	byID := make(map[string]int, len(rows))
+	for i, row := range rows {
+		byID[row.ID] = i
+	}
+
+	results := make([]ApplicationMatch, 0, len(matches))
+	for _, m := range matches {
+		i, ok := byID[m.ID]
+		if !ok {
+			continue
+		}
+		row := rows[i]
+		results = append(results, ApplicationMatch{
+			ApplicationID: row.ID,
+			CandidateID:   row.CandidateID,
+			FullName:      row.FullName,
+			Email:         row.Email,
+			Status:        row.Status,
+			Similarity:    m.Score,
+			FitScore:      row.AI_Score,
+		})
+	}
+
+	return gin.H{"job_id": job.ID, "model": s.embedder.Model(), "matches": results}, http.StatusOK
+}
+
+// jobText is the text a job is embedded from.
+func jobText(job *models.Job) string {
+	parts := []string{job.Title, job.ExperienceLevel, strings.Join(job.SkillsRequired, " "), job.Description}
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/resumelens/authservice/internal/config"
+	"github.com/resumelens/authservice/internal/db"
+	"github.com/resumelens/authservice/internal/models"
+	"github.com/resumelens/authservice/internal/queue"
+	"github.com/resumelens/authservice/internal/utils"
+	"gorm.io/gorm"
+)
+
+// Kinds of background job.
+const (
+	TaskProcessResume    = "resume.process"
+	TaskUnindexResume    = "resume.unindex"
+	TaskRescoreJob       = "job.rescore"
+	TaskSendMagicLink    = "email.magic_link"
+	TaskSendInvite       = "email.invite"
+	TaskDetectDuplicates = "candidate.detect_duplicates"
+	TaskNotifyMention    = "email.mention"
+
+	TaskScanDocument         = "document.scan"
+	TaskNotifyRejectedUpload = "email.upload_rejected"
+	TaskExportMetadata       = "document.export_metadata"
+)
+
+type applicationTask struct {
+	ApplicationID string `json:"application_id"`
+}
+
+type jobTask struct {
+	JobID string `json:"job_id"`
+}
+
+type inviteTask struct {
+	InviteID string `json:"invite_id"`
+}
+
+// RegisterTasks installs the handler for every kind of background job.
+func RegisterTasks(q *queue.Queue, cfg *config.Config, processor *ResumeProcessor, uploads *UploadScanner, applications *JobApplicationService, candidates *CandidateService) {
+	q.Register(TaskScanDocument, func(ctx context.Context, payload json.RawMessage) error {
+		var task documentTask
+		if err := json.Unmarshal(payload, &task); err != nil {
+			return queue.Permanent(err)
+		}
+		return taskError(uploads.Scan(ctx, task))
+	})
+
+	q.Register(TaskProcessResume, func(ctx context.Context, payload json.RawMessage) error {
+		var task applicationTask
+		if err := json.Unmarshal(payload, &task); err != nil {
+			return queue.Permanent(err)
+		}
+		return taskError(processor.Process(ctx, task.ApplicationID))
+	})
+
+	q.Register(TaskUnindexResume, func(ctx context.Context, payload json.RawMessage) error {
+		var task applicationTask
+		if err := json.Unmarshal(payload, &task); err != nil {
+			return queue.Permanent(err)
+		}
+		return processor.Unindex(ctx, task.ApplicationID)
+	})
+
+	q.Register(TaskDetectDuplicates, func(ctx context.Context, payload json.RawMessage) error {
+		var task candidateTask
+		if err := json.Unmarshal(payload, &task); err != nil {
+			return queue.Permanent(err)
+		}
+		return taskError(candidates.DetectDuplicates(ctx, task.CandidateID))
+	})
+
+	q.Register(TaskExportMetadata, func(ctx context.Context, payload json.RawMessage) error {
+		var task applicationTask
+		if err := json.Unmarshal(payload, &task); err != nil {
+			return queue.Permanent(err)
+		}
+		return taskError(applications.ExportMetadata(ctx, task.ApplicationID))
+	})
+
+	q.Register(TaskRescoreJob, func(ctx context.Context, payload json.RawMessage) error {
+		var task jobTask
+		if err := json.Unmarshal(payload, &task); err != nil {
+			return queue.Permanent(err)
+		}
+		return taskError(RescoreJob(task.JobID))
+	})
+
+	q.Register(TaskSendMagicLink, func(ctx context.Context, payload json.RawMessage) error {
+		var task applicationTask
+		if err := json.Unmarshal(payload, &task); err != nil {
+			return queue.Permanent(err)
+		}
+		return taskError(sendMagicLink(cfg, task.ApplicationID))
+	})
+
+	q.Register(TaskSendInvite, func(ctx context.Context, payload json.RawMessage) error {
+		var task inviteTask
+		if err := json.Unmarshal(payload, &task); err != nil {
+			return queue.Permanent(err)
+		}
+		return taskError(sendInvite(cfg, task.InviteID))
+	})
+
+	q.Register(TaskNotifyMention, func(ctx context.Context, payload json.RawMessage) error {
+		var task mentionTask
+		if err := json.Unmarshal(payload, &task); err != nil {
+			return queue.Permanent(err)
+		}
+		return taskError(sendMention(cfg, task))
+	})
+
+	q.Register(TaskNotifyRejectedUpload, func(ctx context.Context, payload json.RawMessage) error {
+		var task quarantineTask
+		if err := json.Unmarshal(payload, &task); err != nil {
+			return queue.Permanent(err)
+		}
+		return taskError(sendUploadRejected(cfg, task.QuarantinedFileID))
+	})
+}
+
+// taskError makes errors about rows that no longer exist permanent; retrying
+// won't bring them back.
+func taskError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return queue.Permanent(err)
+	}
+	return err
+}
+
+// sendMagicLink emails the candidate the link to their application portal.
+func sendMagicLink(cfg *config.Config, applicationID string) error {
+	var application models.JobApplication
+	if err := db.DB.Where("id = ?", applicationID).First(&application).Error; err != nil {
+		return err
+	}
+	var candidate models.Candidate
+	if err := db.DB.Where("id = ?", application.CandidateID).First(&candidate).Error; err != nil {
+		return err
+	}
+	var job models.Job
+	if err := db.DB.Unscoped().Where("id = ?", application.JobID).First(&job).Error; err != nil {
+		return err
+	}
+	return utils.SendMagicLinkEmail(candidate.Email, candidate.FullName, job.Title, application.MagicLinkToken, cfg)
+}
+
+func sendInvite(cfg *config.Config, inviteID string) error {
+	var invite models.Invite
+	if err := db.DB.Where("id = ?", inviteID).First(&invite).Error; err != nil {
+		return err
+	}
+	if invite.IsAccepted {
+		return nil
+	}
+	return utils.SendInviteEmail(invite.Email, invite.Token, cfg)
+}
+
+// sendUploadRejected tells whoever uploaded a quarantined file that it was
+// rejected: the recruiter who uploaded it, or else the candidate.
+func sendUploadRejected(cfg *config.Config, quarantinedFileID string) error {
+	var file models.QuarantinedFile
+	if err := db.DB.Where("id = ?", quarantinedFileID).First(&file).Error; err != nil {
+		return err
+	}
+	var application models.JobApplication
+	if err := db.DB.Where("id = ?", file.ApplicationID).First(&application).Error; err != nil {
+		return err
+	}
+	var job models.Job
+	if err := db.DB.Unscoped().Where("id = ?", application.JobID).First(&job).Error; err != nil {
+		return err
+	}
+
+	if file.UploadedBy != nil {
+		var user models.User
+		if err := db.DB.Where("id = ?", *file.UploadedBy).First(&user).Error; err != nil {
+			return err
+		}
+		return utils.SendUploadRejectedEmail(user.Email, "", file.Filename, job.Title, "", cfg)
+	}
+
+	var candidate models.Candidate
+	if err := db.DB.Where("id = ?", application.CandidateID).First(&candidate).Error; err != nil {
+		return err
+	}
+	return utils.SendUploadRejectedEmail(candidate.Email, candidate.FullName, file.Filename, job.Title, application.MagicLinkToken, cfg)
+}
+
+// sendMention emails a user mentioned in a note, unless the note was
+// deleted or edited to drop the mention before the email went out.
+func sendMention(cfg *config.Config, task mentionTask) error {
+	var note models.CandidateNote
+	err := db.DB.Where("id = ?", task.NoteID).First(&note).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	mentioned := false
+	for _, id := range note.Mentions {
+		mentioned = mentioned || id == task.UserID
+	}
+	if !mentioned {
+		return nil
+	}
+
+	var recipient, author models.User
+	if err := db.DB.Where("id = ?", task.UserID).First(&recipient).Error; err != nil {
+		return err
+	}
+	if err := db.DB.Where("id = ?", note.AuthorID).First(&author).Error; err != nil {
+		return err
+	}
+	var candidate models.Candidate
+	if err := db.DB.Where("id = ?", note.CandidateID).First(&candidate).Error; err != nil {
+		return err
+	}
+	return utils.SendMentionEmail(recipient.Email, author.Email, candidate.FullName, candidate.ID, note.Body, cfg)
+}
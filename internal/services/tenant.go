@@ -0,0 +1,43 @@
+package services
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrNotFound is returned when a resource does not exist or belongs to
+// another organization. The two cases are deliberately indistinguishable.
+var ErrNotFound = errors.New("resource not found")
+
+// Caller identifies the authenticated user a service call is made on behalf
+// of. It is always built from the verified JWT claims, never from request
+// bodies.
+type Caller struct {
+	UserID         string
+	Email          string
+	RoleID         string
+	OrganizationID string
+}
+
+// ForOrganization scopes a query to rows owned by orgID. The column is
+// qualified with the query's own table so the scope is safe to combine with
+// joins.
+func ForOrganization(orgID string) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Where(clause.Eq{
+			Column: clause.Column{Table: clause.CurrentTable, Name: "organization_id"},
+			Value:  orgID,
+		})
+	}
+}
+
+// ApplicationsForOrganization scopes a job_applications query to
+// applications for orgID's jobs.
+func ApplicationsForOrganization(orgID string) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Joins("JOIN jobs ON jobs.id = job_applications.job_id AND jobs.deleted_at IS NULL").
+			Where("jobs.organization_id = ?", orgID)
+	}
+}
@@ -0,0 +1,288 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/resumelens/authservice/internal/config"
+	"github.com/resumelens/authservice/internal/db"
+	"github.com/resumelens/authservice/internal/models"
+	"github.com/resumelens/authservice/internal/queue"
+	"github.com/resumelens/authservice/internal/storage"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// dryRunDB points db.DB at a connection that builds statements without
+// running them and returns the statements queried through it.
+func dryRunDB(t *testing.T) *[]string {
+	t.Helper()
+	conn, err := gorm.Open(postgres.Open("host=127.0.0.1"), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+		Logger:               logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var statements []string
+	conn.Callback().Query().After("gorm:query").Register("test:record", func(tx *gorm.DB) {
+		statements = append(statements, tx.Dialector.Explain(tx.Statement.SQL.String(), tx.Statement.Vars...))
+	})
+	previous := db.DB
+	db.DB = conn
+	t.Cleanup(func() { db.DB = previous })
+	return &statements
+}
+
+// TestTenantScopes checks that lookups filter on the caller's organization
+// in the statement itself, so they hold even without a database.
+func TestTenantScopes(t *testing.T) {
+	const orgA, other = "org-a", "other-id"
+	caller := Caller{UserID: "user-a", OrganizationID: orgA}
+	applications := NewJobApplicationService(&config.Config{}, storage.NewMemoryStore(), nil)
+	candidates := NewCandidateService(nil)
+	jobs := NewJobHostingService(&config.Config{}, nil)
+
+	tests := []struct {
+		name string
+		run  func()
+		want string
+	}{
+		{"loadJob", func() { loadJob(orgA, other) }, `"jobs"."organization_id" = 'org-a'`},
+		{"GetJob", func() { jobs.GetJob(caller, other) }, `"jobs"."organization_id" = 'org-a'`},
+		{"ListJobs", func() { jobs.ListJobs(caller, ListJobsRequest{}) }, `"jobs"."organization_id" = 'org-a'`},
+		{"loadApplication", func() { loadApplication(orgA, other) }, `jobs.organization_id = 'org-a'`},
+		{"ListDocumentVersions", func() { applications.ListDocumentVersions(caller, other, ListDocumentVersionsRequest{}) }, `jobs.organization_id = 'org-a'`},
+		{"AccessDocumentVersion", func() {
+			applications.AccessDocumentVersion(context.Background(), caller, other, other, DocumentAccessRequest{}, AccessClient{})
+		}, `jobs.organization_id = 'org-a'`},
+		{"loadCandidate", func() { loadCandidate(orgA, other) }, `"candidates"."organization_id" = 'org-a'`},
+		{"GetCandidate", func() { candidates.GetCandidate(caller, other) }, `"candidates"."organization_id" = 'org-a'`},
+		{"ListCandidates", func() { candidates.ListCandidates(caller, ListCandidatesRequest{}) }, `"candidates"."organization_id" = 'org-a'`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements := dryRunDB(t)
+			tt.run()
+			if len(*statements) == 0 {
+				t.Fatal("no statement was run")
+			}
+			if first := (*statements)[0]; !strings.Contains(first, tt.want) {
+				t.Errorf("first statement %q is not scoped by %s", first, tt.want)
+			}
+		})
+	}
+}
+
+// tenantFixture is one organization's data for TestTenantIsolation.
+type tenantFixture struct {
+	caller      Caller
+	job         models.Job
+	candidate   models.Candidate
+	application models.JobApplication
+	document    models.DocumentVersion
+}
+
+// testDB connects to TEST_DATABASE_URL and migrates it, skipping the test
+// when no database is configured.
+func testDB(t *testing.T) {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	conn, err := gorm.Open(postgres.Open(url), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Exec(`CREATE EXTENSION IF NOT EXISTS "uuid-ossp"`).Error; err != nil {
+		t.Fatal(err)
+	}
+	db.ConnectDatabase(&config.Config{DatabaseURL: url, MagicLinkExpiryDays: 30})
+}
+
+func createTenant(t *testing.T, store storage.BlobStore) *tenantFixture {
+	t.Helper()
+	id := uuid.NewString()
+	org := models.Organization{ID: id, Name: "tenant-test-" + id}
+	user := models.User{ID: uuid.NewString(), Email: id + "@example.com", PasswordHash: "x", RoleID: uuid.NewString(), OrganizationID: id}
+	f := &tenantFixture{caller: Caller{UserID: user.ID, Email: user.Email, RoleID: user.RoleID, OrganizationID: id}}
+	f.job = models.Job{
+		ID:             uuid.NewString(),
+		OrganizationID: id,
+		Title:          "Engineer",
+		Description:    "Builds things",
+		Status:         "open",
+		CreatedByID:    user.ID,
+		PublicLink:     "tenant-test/" + id,
+		ShortLink:      "tenant-test-short/" + id,
+		Slug:           "tenant-test-" + id,
+	}
+	f.candidate = models.Candidate{ID: uuid.NewString(), OrganizationID: id, FullName: "Candidate", Email: "same@example.com", Phone: "", Skills: ""}
+	f.document = models.DocumentVersion{
+		ID:             uuid.NewString(),
+		OrganizationID: id,
+		Kind:           documentResume,
+		Version:        1,
+		ObjectPath:     "org-" + id + "/resume.txt",
+		SHA256:         "x",
+		Size:           6,
+		ContentType:    "text/plain",
+		ScanStatus:     ScanStatusClean,
+		CreatedAt:      time.Now(),
+	}
+	f.application = models.JobApplication{
+		ID:                 uuid.NewString(),
+		CandidateID:        f.candidate.ID,
+		JobID:              f.job.ID,
+		ResumeGCSPath:      f.document.ObjectPath,
+		CurrentResumeID:    &f.document.ID,
+		ResumeScanStatus:   ScanStatusClean,
+		MagicLinkToken:     uuid.NewString(),
+		MagicLinkExpiresAt: time.Now().Add(time.Hour),
+		CreatedAt:          time.Now(),
+	}
+	f.document.ApplicationID = f.application.ID
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		for _, row := range []interface{}{&org, &user, &f.job, &f.candidate, &f.application, &f.document} {
+			if err := tx.Create(row).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(context.Background(), f.document.ObjectPath, strings.NewReader("resume"), "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		db.DB.Where("organization_id = ?", id).Delete(&models.QueueJob{})
+		db.DB.Where("organization_id = ?", id).Delete(&models.DocumentAccessLog{})
+		db.DB.Where("organization_id = ?", id).Delete(&models.DocumentVersion{})
+		db.DB.Where("job_id = ?", f.job.ID).Delete(&models.JobApplication{})
+		db.DB.Where("organization_id = ?", id).Delete(&models.Candidate{})
+		db.DB.Unscoped().Where("organization_id = ?", id).Delete(&models.Job{})
+		db.DB.Where("organization_id = ?", id).Delete(&models.User{})
+		db.DB.Where("id = ?", id).Delete(&models.Organization{})
+	})
+	return f
+}
+
+// TestTenantIsolation creates two organizations and checks that neither
+// can reach the other's jobs, applications, documents or candidates, by id
+// or through a listing.
+func TestTenantIsolation(t *testing.T) {
+	testDB(t)
+	store := storage.NewMemoryStore()
+	q := queue.New(db.DB, queue.Options{})
+	applications := NewJobApplicationService(&config.Config{}, store, q)
+	candidates := NewCandidateService(q)
+	jobs := NewJobHostingService(&config.Config{}, q)
+	ctx := context.Background()
+
+	a, b := createTenant(t, store), createTenant(t, store)
+
+	// Each organization reaches its own data, so the checks below fail for
+	// the right reason.
+	for _, f := range []*tenantFixture{a, b} {
+		if _, err := loadJob(f.caller.OrganizationID, f.job.ID); err != nil {
+			t.Fatalf("own job: %v", err)
+		}
+		if _, err := loadApplication(f.caller.OrganizationID, f.application.ID); err != nil {
+			t.Fatalf("own application: %v", err)
+		}
+		if _, err := loadCandidate(f.caller.OrganizationID, f.candidate.ID); err != nil {
+			t.Fatalf("own candidate: %v", err)
+		}
+		access, err := applications.AccessDocumentVersion(ctx, f.caller, f.application.ID, f.document.ID, DocumentAccessRequest{}, AccessClient{})
+		if err != nil {
+			t.Fatalf("own document: %v", err)
+		}
+		if access.Reader != nil {
+			access.Reader.Close()
+		}
+	}
+
+	for _, pair := range [][2]*tenantFixture{{a, b}, {b, a}} {
+		self, other := pair[0], pair[1]
+		org := self.caller.OrganizationID
+
+		if _, err := loadJob(org, other.job.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("loadJob of another organization's job: err = %v", err)
+		}
+		if _, err := loadApplication(org, other.application.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("loadApplication of another organization's application: err = %v", err)
+		}
+		if _, err := loadCandidate(org, other.candidate.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("loadCandidate of another organization's candidate: err = %v", err)
+		}
+
+		for _, call := range []struct {
+			name string
+			run  func() (gin.H, int)
+		}{
+			{"GetJob", func() (gin.H, int) { return jobs.GetJob(self.caller, other.job.ID) }},
+			{"GetCandidate", func() (gin.H, int) { return candidates.GetCandidate(self.caller, other.candidate.ID) }},
+			{"ListDocumentVersions", func() (gin.H, int) {
+				return applications.ListDocumentVersions(self.caller, other.application.ID, ListDocumentVersionsRequest{})
+			}},
+			{"ListDocumentAccess", func() (gin.H, int) {
+				return applications.ListDocumentAccess(self.caller, other.application.ID, ListDocumentAccessRequest{})
+			}},
+		} {
+			if _, status := call.run(); status != http.StatusNotFound {
+				t.Errorf("%s of another organization's data: status = %d; want 404", call.name, status)
+			}
+		}
+
+		// Another organization's document, by its own id and through one of
+		// the caller's applications.
+		for _, applicationID := range []string{other.application.ID, self.application.ID} {
+			if _, err := applications.AccessDocumentVersion(ctx, self.caller, applicationID, other.document.ID, DocumentAccessRequest{}, AccessClient{}); !errors.Is(err, ErrNotFound) {
+				t.Errorf("AccessDocumentVersion(%s, other document): err = %v", applicationID, err)
+			}
+		}
+		if _, err := applications.AccessCurrentDocument(ctx, self.caller, other.application.ID, documentResume, DocumentAccessRequest{}, AccessClient{}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("AccessCurrentDocument of another organization's application: err = %v", err)
+		}
+
+		for _, list := range []struct {
+			name string
+			run  func() (gin.H, int)
+		}{
+			{"ListJobs", func() (gin.H, int) { return jobs.ListJobs(self.caller, ListJobsRequest{}) }},
+			{"ListCandidates", func() (gin.H, int) { return candidates.ListCandidates(self.caller, ListCandidatesRequest{}) }},
+			{"ListCandidates by email", func() (gin.H, int) {
+				return candidates.ListCandidates(self.caller, ListCandidatesRequest{Query: "same@example.com"})
+			}},
+		} {
+			body, status := list.run()
+			if status != http.StatusOK {
+				t.Fatalf("%s: status = %d: %v", list.name, status, body)
+			}
+			data, err := json.Marshal(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, id := range []string{other.job.ID, other.candidate.ID, other.caller.OrganizationID} {
+				if strings.Contains(string(data), id) {
+					t.Errorf("%s for %s includes %s of another organization", list.name, org, id)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"path"
+
+	"github.com/google/uuid"
+	"github.com/resumelens/authservice/internal/db"
+	"github.com/resumelens/authservice/internal/models"
+	"github.com/resumelens/authservice/internal/queue"
+	"github.com/resumelens/authservice/internal/scanner"
+	"github.com/resumelens/authservice/internal/storage"
+	"gorm.io/gorm"
+)
+
+// Values of DocumentVersion.ScanStatus and of the application's
+// ResumeScanStatus and CoverLetterScanStatus, which mirror the current version.
+const (
+	ScanStatusPending  = "pending"
+	ScanStatusClean    = "clean"
+	ScanStatusInfected = "infected"
+)
+
+// quarantinePrefix is where rejected uploads are kept. It sits outside the
+// org-* folders so nothing that lists an organization's files finds them.
+const quarantinePrefix = "quarantine"
+
+type documentTask struct {
+	VersionID string `json:"version_id"`
+}
+
+type quarantineTask struct {
+	QuarantinedFileID string `json:"quarantined_file_id"`
+}
+
+// documentColumns returns the JobApplication columns holding the path,
+// current version and scan status of a document kind.
+func documentColumns(kind string) (pathColumn, currentColumn, statusColumn string) {
+	if kind == documentCoverLetter {
+		return "cover_letter_gcs_path", "current_cover_letter_id", "cover_letter_scan_status"
+	}
+	return "resume_gcs_path", "current_resume_id", "resume_scan_status"
+}
+
+// UploadScanner runs every stored upload through the malware scanner before
+// it is marked clean. Clean resumes go on to processing; infected files are
+// moved to quarantine and the uploader is told.
+type UploadScanner struct {
+	store   storage.BlobStore
+	scanner scanner.Scanner
+	queue   *queue.Queue
+}
+
+func NewUploadScanner(store storage.BlobStore, sc scanner.Scanner, q *queue.Queue) *UploadScanner {
+	return &UploadScanner{store: store, scanner: sc, queue: q}
+}
+
+// Scan checks the document version named in task and records the verdict
+// on the version and, if it is still current, on the application. Scanner
+// failures are returned so the queue retries them, and the version stays
+// pending until a scan succeeds.
+func (u *UploadScanner) Scan(ctx context.Context, task documentTask) error {
+	var version models.DocumentVersion
+	if err := db.DB.Where("id = ?", task.VersionID).First(&version).Error; err != nil {
+		return err
+	}
+	if version.ScanStatus != ScanStatusPending {
+		return nil
+	}
+
+	result, err := u.scanObject(ctx, version.ObjectPath)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if result.Infected {
+		return u.quarantine(ctx, &version, result.Signature)
+	}
+	return u.markClean(&version)
+}
+
+func (u *UploadScanner) scanObject(ctx context.Context, name string) (scanner.Result, error) {
+	reader, err := u.store.Get(ctx, name)
+	if err != nil {
+		return scanner.Result{}, err
+	}
+	defer reader.Close()
+
+	result, err := u.scanner.Scan(ctx, reader)
+	if err != nil {
+		return scanner.Result{}, fmt.Errorf("failed to scan %s: %w", name, err)
+	}
+	return result, nil
+}
+
+// markClean makes the version available and, if it is the application's
+// current resume, queues its processing. A clean resume's hash can identify
+// a duplicate candidate, so detection runs again for its owner.
+func (u *UploadScanner) markClean(version *models.DocumentVersion) error {
+	_, currentColumn, statusColumn := documentColumns(version.Kind)
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(version).Update("scan_status", ScanStatusClean).Error; err != nil {
+			return err
+		}
+		if version.Kind == documentResume {
+			var candidateID string
+			if err := tx.Model(&models.JobApplication{}).Where("id = ?", version.ApplicationID).
+				Select("candidate_id").Scan(&candidateID).Error; err != nil {
+				return err
+			}
+			if err := u.queue.Enqueue(tx, version.OrganizationID, TaskDetectDuplicates, candidateTask{CandidateID: candidateID}); err != nil {
+				return err
+			}
+		}
+		result := tx.Model(&models.JobApplication{}).
+			Where("id = ? AND "+currentColumn+" = ?", version.ApplicationID, version.ID).
+			Update(statusColumn, ScanStatusClean)
+		if result.Error != nil || result.RowsAffected == 0 || version.Kind != documentResume {
+			return result.Error
+		}
+		return u.queue.Enqueue(tx, version.OrganizationID, TaskProcessResume, applicationTask{ApplicationID: version.ApplicationID})
+	})
+}
+
+// quarantine copies an infected version under quarantinePrefix, records it
+// and queues the notification. If the version is current, the application
+// falls back to the latest earlier clean version, or to no document. The
+// original object is deleted last, once nothing points at it.
+func (u *UploadScanner) quarantine(ctx context.Context, version *models.DocumentVersion, signature string) error {
+	record := models.QuarantinedFile{
+		ID:             uuid.NewString(),
+		OrganizationID: version.OrganizationID,
+		ApplicationID:  version.ApplicationID,
+		VersionID:      version.ID,
+		Kind:           version.Kind,
+		Filename:       version.Filename,
+		OriginalPath:   version.ObjectPath,
+		Signature:      signature,
+		Scanner:        u.scanner.Name(),
+		UploadedBy:     version.UploadedBy,
+	}
+	record.QuarantinePath = path.Join(quarantinePrefix, record.ID, path.Base(version.ObjectPath))
+
+	reader, err := u.store.Get(ctx, version.ObjectPath)
+	if err != nil {
+		return err
+	}
+	err = u.store.Put(ctx, record.QuarantinePath, reader, "application/octet-stream")
+	reader.Close()
+	if err != nil {
+		return fmt.Errorf("failed to quarantine %s: %w", version.ObjectPath, err)
+	}
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(version).Update("scan_status", ScanStatusInfected).Error; err != nil {
+			return err
+		}
+		if err := revertCurrentVersion(tx, version); err != nil {
+			return err
+		}
+		if err := u.queue.Enqueue(tx, version.OrganizationID, TaskExportMetadata, applicationTask{ApplicationID: version.ApplicationID}); err != nil {
+			return err
+		}
+		if err := tx.Create(&record).Error; err != nil {
+			return err
+		}
+		return u.queue.Enqueue(tx, version.OrganizationID, TaskNotifyRejectedUpload, quarantineTask{QuarantinedFileID: record.ID})
+	})
+	if err != nil {
+		if delErr := u.store.Delete(ctx, record.QuarantinePath); delErr != nil {
+			log.Printf("Failed to delete unused quarantine copy %s: %v", record.QuarantinePath, delErr)
+		}
+		return err
+	}
+
+	log.Printf("Quarantined %s for application %s: %s", version.ObjectPath, version.ApplicationID, signature)
+	if err := u.store.Delete(ctx, version.ObjectPath); err != nil {
+		log.Printf("Failed to delete infected object %s: %v", version.ObjectPath, err)
+	}
+	return nil
+}
+
+// revertCurrentVersion points the application back at the latest clean
+// version before rejected, if rejected is current.
+func revertCurrentVersion(tx *gorm.DB, rejected *models.DocumentVersion) error {
+	pathColumn, currentColumn, statusColumn := documentColumns(rejected.Kind)
+	updates := map[string]interface{}{pathColumn: "", currentColumn: nil, statusColumn: ScanStatusInfected}
+
+	var previous models.DocumentVersion
+	err := tx.Where("application_id = ? AND kind = ? AND version < ? AND scan_status = ?",
+		rejected.ApplicationID, rejected.Kind, rejected.Version, ScanStatusClean).
+		Order("version DESC").First(&previous).Error
+	if err == nil {
+		updates = map[string]interface{}{pathColumn: previous.ObjectPath, currentColumn: previous.ID, statusColumn: ScanStatusClean}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	return tx.Model(&models.JobApplication{}).
+		Where("id = ? AND "+currentColumn+" = ?", rejected.ApplicationID, rejected.ID).
+		Updates(updates).Error
+}
@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	gcstorage "cloud.google.com/go/storage"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/iterator"
+)
+
+// GCSStore stores objects in a single Google Cloud Storage bucket.
+type GCSStore struct {
+	client     *gcstorage.Client
+	bucketName string
+}
+
+func NewGCSStore(client *gcstorage.Client, bucketName string) *GCSStore {
+	return &GCSStore{
+		client:     client,
+		bucketName: bucketName,
+	}
+}
+
+func (s *GCSStore) bucket() *gcstorage.BucketHandle {
+	return s.client.Bucket(s.bucketName)
+}
+
+func (s *GCSStore) Put(ctx context.Context, name string, r io.Reader, contentType string) error {
+	return s.write(ctx, s.bucket().Object(name), r, contentType)
+}
+
+// PutIfGeneration uses GCS generation preconditions, so the check and the
+// write are atomic across every server writing to the bucket.
+func (s *GCSStore) PutIfGeneration(ctx context.Context, name string, r io.Reader, contentType string, generation int64) error {
+	conditions := gcstorage.Conditions{GenerationMatch: generation}
+	if generation == 0 {
+		conditions = gcstorage.Conditions{DoesNotExist: true}
+	}
+	err := s.write(ctx, s.bucket().Object(name).If(conditions), r, contentType)
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusPreconditionFailed {
+		return ErrPreconditionFailed
+	}
+	return err
+}
+
+func (s *GCSStore) write(ctx context.Context, obj *gcstorage.ObjectHandle, r io.Reader, contentType string) error {
+	wc := obj.NewWriter(ctx)
+	if contentType != "" {
+		wc.ContentType = contentType
+	}
+
+	if _, err := io.Copy(wc, r); err != nil {
+		wc.Close()
+		return fmt.Errorf("io.Copy: %w", err)
+	}
+	if err := wc.Close(); err != nil {
+		return fmt.Errorf("Writer.Close: %w", err)
+	}
+	return nil
+}
+
+func (s *GCSStore) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	reader, err := s.bucket().Object(name).NewReader(ctx)
+	if errors.Is(err, gcstorage.ErrObjectNotExist) {
+		return nil, ErrObjectNotExist
+	}
+	return reader, err
+}
+
+func (s *GCSStore) Stat(ctx context.Context, name string) (*ObjectInfo, error) {
+	attrs, err := s.bucket().Object(name).Attrs(ctx)
+	if errors.Is(err, gcstorage.ErrObjectNotExist) {
+		return nil, ErrObjectNotExist
+	}
+	if err != nil {
+		return nil, err
+	}
+	return gcsObjectInfo(attrs), nil
+}
+
+func (s *GCSStore) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+	it := s.bucket().Objects(ctx, &gcstorage.Query{Prefix: prefix})
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, *gcsObjectInfo(attrs))
+	}
+	return objects, nil
+}
+
+func (s *GCSStore) Delete(ctx context.Context, name string) error {
+	err := s.bucket().Object(name).Delete(ctx)
+	if errors.Is(err, gcstorage.ErrObjectNotExist) {
+		return ErrObjectNotExist
+	}
+	return err
+}
+
+func (s *GCSStore) SignedURL(ctx context.Context, name string, expiry time.Duration) (string, error) {
+	return s.bucket().SignedURL(name, &gcstorage.SignedURLOptions{
+		Scheme:  gcstorage.SigningSchemeV4,
+		Method:  "GET",
+		Expires: time.Now().Add(expiry),
+	})
+}
+
+func gcsObjectInfo(attrs *gcstorage.ObjectAttrs) *ObjectInfo {
+	return &ObjectInfo{
+		Name:        attrs.Name,
+		Size:        attrs.Size,
+		ContentType: attrs.ContentType,
+		Updated:     attrs.Updated,
+		Generation:  attrs.Generation,
+	}
+}
@@ -0,0 +1,205 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LocalStore keeps objects as plain files under a root directory. It is meant
+// for development and CI where no cloud bucket is available. Generations
+// are modification times, and conditional writes are only atomic within
+// one process.
+type LocalStore struct {
+	root string
+	mu   sync.Mutex // serializes the check and rename of conditional writes
+}
+
+func NewLocalStore(root string) (*LocalStore, error) {
+	if root == "" {
+		return nil, fmt.Errorf("local storage path is required")
+	}
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(absRoot, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	return &LocalStore{root: absRoot}, nil
+}
+
+// resolve maps an object name onto a path inside the root, rejecting names
+// that would escape it.
+func (s *LocalStore) resolve(name string) (string, error) {
+	clean := path.Clean("/" + name)
+	if clean == "/" || strings.HasSuffix(name, "/") {
+		return "", ErrInvalidObjectName
+	}
+	return filepath.Join(s.root, filepath.FromSlash(clean)), nil
+}
+
+func (s *LocalStore) Put(ctx context.Context, name string, r io.Reader, contentType string) error {
+	return s.write(name, r, nil)
+}
+
+func (s *LocalStore) PutIfGeneration(ctx context.Context, name string, r io.Reader, contentType string, generation int64) error {
+	return s.write(name, r, func(current int64) error {
+		if current != generation {
+			return ErrPreconditionFailed
+		}
+		return nil
+	})
+}
+
+// write stores r under name. check, if set, gets the current generation
+// under mu just before the object is replaced and can veto the write.
+// File systems stamp modification times from a coarse clock, so the new
+// file's time is set explicitly to keep generations distinct.
+func (s *LocalStore) write(name string, r io.Reader, check func(current int64) error) error {
+	target, err := s.resolve(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+
+	// Write to a temp file first so readers never observe a partial object.
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("io.Copy: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var current time.Time
+	fi, err := os.Stat(target)
+	if err == nil {
+		current = fi.ModTime()
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if check != nil {
+		var generation int64
+		if !current.IsZero() {
+			generation = current.UnixNano()
+		}
+		if err := check(generation); err != nil {
+			return err
+		}
+	}
+
+	modified := time.Now()
+	if !modified.After(current) {
+		modified = current.Add(time.Nanosecond)
+	}
+	if err := os.Chtimes(tmp.Name(), modified, modified); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+func (s *LocalStore) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	target, err := s.resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(target)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrObjectNotExist
+	}
+	return f, err
+}
+
+func (s *LocalStore) Stat(ctx context.Context, name string) (*ObjectInfo, error) {
+	target, err := s.resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := os.Stat(target)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && fi.IsDir()) {
+		return nil, ErrObjectNotExist
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.objectInfo(name, fi), nil
+}
+
+func (s *LocalStore) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+	err := filepath.WalkDir(s.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".upload-") {
+			return nil
+		}
+		rel, err := filepath.Rel(s.root, p)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if !strings.HasPrefix(name, prefix) {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, *s.objectInfo(name, fi))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Name < objects[j].Name })
+	return objects, nil
+}
+
+func (s *LocalStore) Delete(ctx context.Context, name string) error {
+	target, err := s.resolve(name)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(target)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrObjectNotExist
+	}
+	return err
+}
+
+func (s *LocalStore) SignedURL(ctx context.Context, name string, expiry time.Duration) (string, error) {
+	return "", ErrSignedURLNotSupported
+}
+
+func (s *LocalStore) objectInfo(name string, fi fs.FileInfo) *ObjectInfo {
+	return &ObjectInfo{
+		Name:        name,
+		Size:        fi.Size(),
+		ContentType: mime.TypeByExtension(path.Ext(name)),
+		Updated:     fi.ModTime(),
+		Generation:  fi.ModTime().UnixNano(),
+	}
+}
@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type memoryObject struct {
+	data        []byte
+	contentType string
+	updated     time.Time
+	generation  int64
+}
+
+// MemoryStore keeps objects in process memory. Contents are lost on restart,
+// which makes it suitable only for tests and throwaway environments.
+type MemoryStore struct {
+	mu         sync.RWMutex
+	objects    map[string]memoryObject
+	generation int64 // last generation handed out
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{objects: make(map[string]memoryObject)}
+}
+
+func (s *MemoryStore) Put(ctx context.Context, name string, r io.Reader, contentType string) error {
+	if name == "" {
+		return ErrInvalidObjectName
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.store(name, data, contentType)
+	return nil
+}
+
+func (s *MemoryStore) PutIfGeneration(ctx context.Context, name string, r io.Reader, contentType string, generation int64) error {
+	if name == "" {
+		return ErrInvalidObjectName
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.objects[name].generation != generation {
+		return ErrPreconditionFailed
+	}
+	s.store(name, data, contentType)
+	return nil
+}
+
+// store saves an object under a new generation. The caller holds mu.
+func (s *MemoryStore) store(name string, data []byte, contentType string) {
+	s.generation++
+	s.objects[name] = memoryObject{data: data, contentType: contentType, updated: time.Now(), generation: s.generation}
+}
+
+func (s *MemoryStore) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	obj, ok := s.objects[name]
+	if !ok {
+		return nil, ErrObjectNotExist
+	}
+	return io.NopCloser(bytes.NewReader(obj.data)), nil
+}
+
+func (s *MemoryStore) Stat(ctx context.Context, name string) (*ObjectInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	obj, ok := s.objects[name]
+	if !ok {
+		return nil, ErrObjectNotExist
+	}
+	return obj.info(name), nil
+}
+
+func (s *MemoryStore) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var objects []ObjectInfo
+	for name, obj := range s.objects {
+		if strings.HasPrefix(name, prefix) {
+			objects = append(objects, *obj.info(name))
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Name < objects[j].Name })
+	return objects, nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.objects[name]; !ok {
+		return ErrObjectNotExist
+	}
+	delete(s.objects, name)
+	return nil
+}
+
+func (s *MemoryStore) SignedURL(ctx context.Context, name string, expiry time.Duration) (string, error) {
+	return "", ErrSignedURLNotSupported
+}
+
+func (o memoryObject) info(name string) *ObjectInfo {
+	return &ObjectInfo{
+		Name:        name,
+		Size:        int64(len(o.data)),
+		ContentType: o.contentType,
+		Updated:     o.updated,
+		Generation:  o.generation,
+	}
+}
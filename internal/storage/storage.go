@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"time"
+
+	"github.com/resumelens/authservice/internal/config"
+	"github.com/resumelens/authservice/internal/gcs"
+)
+
+// Supported values for STORAGE_BACKEND.
+const (
+	BackendGCS    = "gcs"
+	BackendLocal  = "local"
+	BackendMemory = "memory"
+)
+
+var (
+	ErrObjectNotExist        = errors.New("storage: object doesn't exist")
+	ErrSignedURLNotSupported = errors.New("storage: signed URLs are not supported by this backend")
+	ErrInvalidObjectName     = errors.New("storage: invalid object name")
+	ErrPreconditionFailed    = errors.New("storage: object changed since it was read")
+)
+
+// ObjectInfo describes a stored object without its contents.
+type ObjectInfo struct {
+	Name        string
+	Size        int64
+	ContentType string
+	Updated     time.Time
+	// Generation changes on every write of the object. It is never 0,
+	// which PutIfGeneration uses to mean "does not exist".
+	Generation int64
+}
+
+// BlobStore is the object storage used for resumes, cover letters and their
+// metadata. Object names are slash separated paths relative to the store root.
+type BlobStore interface {
+	Put(ctx context.Context, name string, r io.Reader, contentType string) error
+	// PutIfGeneration writes the object only if its current generation is
+	// generation, or if it doesn't exist when generation is 0. Otherwise it
+	// returns ErrPreconditionFailed.
+	PutIfGeneration(ctx context.Context, name string, r io.Reader, contentType string, generation int64) error
+	Get(ctx context.Context, name string) (io.ReadCloser, error)
+	Stat(ctx context.Context, name string) (*ObjectInfo, error)
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+	Delete(ctx context.Context, name string) error
+	SignedURL(ctx context.Context, name string, expiry time.Duration) (string, error)
+}
+
+// NewBlobStore builds the backend selected by cfg.StorageBackend.
+func NewBlobStore(cfg *config.Config) (BlobStore, error) {
+	switch cfg.StorageBackend {
+	case BackendGCS:
+		gcs.InitClient(cfg.GoogleProjectID, cfg.GoogleCredentialsFile)
+		return NewGCSStore(gcs.GCSClient, cfg.GCSBucketName), nil
+	case BackendLocal:
+		return NewLocalStore(cfg.LocalStoragePath)
+	case BackendMemory:
+		return NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.StorageBackend)
+	}
+}
+
+// maxUpdateAttempts bounds how often Update retries after losing a race.
+const maxUpdateAttempts = 5
+
+// Update is a compare-and-swap on an object: fn gets the current contents
+// (nil when the object doesn't exist) and returns the new ones, which are
+// written only if nobody else wrote in between. On a conflict fn runs again
+// on the fresh contents, so it must not have side effects.
+func Update(ctx context.Context, store BlobStore, name, contentType string, fn func(current []byte) ([]byte, error)) error {
+	for attempt := 1; ; attempt++ {
+		current, generation, err := readGeneration(ctx, store, name)
+		if err != nil {
+			return err
+		}
+		next, err := fn(current)
+		if err != nil {
+			return err
+		}
+
+		err = store.PutIfGeneration(ctx, name, bytes.NewReader(next), contentType, generation)
+		if !errors.Is(err, ErrPreconditionFailed) {
+			return err
+		}
+		if attempt == maxUpdateAttempts {
+			return fmt.Errorf("storage: gave up updating %s: %w", name, err)
+		}
+
+		backoff := time.Duration(attempt*attempt)*20*time.Millisecond + time.Duration(rand.Int63n(int64(20*time.Millisecond)))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// readGeneration returns an object's contents with the generation they were
+// read at, or nil and 0 if it doesn't exist. The generation comes from Stat
+// before the read, so a write in between makes the later conditional put
+// fail rather than succeed on stale data.
+func readGeneration(ctx context.Context, store BlobStore, name string) ([]byte, int64, error) {
+	info, err := store.Stat(ctx, name)
+	if errors.Is(err, ErrObjectNotExist) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	reader, err := store.Get(ctx, name)
+	if errors.Is(err, ErrObjectNotExist) {
+		// Deleted since Stat; the stale generation makes the put fail and
+		// the next attempt starts over.
+		return nil, info.Generation, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, info.Generation, nil
+}
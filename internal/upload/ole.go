@@ -0,0 +1,188 @@
+package upload
+
+import (
+	"encoding/binary"
+	"io"
+	"unicode/utf16"
+)
+
+// OLE compound files hold Word 97-2003 documents, but also Excel workbooks,
+// PowerPoint decks and MSI installers. Only the first have a WordDocument
+// stream in the root storage.
+
+const (
+	oleHeaderSize  = 512
+	oleDirEntry    = 128
+	oleNoStream    = 0xFFFFFFFF
+	oleEndOfChain  = 0xFFFFFFFE
+	oleMaxSectorID = 0xFFFFFFFA
+	oleTypeStream  = 2
+	oleTypeRoot    = 5
+)
+
+// compoundFile reads the sector chains of an OLE compound file. Every
+// sector id comes from the file, so each is checked against its size.
+type compoundFile struct {
+	r          io.ReaderAt
+	size       int64
+	sectorSize int64
+	fat        []uint32 // sector ids of the FAT
+}
+
+// sectors returns how many sectors follow the header, which takes up the
+// first sector.
+func (c *compoundFile) sectors() int64 {
+	return c.size/c.sectorSize - 1
+}
+
+func (c *compoundFile) read(sector uint32, offset int64, p []byte) bool {
+	if int64(sector) >= c.sectors() || offset+int64(len(p)) > c.sectorSize {
+		return false
+	}
+	_, err := c.r.ReadAt(p, (int64(sector)+1)*c.sectorSize+offset)
+	return err == nil
+}
+
+func (c *compoundFile) uint32At(sector uint32, index int64) (uint32, bool) {
+	var b [4]byte
+	if !c.read(sector, index*4, b[:]) {
+		return 0, false
+	}
+	return binary.LittleEndian.Uint32(b[:]), true
+}
+
+// next follows the FAT from sector.
+func (c *compoundFile) next(sector uint32) (uint32, bool) {
+	perSector := c.sectorSize / 4
+	i := int64(sector) / perSector
+	if i >= int64(len(c.fat)) {
+		return 0, false
+	}
+	return c.uint32At(c.fat[i], int64(sector)%perSector)
+}
+
+func openCompoundFile(r io.ReaderAt, size int64) (*compoundFile, uint32, bool) {
+	header := make([]byte, oleHeaderSize)
+	if size < oleHeaderSize {
+		return nil, 0, false
+	}
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, 0, false
+	}
+	shift := binary.LittleEndian.Uint16(header[0x1E:])
+	if shift != 9 && shift != 12 {
+		return nil, 0, false
+	}
+	c := &compoundFile{r: r, size: size, sectorSize: 1 << shift}
+
+	numFAT := int64(binary.LittleEndian.Uint32(header[0x2C:]))
+	if numFAT > c.sectors() {
+		return nil, 0, false
+	}
+	for i := int64(0); i < 109 && int64(len(c.fat)) < numFAT; i++ {
+		c.fat = append(c.fat, binary.LittleEndian.Uint32(header[0x4C+4*i:]))
+	}
+	// The rest of the FAT sector ids are in a chain of DIFAT sectors, each
+	// ending with the id of the next.
+	difat := binary.LittleEndian.Uint32(header[0x44:])
+	perSector := c.sectorSize/4 - 1
+	for visited := int64(0); int64(len(c.fat)) < numFAT; visited++ {
+		if difat > oleMaxSectorID || visited > c.sectors() {
+			return nil, 0, false
+		}
+		for i := int64(0); i < perSector && int64(len(c.fat)) < numFAT; i++ {
+			id, ok := c.uint32At(difat, i)
+			if !ok {
+				return nil, 0, false
+			}
+			c.fat = append(c.fat, id)
+		}
+		var ok bool
+		if difat, ok = c.uint32At(difat, perSector); !ok {
+			return nil, 0, false
+		}
+	}
+	return c, binary.LittleEndian.Uint32(header[0x30:]), true
+}
+
+// oleEntry is the part of a directory entry needed to walk the tree.
+type oleEntry struct {
+	name               string
+	kind               byte
+	left, right, child uint32
+}
+
+// directory reads every entry of the directory chain starting at first.
+func (c *compoundFile) directory(first uint32) ([]oleEntry, bool) {
+	var entries []oleEntry
+	raw := make([]byte, oleDirEntry)
+	sector := first
+	for visited := int64(0); sector != oleEndOfChain; visited++ {
+		if sector > oleMaxSectorID || visited > c.sectors() {
+			return nil, false
+		}
+		for offset := int64(0); offset < c.sectorSize; offset += oleDirEntry {
+			if !c.read(sector, offset, raw) {
+				return nil, false
+			}
+			entries = append(entries, parseOLEEntry(raw))
+		}
+		var ok bool
+		if sector, ok = c.next(sector); !ok {
+			return nil, false
+		}
+	}
+	return entries, true
+}
+
+func parseOLEEntry(raw []byte) oleEntry {
+	nameLen := int(binary.LittleEndian.Uint16(raw[0x40:]))
+	if nameLen > 64 {
+		nameLen = 64
+	}
+	units := make([]uint16, 0, nameLen/2)
+	for i := 0; i+1 < nameLen; i += 2 {
+		if u := binary.LittleEndian.Uint16(raw[i:]); u != 0 {
+			units = append(units, u)
+		}
+	}
+	return oleEntry{
+		name:  string(utf16.Decode(units)),
+		kind:  raw[0x42],
+		left:  binary.LittleEndian.Uint32(raw[0x44:]),
+		right: binary.LittleEndian.Uint32(raw[0x48:]),
+		child: binary.LittleEndian.Uint32(raw[0x4C:]),
+	}
+}
+
+// isWordDocument reports whether an OLE compound file has a WordDocument
+// stream directly under its root storage.
+func isWordDocument(r io.ReaderAt, size int64) bool {
+	c, firstDir, ok := openCompoundFile(r, size)
+	if !ok {
+		return false
+	}
+	entries, ok := c.directory(firstDir)
+	if !ok || len(entries) == 0 || entries[0].kind != oleTypeRoot {
+		return false
+	}
+
+	// The root's children form a tree through their left and right
+	// siblings; visited guards against cycles.
+	visited := make(map[uint32]bool)
+	pending := []uint32{entries[0].child}
+	for len(pending) > 0 {
+		id := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if id == oleNoStream || int(id) >= len(entries) || visited[id] {
+			continue
+		}
+		visited[id] = true
+		e := entries[id]
+		if e.kind == oleTypeStream && e.name == "WordDocument" {
+			return true
+		}
+		pending = append(pending, e.left, e.right)
+	}
+	return false
+}
@@ -0,0 +1,340 @@
+// Package upload decides whether an uploaded file is acceptable before it is
+// stored. Types are identified from the content, not the file name, and zip
+// containers are inspected without being decompressed.
+package upload
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"unicode/utf8"
+)
+
+// Error is a rejected upload. Status is the HTTP status to answer with.
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// Is matches on Code so a sentinel still matches after WithDetail.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// WithDetail returns a copy of e with more specific text.
+func (e *Error) WithDetail(format string, args ...interface{}) *Error {
+	return &Error{Status: e.Status, Code: e.Code, Message: e.Message + ": " + fmt.Sprintf(format, args...)}
+}
+
+var (
+	ErrEmptyFile         = &Error{http.StatusBadRequest, "empty_file", "file is empty"}
+	ErrFileTooLarge      = &Error{http.StatusRequestEntityTooLarge, "file_too_large", "file is too large"}
+	ErrRequestTooLarge   = &Error{http.StatusRequestEntityTooLarge, "request_too_large", "request is too large"}
+	ErrUnsupportedType   = &Error{http.StatusUnsupportedMediaType, "unsupported_type", "file type is not allowed; upload a PDF, DOCX, DOC, TXT or RTF file"}
+	ErrExtensionMismatch = &Error{http.StatusUnsupportedMediaType, "extension_mismatch", "file extension does not match its contents"}
+	ErrNotArchive        = &Error{http.StatusUnsupportedMediaType, "not_archive", "file is not a zip archive"}
+	ErrTooManyEntries    = &Error{http.StatusRequestEntityTooLarge, "too_many_entries", "archive has too many files"}
+	ErrArchiveTooLarge   = &Error{http.StatusRequestEntityTooLarge, "archive_too_large", "archive expands to too much data"}
+	ErrCompressionRatio  = &Error{http.StatusUnprocessableEntity, "compression_ratio", "archive entry is compressed suspiciously well"}
+	ErrEncryptedArchive  = &Error{http.StatusUnprocessableEntity, "encrypted_archive", "encrypted archives are not supported"}
+	ErrNestedArchive     = &Error{http.StatusUnprocessableEntity, "nested_archive", "archives inside archives are not supported"}
+	ErrUnsafePath        = &Error{http.StatusUnprocessableEntity, "unsafe_path", "archive contains an unsafe file path"}
+	ErrCorruptArchive    = &Error{http.StatusUnprocessableEntity, "corrupt_archive", "archive is damaged"}
+)
+
+// Type is an allowed document type.
+type Type struct {
+	Name        string
+	ContentType string
+	Extension   string   // canonical extension used when storing
+	Extensions  []string // extensions accepted from uploaders
+}
+
+var (
+	TypePDF  = &Type{"pdf", "application/pdf", ".pdf", []string{".pdf"}}
+	TypeDOCX = &Type{"docx", "application/vnd.openxmlformats-officedocument.wordprocessingml.document", ".docx", []string{".docx"}}
+	TypeDOC  = &Type{"doc", "application/msword", ".doc", []string{".doc"}}
+	TypeRTF  = &Type{"rtf", "application/rtf", ".rtf", []string{".rtf"}}
+	TypeTXT  = &Type{"txt", "text/plain; charset=utf-8", ".txt", []string{".txt", ".text", ""}}
+)
+
+var types = []*Type{TypePDF, TypeDOCX, TypeDOC, TypeRTF, TypeTXT}
+
+// Lookup returns the type a stored document was saved as, from its content
+// type or, failing that, the extension of name. It returns nil for
+// anything else.
+func Lookup(contentType, name string) *Type {
+	for _, t := range types {
+		if t.ContentType == contentType {
+			return t
+		}
+	}
+	ext := strings.ToLower(path.Ext(name))
+	for _, t := range types {
+		if ext != "" && ext == t.Extension {
+			return t
+		}
+	}
+	return nil
+}
+
+// Limits bound what a single document or archive may contain.
+type Limits struct {
+	MaxFileSize int64 // per document, and per document inside an archive
+	// MaxArchiveSize bounds the total uncompressed size of an archive,
+	// including the parts of a DOCX.
+	MaxArchiveSize    int64
+	MaxArchiveEntries int
+	// MaxCompressionRatio is the highest uncompressed/compressed ratio
+	// accepted for an entry larger than 1 MB. Text compresses about 10:1;
+	// zip bombs reach thousands.
+	MaxCompressionRatio float64
+}
+
+func DefaultLimits(maxFileSize int64) Limits {
+	return Limits{
+		MaxFileSize:         maxFileSize,
+		MaxArchiveSize:      50 * maxFileSize,
+		MaxArchiveEntries:   1000,
+		MaxCompressionRatio: 100,
+	}
+}
+
+const sniffLen = 512
+
+var (
+	oleMagic = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+	zipMagic = []byte("PK\x03\x04")
+)
+
+// Document identifies a single uploaded document and checks it against the
+// limits. filename is only used to check that the extension agrees with the
+// content.
+func (l Limits) Document(r io.ReaderAt, size int64, filename string) (*Type, error) {
+	if size == 0 {
+		return nil, ErrEmptyFile
+	}
+	if size > l.MaxFileSize {
+		return nil, ErrFileTooLarge.WithDetail("the limit is %d MB", l.MaxFileSize>>20)
+	}
+
+	head := make([]byte, sniffLen)
+	n, err := r.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	head = head[:n]
+
+	var t *Type
+	switch {
+	case bytes.HasPrefix(head, []byte("%PDF-")):
+		t = TypePDF
+	case bytes.HasPrefix(head, oleMagic):
+		if !isWordDocument(r, size) {
+			// An Excel workbook, installer or other compound file.
+			return nil, ErrUnsupportedType
+		}
+		t = TypeDOC
+	case bytes.HasPrefix(head, []byte(`{\rtf`)):
+		t = TypeRTF
+	case bytes.HasPrefix(head, zipMagic):
+		if err := l.checkDOCX(r, size); err != nil {
+			return nil, err
+		}
+		t = TypeDOCX
+	case looksLikeText(r, size):
+		t = TypeTXT
+	default:
+		return nil, ErrUnsupportedType
+	}
+
+	ext := strings.ToLower(path.Ext(filename))
+	for _, allowed := range t.Extensions {
+		if ext == allowed {
+			return t, nil
+		}
+	}
+	return nil, ErrExtensionMismatch.WithDetail("%s file named %q", strings.ToUpper(t.Name), path.Base(filename))
+}
+
+// checkDOCX makes sure a zip container is a Word document and not a bomb.
+func (l Limits) checkDOCX(r io.ReaderAt, size int64) error {
+	zr, err := l.openArchive(r, size)
+	if err != nil {
+		return err
+	}
+	var hasContentTypes, hasDocument bool
+	for _, f := range zr.File {
+		switch f.Name {
+		case "[Content_Types].xml":
+			hasContentTypes = true
+		case "word/document.xml":
+			hasDocument = true
+		}
+	}
+	if !hasContentTypes || !hasDocument {
+		// A zip that isn't a Word document, e.g. a renamed archive.
+		return ErrUnsupportedType
+	}
+	return nil
+}
+
+// Archive checks a zip archive of documents: entry count, total expanded
+// size, compression ratio, encryption, nesting and paths. Each document
+// inside still needs ReadEntry and Document.
+func (l Limits) Archive(r io.ReaderAt, size int64) (*zip.Reader, error) {
+	if size == 0 {
+		return nil, ErrEmptyFile
+	}
+	head := make([]byte, len(zipMagic))
+	if _, err := r.ReadAt(head, 0); err != nil || !bytes.Equal(head, zipMagic) {
+		return nil, ErrNotArchive
+	}
+
+	zr, err := l.openArchive(r, size)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range zr.File {
+		if !safePath(f.Name) {
+			return nil, ErrUnsafePath.WithDetail("%q", f.Name)
+		}
+		if isArchiveName(f.Name) {
+			return nil, ErrNestedArchive.WithDetail("%q", f.Name)
+		}
+	}
+	return zr, nil
+}
+
+// openArchive reads the zip directory and applies the limits that every zip
+// container shares. The sizes come from the directory, which an attacker
+// controls, so readers must still cap how much they read from each entry.
+func (l Limits) openArchive(r io.ReaderAt, size int64) (*zip.Reader, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, ErrCorruptArchive
+	}
+	if len(zr.File) > l.MaxArchiveEntries {
+		return nil, ErrTooManyEntries.WithDetail("the limit is %d", l.MaxArchiveEntries)
+	}
+
+	var total uint64
+	for _, f := range zr.File {
+		if f.Flags&0x1 != 0 {
+			return nil, ErrEncryptedArchive
+		}
+		total += f.UncompressedSize64
+		if total > uint64(l.MaxArchiveSize) {
+			return nil, ErrArchiveTooLarge.WithDetail("the limit is %d MB", l.MaxArchiveSize>>20)
+		}
+		if f.UncompressedSize64 > 1<<20 {
+			compressed := f.CompressedSize64
+			if compressed == 0 {
+				compressed = 1
+			}
+			if float64(f.UncompressedSize64)/float64(compressed) > l.MaxCompressionRatio {
+				return nil, ErrCompressionRatio.WithDetail("%q", f.Name)
+			}
+		}
+	}
+	return zr, nil
+}
+
+// ReadEntry reads an archive entry, failing if it expands beyond the size the
+// directory declared or the per-file limit, or if it is itself an archive
+// whatever its name.
+func (l Limits) ReadEntry(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, ErrCorruptArchive.WithDetail("%q", f.Name)
+	}
+	defer rc.Close()
+
+	limit := l.MaxFileSize
+	if declared := int64(f.UncompressedSize64); declared < limit {
+		limit = declared
+	}
+	data, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, ErrCorruptArchive.WithDetail("%q", f.Name)
+	}
+	if int64(len(data)) > limit {
+		return nil, ErrFileTooLarge.WithDetail("%q", f.Name)
+	}
+	if l.isArchive(data) {
+		return nil, ErrNestedArchive.WithDetail("%q", f.Name)
+	}
+	return data, nil
+}
+
+func safePath(name string) bool {
+	if name == "" || strings.HasPrefix(name, "/") || strings.Contains(name, `\`) || strings.ContainsRune(name, 0) {
+		return false
+	}
+	if len(name) > 1 && name[1] == ':' {
+		return false // C:foo
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+var archiveExtensions = []string{".zip", ".rar", ".7z", ".tar", ".gz", ".tgz", ".bz2", ".xz", ".jar"}
+
+func isArchiveName(name string) bool {
+	lower := strings.ToLower(name)
+	for _, ext := range archiveExtensions {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// archiveMagic holds the signatures of the archive formats in
+// archiveExtensions, other than zip.
+var archiveMagic = [][]byte{
+	[]byte("Rar!\x1a\x07"),
+	[]byte("7z\xbc\xaf\x27\x1c"),
+	{0x1f, 0x8b},           // gzip
+	[]byte("BZh"),          // bzip2
+	[]byte("\xfd7zXZ\x00"), // xz
+}
+
+// isArchive sniffs data for an archive. A zip counts unless it is a DOCX,
+// which is a zip too.
+func (l Limits) isArchive(data []byte) bool {
+	for _, magic := range archiveMagic {
+		if bytes.HasPrefix(data, magic) {
+			return true
+		}
+	}
+	if len(data) > 262 && bytes.Equal(data[257:262], []byte("ustar")) {
+		return true
+	}
+	if bytes.HasPrefix(data, zipMagic) || bytes.HasPrefix(data, []byte("PK\x05\x06")) {
+		return l.checkDOCX(bytes.NewReader(data), int64(len(data))) != nil
+	}
+	return false
+}
+
+// looksLikeText accepts UTF-8 without NUL bytes, checking the whole file
+// since a text file is small enough to pass the size limit.
+func looksLikeText(r io.ReaderAt, size int64) bool {
+	data := make([]byte, size)
+	if n, err := r.ReadAt(data, 0); int64(n) != size && err != nil {
+		return false
+	}
+	return utf8.Valid(data) && bytes.IndexByte(data, 0) < 0
+}
@@ -0,0 +1,290 @@
+package upload
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+type zipEntry struct {
+	name      string
+	data      []byte
+	encrypted bool
+}
+
+// buildZip writes entries, deflated, into an in-memory zip.
+func buildZip(t *testing.T, entries ...zipEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, e := range entries {
+		h := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		if e.encrypted {
+			h.Flags |= 0x1
+		}
+		f, err := w.CreateHeader(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write(e.data)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func buildDOCX(t *testing.T, extra ...zipEntry) []byte {
+	t.Helper()
+	return buildZip(t, append([]zipEntry{
+		{name: "[Content_Types].xml", data: []byte(`<Types/>`)},
+		{name: "word/document.xml", data: []byte(`<w:document/>`)},
+	}, extra...)...)
+}
+
+type oleStream struct {
+	name        string
+	left, right uint32
+}
+
+// buildOLE writes a compound file with 512-byte sectors: the header, one
+// FAT sector and one directory sector. The root's child is the first
+// stream; the others hang off it through left and right.
+func buildOLE(streams ...oleStream) []byte {
+	const sector = 512
+	data := make([]byte, 3*sector)
+	le := binary.LittleEndian
+
+	copy(data, oleMagic)
+	le.PutUint16(data[0x18:], 0x3E)
+	le.PutUint16(data[0x1A:], 3)
+	le.PutUint16(data[0x1C:], 0xFFFE)
+	le.PutUint16(data[0x1E:], 9)
+	le.PutUint16(data[0x20:], 6)
+	le.PutUint32(data[0x2C:], 1)             // FAT sectors
+	le.PutUint32(data[0x30:], 1)             // first directory sector
+	le.PutUint32(data[0x38:], 4096)          // mini stream cutoff
+	le.PutUint32(data[0x3C:], oleEndOfChain) // no mini FAT
+	le.PutUint32(data[0x44:], oleEndOfChain) // no DIFAT sectors
+	for i := 0; i < 109; i++ {
+		le.PutUint32(data[0x4C+4*i:], oleNoStream)
+	}
+	le.PutUint32(data[0x4C:], 0)
+
+	fat := data[sector : 2*sector]
+	for i := 0; i < sector/4; i++ {
+		le.PutUint32(fat[4*i:], oleNoStream)
+	}
+	le.PutUint32(fat[0:], 0xFFFFFFFD) // the FAT itself
+	le.PutUint32(fat[4:], oleEndOfChain)
+
+	dir := data[2*sector:]
+	entry := func(i int, name string, kind byte, left, right, child uint32) {
+		e := dir[i*oleDirEntry : (i+1)*oleDirEntry]
+		units := utf16.Encode([]rune(name))
+		for j, u := range units {
+			le.PutUint16(e[2*j:], u)
+		}
+		le.PutUint16(e[0x40:], uint16(2*len(units)+2))
+		e[0x42] = kind
+		le.PutUint32(e[0x44:], left)
+		le.PutUint32(e[0x48:], right)
+		le.PutUint32(e[0x4C:], child)
+	}
+	entry(0, "Root Entry", oleTypeRoot, oleNoStream, oleNoStream, 1)
+	for i, s := range streams {
+		entry(i+1, s.name, oleTypeStream, s.left, s.right, oleNoStream)
+	}
+	return data
+}
+
+func TestDocument(t *testing.T) {
+	limits := DefaultLimits(1 << 20)
+	tests := []struct {
+		name     string
+		data     []byte
+		filename string
+		want     *Type
+		wantErr  error
+	}{
+		{name: "pdf", data: []byte("%PDF-1.7\n..."), filename: "resume.pdf", want: TypePDF},
+		{name: "docx", data: buildDOCX(t), filename: "Resume.DOCX", want: TypeDOCX},
+		{name: "doc", data: buildOLE(oleStream{name: "WordDocument", left: oleNoStream, right: oleNoStream}), filename: "resume.doc", want: TypeDOC},
+		{name: "doc with the stream deeper in the tree", data: buildOLE(
+			oleStream{name: "1Table", left: oleNoStream, right: 2},
+			oleStream{name: "WordDocument", left: oleNoStream, right: oleNoStream},
+		), filename: "resume.doc", want: TypeDOC},
+		{name: "rtf", data: []byte(`{\rtf1 Jane}`), filename: "resume.rtf", want: TypeRTF},
+		{name: "text", data: []byte("Jane Doe\nGo engineer"), filename: "resume.txt", want: TypeTXT},
+		{name: "text without extension", data: []byte("Jane Doe"), filename: "resume", want: TypeTXT},
+
+		{name: "empty", data: nil, filename: "resume.pdf", wantErr: ErrEmptyFile},
+		{name: "too large", data: bytes.Repeat([]byte("a"), 1<<20+1), filename: "resume.txt", wantErr: ErrFileTooLarge},
+		{name: "executable", data: []byte("MZ\x90\x00\x03\x00\x00\x00"), filename: "resume.pdf", wantErr: ErrUnsupportedType},
+		{name: "text with NUL", data: []byte("Jane\x00Doe"), filename: "resume.txt", wantErr: ErrUnsupportedType},
+		{name: "invalid UTF-8", data: []byte("Jane \xff Doe"), filename: "resume.txt", wantErr: ErrUnsupportedType},
+		{name: "pdf named docx", data: []byte("%PDF-1.7"), filename: "resume.docx", wantErr: ErrExtensionMismatch},
+		{name: "docx named pdf", data: buildDOCX(t), filename: "resume.pdf", wantErr: ErrExtensionMismatch},
+		{name: "zip that isn't a docx", data: buildZip(t, zipEntry{name: "resume.txt", data: []byte("Jane")}), filename: "resume.docx", wantErr: ErrUnsupportedType},
+		{name: "corrupt zip", data: []byte("PK\x03\x04 truncated"), filename: "resume.docx", wantErr: ErrCorruptArchive},
+		{name: "encrypted docx", data: buildDOCX(t, zipEntry{name: "word/secret.xml", data: []byte("x"), encrypted: true}), filename: "resume.docx", wantErr: ErrEncryptedArchive},
+		{name: "xls renamed doc", data: buildOLE(oleStream{name: "Workbook", left: oleNoStream, right: oleNoStream}), filename: "resume.doc", wantErr: ErrUnsupportedType},
+		{name: "WordDocument storage", data: func() []byte {
+			data := buildOLE(oleStream{name: "WordDocument", left: oleNoStream, right: oleNoStream})
+			data[2*512+oleDirEntry+0x42] = 1 // a storage, not a stream
+			return data
+		}(), filename: "resume.doc", wantErr: ErrUnsupportedType},
+		{name: "OLE header only", data: append(append([]byte{}, oleMagic...), make([]byte, 100)...), filename: "resume.doc", wantErr: ErrUnsupportedType},
+		{name: "OLE directory cycle", data: buildOLE(
+			oleStream{name: "1Table", left: oleNoStream, right: 2},
+			oleStream{name: "Data", left: 1, right: oleNoStream},
+		), filename: "resume.doc", wantErr: ErrUnsupportedType},
+		{name: "OLE directory outside the file", data: func() []byte {
+			data := buildOLE(oleStream{name: "WordDocument", left: oleNoStream, right: oleNoStream})
+			binary.LittleEndian.PutUint32(data[0x30:], 1000)
+			return data
+		}(), filename: "resume.doc", wantErr: ErrUnsupportedType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := limits.Document(bytes.NewReader(tt.data), int64(len(tt.data)), tt.filename)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v; want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Document: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("type = %s; want %s", got.Name, tt.want.Name)
+			}
+		})
+	}
+}
+
+func TestArchive(t *testing.T) {
+	limits := Limits{MaxFileSize: 4 << 20, MaxArchiveSize: 8 << 20, MaxArchiveEntries: 3, MaxCompressionRatio: 100}
+	resume := zipEntry{name: "resumes/jane.pdf", data: []byte("%PDF-1.7")}
+	random := make([]byte, 3<<20)
+	for i := range random {
+		random[i] = byte(i * 7919 >> 3)
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr error
+	}{
+		{name: "ok", data: buildZip(t, resume, zipEntry{name: "jane.docx", data: buildDOCX(t)})},
+		{name: "empty", data: nil, wantErr: ErrEmptyFile},
+		{name: "not a zip", data: []byte("%PDF-1.7"), wantErr: ErrNotArchive},
+		{name: "corrupt", data: []byte("PK\x03\x04 truncated"), wantErr: ErrCorruptArchive},
+		{name: "too many entries", data: buildZip(t, resume, resume, resume, resume), wantErr: ErrTooManyEntries},
+		{name: "expands too much", data: buildZip(t,
+			zipEntry{name: "a.txt", data: random}, zipEntry{name: "b.txt", data: random}, zipEntry{name: "c.txt", data: random},
+		), wantErr: ErrArchiveTooLarge},
+		{name: "zip bomb", data: buildZip(t, zipEntry{name: "bomb.txt", data: make([]byte, 2<<20)}), wantErr: ErrCompressionRatio},
+		{name: "small entries may compress well", data: buildZip(t, zipEntry{name: "zeros.txt", data: make([]byte, 1<<20)})},
+		{name: "encrypted", data: buildZip(t, zipEntry{name: "jane.pdf", data: []byte("x"), encrypted: true}), wantErr: ErrEncryptedArchive},
+		{name: "parent directory", data: buildZip(t, zipEntry{name: "../../etc/cron.d/x", data: []byte("x")}), wantErr: ErrUnsafePath},
+		{name: "absolute path", data: buildZip(t, zipEntry{name: "/etc/passwd", data: []byte("x")}), wantErr: ErrUnsafePath},
+		{name: "backslash", data: buildZip(t, zipEntry{name: `..\evil.pdf`, data: []byte("x")}), wantErr: ErrUnsafePath},
+		{name: "drive letter", data: buildZip(t, zipEntry{name: "C:evil.pdf", data: []byte("x")}), wantErr: ErrUnsafePath},
+		{name: "nested by name", data: buildZip(t, resume, zipEntry{name: "more.ZIP", data: []byte("x")}), wantErr: ErrNestedArchive},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zr, err := limits.Archive(bytes.NewReader(tt.data), int64(len(tt.data)))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v; want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Archive: %v", err)
+			}
+			if zr == nil || len(zr.File) == 0 {
+				t.Error("Archive returned no entries")
+			}
+		})
+	}
+}
+
+func TestReadEntry(t *testing.T) {
+	limits := Limits{MaxFileSize: 4096, MaxArchiveSize: 1 << 20, MaxArchiveEntries: 10, MaxCompressionRatio: 100}
+	gz := []byte{0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00}
+	tar := make([]byte, 512)
+	copy(tar[257:], "ustar\x0000")
+
+	tests := []struct {
+		name string
+		data []byte
+		// declared overrides the uncompressed size in the directory.
+		declared uint64
+		wantErr  error
+	}{
+		{name: "document", data: []byte("%PDF-1.7 Jane Doe")},
+		{name: "docx is not an archive", data: buildDOCX(t)},
+		{name: "over the file limit", data: bytes.Repeat([]byte("a"), 4097), wantErr: ErrFileTooLarge},
+		{name: "larger than declared", data: []byte("Jane Doe, Go engineer"), declared: 4},
+		{name: "zip", data: buildZip(t, zipEntry{name: "inner.pdf", data: []byte("%PDF")}), wantErr: ErrNestedArchive},
+		{name: "gzip", data: gz, wantErr: ErrNestedArchive},
+		{name: "rar", data: []byte("Rar!\x1a\x07\x01\x00"), wantErr: ErrNestedArchive},
+		{name: "7z", data: []byte("7z\xbc\xaf\x27\x1c\x00\x04"), wantErr: ErrNestedArchive},
+		{name: "xz", data: []byte("\xfd7zXZ\x00\x00\x04"), wantErr: ErrNestedArchive},
+		{name: "bzip2", data: []byte("BZh91AY&SY"), wantErr: ErrNestedArchive},
+		{name: "empty zip", data: []byte("PK\x05\x06" + strings.Repeat("\x00", 18)), wantErr: ErrNestedArchive},
+		{name: "tar", data: tar, wantErr: ErrNestedArchive},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Entries are named like documents; only the content gives
+			// the archives away.
+			archive := buildZip(t, zipEntry{name: "resume.pdf", data: tt.data})
+			zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			f := zr.File[0]
+			if tt.declared != 0 {
+				f.UncompressedSize64 = tt.declared
+			}
+
+			got, err := limits.ReadEntry(f)
+			switch {
+			case tt.declared != 0:
+				// Reading past the declared size fails one way or another,
+				// never by returning the extra bytes.
+				if err == nil {
+					t.Fatalf("ReadEntry returned %q; want an error", got)
+				}
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v; want %v", err, tt.wantErr)
+				}
+			case err != nil:
+				t.Fatalf("ReadEntry: %v", err)
+			case !bytes.Equal(got, tt.data):
+				t.Errorf("ReadEntry = %q; want %q", got, tt.data)
+			}
+		})
+	}
+}
+
+func TestErrorDetail(t *testing.T) {
+	err := ErrUnsafePath.WithDetail("%q", "../x")
+	if !errors.Is(err, ErrUnsafePath) || errors.Is(err, ErrNestedArchive) {
+		t.Errorf("errors.Is on %v matched the wrong sentinel", err)
+	}
+	if want := fmt.Sprintf("%s: %q", ErrUnsafePath.Message, "../x"); err.Error() != want {
+		t.Errorf("Error() = %q; want %q", err.Error(), want)
+	}
+}
@@ -2,7 +2,9 @@ package utils
 
 import (
 	"fmt"
+	"mime"
 	"net/smtp"
+	"strings"
 
 	"github.com/resumelens/authservice/internal/config"
 )
@@ -21,10 +23,98 @@ func SendInviteEmail(recipientEmail, inviteToken string, cfg *config.Config) err
 
 	body := fmt.Sprintf("Hello,\n\nYou've been invited to join ResumeLens.\n\nAccept your invite here: %s\n\nThis invite expires in 48 hours.\n\nBest,\n%s", inviteLink, senderName)
 
-	message := []byte(fmt.Sprintf("Subject: %s\r\n\r\n%s", subject, body))
+	message := buildMessage(subject, body)
 
 	auth := smtp.PlainAuth("", smtpUser, smtpPass, smtpHost)
 	addr := fmt.Sprintf("%s:%s", smtpHost, smtpPort)
 
 	return smtp.SendMail(addr, auth, from, to, message)
 }
+
+func SendMagicLinkEmail(recipientEmail, candidateName, jobTitle, magicToken string, cfg *config.Config) error {
+	smtpHost := cfg.SMTPHost
+	smtpPort := cfg.SMTPPort
+	smtpUser := cfg.SMTPUser
+	smtpPass := cfg.SMTPPass
+	senderName := cfg.SMTPSenderName
+
+	from := smtpUser
+	to := []string{recipientEmail}
+	subject := fmt.Sprintf("Your application for %s", jobTitle)
+	portalLink := fmt.Sprintf("https://resumelens.com/application?token=%s", magicToken)
+
+	body := fmt.Sprintf("Hello %s,\n\nThanks for applying for %s.\n\nYou can check the status of your application, update your documents or withdraw at any time here: %s\n\nKeep this link private; anyone with it can manage your application. It expires in %d days.\n\nBest,\n%s", candidateName, jobTitle, portalLink, cfg.MagicLinkExpiryDays, senderName)
+
+	message := buildMessage(subject, body)
+
+	auth := smtp.PlainAuth("", smtpUser, smtpPass, smtpHost)
+	addr := fmt.Sprintf("%s:%s", smtpHost, smtpPort)
+
+	return smtp.SendMail(addr, auth, from, to, message)
+}
+
+// SendUploadRejectedEmail tells the uploader that a file failed the malware
+// scan. Candidates get a link to their portal to upload a clean copy;
+// magicToken is empty for recruiters.
+func SendUploadRejectedEmail(recipientEmail, recipientName, filename, jobTitle, magicToken string, cfg *config.Config) error {
+	smtpHost := cfg.SMTPHost
+	smtpPort := cfg.SMTPPort
+	smtpUser := cfg.SMTPUser
+	smtpPass := cfg.SMTPPass
+	senderName := cfg.SMTPSenderName
+
+	from := smtpUser
+	to := []string{recipientEmail}
+	subject := fmt.Sprintf("Your file for %s was rejected", jobTitle)
+
+	greeting := "Hello"
+	if recipientName != "" {
+		greeting = "Hello " + recipientName
+	}
+	next := "Please upload a clean copy."
+	if magicToken != "" {
+		next = fmt.Sprintf("Please upload a clean copy here: https://resumelens.com/application?token=%s", magicToken)
+	}
+
+	body := fmt.Sprintf("%s,\n\nThe file %q you uploaded for %s was flagged by our virus scanner and has not been added to the application.\n\n%s\n\nBest,\n%s", greeting, filename, jobTitle, next, senderName)
+
+	message := buildMessage(subject, body)
+
+	auth := smtp.PlainAuth("", smtpUser, smtpPass, smtpHost)
+	addr := fmt.Sprintf("%s:%s", smtpHost, smtpPort)
+
+	return smtp.SendMail(addr, auth, from, to, message)
+}
+
+// SendMentionEmail tells a user they were @mentioned in a note on a
+// candidate.
+func SendMentionEmail(recipientEmail, authorEmail, candidateName, candidateID, note string, cfg *config.Config) error {
+	smtpHost := cfg.SMTPHost
+	smtpPort := cfg.SMTPPort
+	smtpUser := cfg.SMTPUser
+	smtpPass := cfg.SMTPPass
+	senderName := cfg.SMTPSenderName
+
+	from := smtpUser
+	to := []string{recipientEmail}
+	subject := fmt.Sprintf("%s mentioned you in a note on %s", authorEmail, candidateName)
+	candidateLink := fmt.Sprintf("https://resumelens.com/candidates/%s", candidateID)
+
+	body := fmt.Sprintf("Hello,\n\n%s mentioned you in a note on %s:\n\n%s\n\nSee the candidate here: %s\n\nBest,\n%s", authorEmail, candidateName, note, candidateLink, senderName)
+
+	message := buildMessage(subject, body)
+
+	auth := smtp.PlainAuth("", smtpUser, smtpPass, smtpHost)
+	addr := fmt.Sprintf("%s:%s", smtpHost, smtpPort)
+
+	return smtp.SendMail(addr, auth, from, to, message)
+}
+
+// buildMessage formats a plain-text mail. Subjects carry candidate names and
+// job titles, so line breaks are dropped and the subject is Q-encoded; it
+// can't end the header and start another.
+func buildMessage(subject, body string) []byte {
+	subject = strings.Join(strings.Fields(subject), " ")
+	return []byte(fmt.Sprintf("Subject: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n%s",
+		mime.QEncoding.Encode("utf-8", subject), body))
+}
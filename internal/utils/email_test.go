@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/mail"
+	"testing"
+)
+
+func TestBuildMessageSubject(t *testing.T) {
+	name := "Élodie\r\nBcc: victim@example.com\r\n\r\nInjected body"
+	msg, err := mail.ReadMessage(bytes.NewReader(buildMessage("Your application for "+name, "Hello")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msg.Header) != 3 || msg.Header.Get("Bcc") != "" {
+		t.Errorf("headers = %v; want Subject, MIME-Version and Content-Type only", msg.Header)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Your application for Élodie Bcc: victim@example.com Injected body"; subject != want {
+		t.Errorf("subject = %q; want %q", subject, want)
+	}
+	if body, _ := io.ReadAll(msg.Body); string(body) != "Hello" {
+		t.Errorf("body = %q; want %q", body, "Hello")
+	}
+}
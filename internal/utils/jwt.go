@@ -7,10 +7,16 @@ import (
 	"github.com/resumelens/authservice/internal/config"
 )
 
-var jwtSecret []byte
+var (
+	jwtSecret     []byte
+	expiryMinutes = 60
+)
 
 func InitJWT(cfg *config.Config) {
 	jwtSecret = []byte(cfg.JWTSecret)
+	if cfg.JWTExpiry > 0 {
+		expiryMinutes = cfg.JWTExpiry
+	}
 }
 
 type JWTClaim struct {
@@ -22,7 +28,6 @@ type JWTClaim struct {
 }
 
 func GenerateJWT(userID, email, role, organizationID string) (string, error) {
-	expiryMinutes := 60 // default, can be overridden by config
 	claims := &JWTClaim{
 		UserID:         userID,
 		Email:          email,
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 )
 
@@ -13,3 +14,10 @@ func GenerateRandomToken(length int) string {
 	}
 	return hex.EncodeToString(bytes)
 }
+
+// HashToken returns the hex encoded SHA-256 of an opaque token so it can be
+// stored and looked up without keeping the token itself.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
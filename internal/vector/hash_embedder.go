@@ -0,0 +1,99 @@
+package vector
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// stopWords carry no meaning for matching resumes to jobs.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+	"for": true, "from": true, "has": true, "have": true, "i": true, "in": true, "is": true, "it": true,
+	"of": true, "on": true, "or": true, "our": true, "the": true, "to": true, "was": true, "we": true,
+	"will": true, "with": true, "you": true, "your": true, "my": true, "this": true, "that": true,
+}
+
+// HashEmbedder is a bag-of-words embedder that needs no model: each word and
+// adjacent word pair is hashed into one of a fixed number of buckets with a
+// hashed sign, weighted by log term frequency, and the result is normalized.
+// Similar texts share words and therefore buckets.
+type HashEmbedder struct {
+	dims int
+}
+
+func NewHashEmbedder(dims int) *HashEmbedder {
+	return &HashEmbedder{dims: dims}
+}
+
+func (e *HashEmbedder) Dimensions() int { return e.dims }
+
+func (e *HashEmbedder) Model() string { return fmt.Sprintf("hash-bow-%d", e.dims) }
+
+func (e *HashEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		out[i] = e.embed(text)
+	}
+	return out, nil
+}
+
+func (e *HashEmbedder) embed(text string) []float32 {
+	counts := make(map[string]int)
+	var prev string
+	for _, word := range Tokenize(text) {
+		counts[word]++
+		if prev != "" {
+			counts[prev+" "+word]++
+		}
+		prev = word
+	}
+
+	vec := make([]float32, e.dims)
+	for term, n := range counts {
+		h := fnv.New64a()
+		h.Write([]byte(term))
+		sum := h.Sum64()
+		weight := float32(1 + math.Log(float64(n)))
+		if sum>>63 == 1 {
+			weight = -weight
+		}
+		vec[sum%uint64(e.dims)] += weight
+	}
+
+	var norm float64
+	for _, v := range vec {
+		norm += float64(v) * float64(v)
+	}
+	if norm > 0 {
+		scale := float32(1 / math.Sqrt(norm))
+		for i := range vec {
+			vec[i] *= scale
+		}
+	}
+	return vec
+}
+
+// Tokenize lowercases text and splits it into words, keeping characters
+// that matter in technology names ("c++", "c#", "node.js") and dropping stop
+// words.
+func Tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '+' && r != '#' && r != '.'
+	})
+	words := fields[:0]
+	for _, f := range fields {
+		f = strings.Trim(f, ".")
+		if f == "" || stopWords[f] {
+			continue
+		}
+		words = append(words, f)
+	}
+	return words
+}
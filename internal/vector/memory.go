@@ -0,0 +1,67 @@
+package vector
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// MemoryIndex keeps embeddings in a map and searches them by brute force.
+// It is meant for development and tests; everything is lost on restart.
+type MemoryIndex struct {
+	mu      sync.RWMutex
+	records map[string]Record
+}
+
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{records: make(map[string]Record)}
+}
+
+func (m *MemoryIndex) Upsert(ctx context.Context, records ...Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, r := range records {
+		r.Vector = append([]float32(nil), r.Vector...)
+		m.records[r.ID] = r
+	}
+	return nil
+}
+
+func (m *MemoryIndex) Delete(ctx context.Context, ids ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, id := range ids {
+		delete(m.records, id)
+	}
+	return nil
+}
+
+func (m *MemoryIndex) Query(ctx context.Context, vector []float32, k int, filter Filter) ([]Match, error) {
+	if filter.OrganizationID == "" {
+		return nil, ErrNoOrganization
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var matches []Match
+	for _, r := range m.records {
+		if !filter.matches(&r) {
+			continue
+		}
+		matches = append(matches, Match{Record: r, Score: Cosine(vector, r.Vector)})
+	}
+	return topK(matches, k), nil
+}
+
+func topK(matches []Match, k int) []Match {
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].ID < matches[j].ID
+	})
+	if k > 0 && len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches
+}
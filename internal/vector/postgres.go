@@ -0,0 +1,93 @@
+package vector
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/lib/pq"
+	"github.com/resumelens/authservice/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PostgresIndex stores embeddings in the embeddings table and ranks them in
+// process. The organization and job filters are applied in SQL, which keeps
+// the candidate set to one talent pool; a pgvector column can replace the
+// in-process ranking without changing the interface.
+type PostgresIndex struct {
+	db *gorm.DB
+}
+
+func NewPostgresIndex(db *gorm.DB) *PostgresIndex {
+	return &PostgresIndex{db: db}
+}
+
+func (p *PostgresIndex) Upsert(ctx context.Context, records ...Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+	rows := make([]models.Embedding, len(records))
+	for i, r := range records {
+		metadata, err := json.Marshal(r.Metadata)
+		if err != nil {
+			return err
+		}
+		rows[i] = models.Embedding{
+			ID:             r.ID,
+			OrganizationID: r.OrganizationID,
+			JobID:          r.JobID,
+			CandidateID:    r.CandidateID,
+			Model:          r.Model,
+			Vector:         pq.Float32Array(r.Vector),
+			Metadata:       string(metadata),
+		}
+	}
+	return p.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"organization_id", "job_id", "candidate_id", "model", "vector", "metadata", "updated_at"}),
+	}).Create(&rows).Error
+}
+
+func (p *PostgresIndex) Delete(ctx context.Context, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return p.db.WithContext(ctx).Where("id IN ?", ids).Delete(&models.Embedding{}).Error
+}
+
+func (p *PostgresIndex) Query(ctx context.Context, vector []float32, k int, filter Filter) ([]Match, error) {
+	if filter.OrganizationID == "" {
+		return nil, ErrNoOrganization
+	}
+	query := p.db.WithContext(ctx).Model(&models.Embedding{}).Where("organization_id = ?", filter.OrganizationID)
+	if filter.JobID != "" {
+		query = query.Where("job_id = ?", filter.JobID)
+	}
+	if filter.Model != "" {
+		query = query.Where("model = ?", filter.Model)
+	}
+
+	var rows []models.Embedding
+	if err := query.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	matches := make([]Match, 0, len(rows))
+	for _, row := range rows {
+		r := Record{
+			ID:             row.ID,
+			OrganizationID: row.OrganizationID,
+			JobID:          row.JobID,
+			CandidateID:    row.CandidateID,
+			Model:          row.Model,
+			Vector:         row.Vector,
+		}
+		if row.Metadata != "" {
+			if err := json.Unmarshal([]byte(row.Metadata), &r.Metadata); err != nil {
+				return nil, err
+			}
+		}
+		matches = append(matches, Match{Record: r, Score: Cosine(vector, r.Vector)})
+	}
+	return topK(matches, k), nil
+}
@@ -0,0 +1,107 @@
+// Package vector turns text into embeddings and finds the nearest stored
+// embeddings to a query. Both halves are interfaces so a hosted embedding
+// model or vector database can replace the in-process implementations.
+package vector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/resumelens/authservice/internal/config"
+	"gorm.io/gorm"
+)
+
+// Supported values for VECTOR_BACKEND.
+const (
+	BackendPostgres = "postgres"
+	BackendMemory   = "memory"
+)
+
+// Embedder maps texts to fixed-length vectors. Vectors from different
+// embedders (or the same embedder with different dimensions) are not
+// comparable; Model identifies which one produced a vector.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	Dimensions() int
+	Model() string
+}
+
+// Record is one stored embedding. OrganizationID and JobID are used for
+// filtering; Metadata is returned with matches untouched.
+type Record struct {
+	ID             string
+	OrganizationID string
+	JobID          string
+	CandidateID    string
+	Model          string
+	Vector         []float32
+	Metadata       map[string]string
+}
+
+// ErrNoOrganization is returned by queries whose filter has no
+// OrganizationID; embeddings are never compared across organizations.
+var ErrNoOrganization = errors.New("vector: query filter has no organization")
+
+// Filter restricts a query. OrganizationID is required; the other fields
+// match everything when empty.
+type Filter struct {
+	OrganizationID string
+	JobID          string
+	Model          string
+}
+
+type Match struct {
+	Record
+	Score float32 // cosine similarity, -1 to 1
+}
+
+// Index stores embeddings and answers top-k similarity queries.
+type Index interface {
+	Upsert(ctx context.Context, records ...Record) error
+	Delete(ctx context.Context, ids ...string) error
+	Query(ctx context.Context, vector []float32, k int, filter Filter) ([]Match, error)
+}
+
+// NewEmbedder builds the embedder selected by the configuration. Only the
+// local hashed bag-of-words embedder exists today.
+func NewEmbedder(cfg *config.Config) Embedder {
+	return NewHashEmbedder(cfg.EmbeddingDimensions)
+}
+
+// NewIndex builds the index selected by cfg.VectorBackend.
+func NewIndex(cfg *config.Config, db *gorm.DB) (Index, error) {
+	switch cfg.VectorBackend {
+	case BackendPostgres:
+		return NewPostgresIndex(db), nil
+	case BackendMemory:
+		return NewMemoryIndex(), nil
+	default:
+		return nil, fmt.Errorf("unknown vector backend %q", cfg.VectorBackend)
+	}
+}
+
+// Cosine returns the cosine similarity of two vectors, or 0 when their
+// lengths differ or either is all zeros.
+func Cosine(a, b []float32) float32 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return float32(dot / (math.Sqrt(na) * math.Sqrt(nb)))
+}
+
+func (f Filter) matches(r *Record) bool {
+	return f.OrganizationID == r.OrganizationID &&
+		(f.JobID == "" || f.JobID == r.JobID) &&
+		(f.Model == "" || f.Model == r.Model)
+}